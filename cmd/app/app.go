@@ -2,11 +2,17 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"gorm.io/gorm"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 
+	admin_repo "solution/internal/repository/admin"
+
+	admin_service "solution/internal/service/admin"
+
 	b2b_repo "solution/internal/repository/b2b"
 
 	b2b_service "solution/internal/service/b2b"
@@ -16,10 +22,18 @@ import (
 	b2c_service "solution/internal/service/b2c"
 
 	di "solution/internal/service/services"
+	"solution/internal/shared/antifraud"
 	"solution/internal/shared/config"
+	"solution/internal/shared/health"
+	"solution/internal/shared/logger"
+	"solution/internal/shared/models"
+	"solution/internal/shared/openapi"
 	"solution/internal/shared/storage/postgres"
 	"solution/internal/shared/storage/redis"
+	"solution/internal/shared/tracing"
+	"solution/internal/shared/validation"
 	server "solution/internal/transport/http"
+	"strings"
 	"sync"
 	"syscall"
 )
@@ -29,17 +43,34 @@ type App struct {
 	db          *gorm.DB
 	redisClient *redis.RDB
 	appServer   *server.Server
+	ranking     b2c_service.RankingService
 	wg          *sync.WaitGroup
+
+	shutdownTracing func(context.Context) error
 }
 
 func NewApp() (*App, error) {
 
 	cfg, err := config.Init()
 	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := logger.Init(cfg.Logging); err != nil {
 		return nil, err
 	}
+	slog.Info("Effective configuration", "config", cfg)
 
-	db, err := postgres.InitPostgres(cfg.Postgres)
+	if err := validation.Configure(cfg.Validation); err != nil {
+		return nil, err
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := postgres.InitPostgres(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -49,10 +80,28 @@ func NewApp() (*App, error) {
 		return nil, err
 	}
 
+	app, err := Wire(cfg, db, redisClient)
+	if err != nil {
+		return nil, err
+	}
+	app.shutdownTracing = shutdownTracing
+	return app, nil
+}
+
+// Wire регистрирует зависимости в DI контейнере поверх уже открытых соединений и собирает приложение.
+// Конфигурация, логгер и схема БД к этому моменту должны быть готовы - так приложение можно поднять
+// в одном процессе с тестовыми Postgres и Redis и обращаться к нему через Handler.
+func Wire(cfg *config.Config, db *gorm.DB, redisClient *redis.RDB) (*App, error) {
+	config.SetAuth(cfg.Auth)
+
 	if err := registerEnv(cfg, redisClient); err != nil {
 		return nil, err
 	}
 
+	if err := registerHealth(cfg, db, redisClient); err != nil {
+		return nil, err
+	}
+
 	if err := registerRepositories(db, redisClient); err != nil {
 		return nil, err
 	}
@@ -61,35 +110,85 @@ func NewApp() (*App, error) {
 		return nil, err
 	}
 
+	if err := di.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid service graph: %w", err)
+	}
+
+	if err := ensureAdmin(cfg.Admin); err != nil {
+		return nil, fmt.Errorf("failed to create admin account: %w", err)
+	}
+
 	var appServer *server.Server
 	if err := di.GetService(&appServer); err != nil {
 		return nil, err
 	}
 
+	var ranking b2c_service.RankingService
+	if err := di.GetService(&ranking); err != nil {
+		return nil, err
+	}
+
 	return &App{
 		cfg:         cfg,
 		db:          db,
 		redisClient: redisClient,
 		appServer:   appServer,
+		ranking:     ranking,
 		wg:          &sync.WaitGroup{},
+
+		shutdownTracing: func(context.Context) error { return nil },
 	}, nil
 }
 
+// Handler возвращает полностью настроенный роутер без запуска HTTP сервера
+func (a *App) Handler() http.Handler {
+	return a.appServer.Handler()
+}
+
 func registerEnv(cfg *config.Config, redisClient *redis.RDB) error {
 	envRegistrations := map[string]func() error{
 		"config": func() error { return di.AddSingleton(func() *config.Config { return cfg }) },
 		"redis":  func() error { return di.AddSingleton(func() *redis.RDB { return redisClient }) },
+		"antifraud": func() error {
+			return di.AddSingleton(func() antifraud.Client { return antifraud.New(cfg.Antifraud, redisClient) })
+		},
+		"openapi": func() error {
+			spec, err := openapi.Load(cfg.OpenAPI.SpecFile)
+			if err != nil {
+				return err
+			}
+			return di.AddSingleton(func() *openapi.Spec { return spec })
+		},
 	}
 	for name, register := range envRegistrations {
 		if err := register(); err != nil {
-			log.Fatalf("Failed to register %s: %v", name, err)
+			slog.Error("Failed to register service", "service", name, "error", err)
 			return err
 		}
-		log.Println(name, "registered")
+		slog.Debug("Service registered", "service", name)
 	}
 	return nil
 }
 
+// registerHealth собирает проверки готовности поверх уже открытых соединений с Postgres и Redis
+func registerHealth(cfg *config.Config, db *gorm.DB, redisClient *redis.RDB) error {
+	checks := []health.Check{
+		{Name: "postgres", Run: func(ctx context.Context) error { return postgres.Ping(ctx, db) }},
+		{Name: "migrations", Run: func(ctx context.Context) error { return postgres.CheckMigrations(ctx, db) }},
+		{Name: "redis", Run: func(ctx context.Context) error { return redisClient.Client.Ping(ctx).Err() }},
+	}
+	if cfg.Antifraud.Address != "" {
+		checks = append(checks, health.Check{
+			Name:     "antifraud",
+			Optional: cfg.Health.AntifraudOptional,
+			Run:      health.DialCheck(cfg.Antifraud.Address),
+		})
+	}
+
+	checker := health.NewChecker(cfg.Health.CheckTimeout, checks...)
+	return di.AddSingleton(func() *health.Checker { return checker })
+}
+
 func registerRepositories(db *gorm.DB, redisClient *redis.RDB) error {
 	repoRegistrations := map[string]func() error{
 		"b2bAuthRepo": func() error {
@@ -98,6 +197,9 @@ func registerRepositories(db *gorm.DB, redisClient *redis.RDB) error {
 		"b2bPromoRepo": func() error {
 			return di.AddSingleton(func() b2b_repo.PromoRepository { return b2b_repo.NewPromoRepository(db, redisClient) })
 		},
+		"b2bApiKeyRepo": func() error {
+			return di.AddSingleton(func() b2b_repo.ApiKeyRepository { return b2b_repo.NewApiKeyRepository(db, redisClient) })
+		},
 		"b2cAuthRepo": func() error {
 			return di.AddSingleton(func() b2c_repo.AuthRepository { return b2c_repo.NewAuthRepository(db, redisClient) })
 		},
@@ -107,13 +209,25 @@ func registerRepositories(db *gorm.DB, redisClient *redis.RDB) error {
 		"b2cPromoRepo": func() error {
 			return di.AddSingleton(func() b2c_repo.PromoRepository { return b2c_repo.NewPromoRepository(db, redisClient) })
 		},
+		"b2cActivationLimiter": func() error {
+			return di.AddSingleton(func() b2c_repo.ActivationLimiter { return b2c_repo.NewActivationLimiter(redisClient) })
+		},
+		"b2cRankingRepo": func() error {
+			return di.AddSingleton(func() b2c_repo.RankingRepository { return b2c_repo.NewRankingRepository(db, redisClient) })
+		},
+		"adminAuthRepo": func() error {
+			return di.AddSingleton(func() admin_repo.AuthRepository { return admin_repo.NewAuthRepository(db, redisClient) })
+		},
+		"adminRepo": func() error {
+			return di.AddSingleton(func() admin_repo.AdminRepository { return admin_repo.NewAdminRepository(db, redisClient) })
+		},
 	}
 	for name, register := range repoRegistrations {
 		if err := register(); err != nil {
-			log.Fatalf("Failed to register %s: %v", name, err)
+			slog.Error("Failed to register service", "service", name, "error", err)
 			return err
 		}
-		log.Println(name, "registered")
+		slog.Debug("Service registered", "service", name)
 	}
 	return nil
 }
@@ -126,6 +240,11 @@ func registerServices(cfg *config.Config) error {
 		"b2bPromoService": func() error {
 			return di.AddSingleton(func(repo b2b_repo.PromoRepository) b2b_service.PromoService { return b2b_service.NewPromoService(repo) })
 		},
+		"b2bApiKeyService": func() error {
+			return di.AddSingleton(func(repo b2b_repo.ApiKeyRepository) b2b_service.ApiKeyService {
+				return b2b_service.NewApiKeyService(repo)
+			})
+		},
 		"b2cAuthService": func() error {
 			return di.AddSingleton(func(repo b2c_repo.AuthRepository) b2c_service.AuthService { return b2c_service.NewAuthService(repo) })
 		},
@@ -135,19 +254,54 @@ func registerServices(cfg *config.Config) error {
 			})
 		},
 		"b2cPromoService": func() error {
-			return di.AddSingleton(func(repo b2c_repo.PromoRepository) b2c_service.PromoService { return b2c_service.NewPromoService(repo) })
+			return di.AddSingleton(func(repo b2c_repo.PromoRepository, rankingRepo b2c_repo.RankingRepository) b2c_service.PromoService {
+				return b2c_service.NewPromoService(repo, rankingRepo, cfg.Ranking)
+			})
+		},
+		"b2cActivationService": func() error {
+			return di.AddSingleton(func(repo b2c_repo.PromoRepository, limiter b2c_repo.ActivationLimiter, checker antifraud.Client) b2c_service.ActivationService {
+				return b2c_service.NewActivationService(repo, limiter, checker)
+			})
+		},
+		"b2cRankingService": func() error {
+			return di.AddSingleton(func(repo b2c_repo.RankingRepository) b2c_service.RankingService {
+				return b2c_service.NewRankingService(repo, cfg.Ranking)
+			})
+		},
+		"adminAuthService": func() error {
+			return di.AddSingleton(func(repo admin_repo.AuthRepository) admin_service.AuthService {
+				return admin_service.NewAuthService(repo)
+			})
+		},
+		"adminService": func() error {
+			return di.AddSingleton(func(repo admin_repo.AdminRepository) admin_service.AdminService {
+				return admin_service.NewAdminService(repo)
+			})
 		},
 	}
 	for name, register := range serviceRegistrations {
 		if err := register(); err != nil {
-			log.Fatalf("Failed to register %s: %v", name, err)
+			slog.Error("Failed to register service", "service", name, "error", err)
 			return err
 		}
-		log.Println(name, "registered")
+		slog.Debug("Service registered", "service", name)
+	}
+
+	// субъект запроса заполняется middleware авторизации в рамках области запроса
+	if err := di.AddScoped(func() *models.Principal { return &models.Principal{} }); err != nil {
+		return err
 	}
 
-	err := di.AddSingleton(func(cfg *config.Config) *server.Server {
-		return server.NewServer(cfg)
+	// использование API ключа записывается при закрытии области, после ответа
+	err := di.AddScoped(func(ctx context.Context, repo b2b_repo.ApiKeyRepository) *b2b_service.ApiKeyUsage {
+		return b2b_service.NewApiKeyUsage(ctx, repo)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = di.AddSingleton(func(cfg *config.Config, checker *health.Checker, spec *openapi.Spec) *server.Server {
+		return server.NewServer(cfg, checker, spec)
 	})
 	if err != nil {
 		return err
@@ -156,6 +310,20 @@ func registerServices(cfg *config.Config) error {
 	return nil
 }
 
+// ensureAdmin создаёт администратора из конфигурации; без admin.email админка доступна
+// только уже существующим администраторам
+func ensureAdmin(cfg *config.Admin) error {
+	if cfg.Email == "" {
+		return nil
+	}
+
+	var auth admin_service.AuthService
+	if err := di.GetService(&auth); err != nil {
+		return err
+	}
+	return auth.EnsureAdmin(context.Background(), strings.ToLower(cfg.Email), cfg.Password)
+}
+
 func (a *App) Run() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -164,16 +332,31 @@ func (a *App) Run() {
 	go func() {
 		defer a.wg.Done()
 		if err := a.appServer.StartHttpServer(ctx); err != nil {
-			log.Fatalf("Failed to start HTTP server: %v", err)
+			slog.Error("Failed to start HTTP server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		// пересчёт скоров - фоновая задача, таймаут запроса клиента к ней не относится
+		a.ranking.Run(postgres.WithoutStatementTimeout(ctx))
+	}()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
-	log.Println("Shutting down HTTP server...")
+	slog.Info("Shutting down HTTP server")
 
 	cancel()
 	a.wg.Wait()
-	log.Println("Redis gracefully stopped.")
+
+	// отправляем оставшиеся спаны, не дольше таймаута остановки сервера
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := a.shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	slog.Info("Application stopped")
 }
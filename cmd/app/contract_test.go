@@ -0,0 +1,613 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"solution/internal/shared/config"
+	"solution/internal/shared/logger"
+	"solution/internal/shared/openapi"
+	"solution/internal/shared/storage/postgres/pgtest"
+	"solution/internal/shared/storage/redis/redistest"
+)
+
+// specFile - api.yml в корне репозитория относительно пакета
+const specFile = "../../api.yml"
+
+const (
+	adminEmail = "admin@contract-test.com"
+	password   = "Contract1!"
+)
+
+// contractApp - приложение из Wire поверх тестовых Postgres и Redis. Ответы сверяются с api.yml
+// middleware проверки ответов: нарушение контракта превращается в 500 и роняет проверку статуса.
+type contractApp struct {
+	handler http.Handler
+	spec    *openapi.Spec
+	covered map[openapi.Operation]bool
+}
+
+// newContractApp собирает приложение через Wire. DI контейнер глобальный, поэтому Wire вызывается
+// один раз на тестовый бинарник.
+func newContractApp(t *testing.T) *contractApp {
+	t.Helper()
+
+	db := pgtest.Open(t)
+	rdb, mr := redistest.Open(t)
+
+	cfg, err := config.Load([]string{
+		"-postgres.conn=" + os.Getenv(pgtest.DSNEnv),
+		"-redis.host=" + mr.Host(),
+		"-redis.port=" + mr.Port(),
+		"-auth.secret=contract-test-secret",
+		"-openapi.spec_file=" + specFile,
+		"-openapi.validate_responses=true",
+		"-admin.email=" + adminEmail,
+		"-admin.password=" + password,
+	})
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	app, err := Wire(cfg, db, rdb)
+	if err != nil {
+		t.Fatalf("wire app: %v", err)
+	}
+
+	spec, err := openapi.Load(specFile)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	return &contractApp{handler: app.Handler(), spec: spec, covered: map[openapi.Operation]bool{}}
+}
+
+// do выполняет запрос и отмечает операцию api.yml как проверенную. body - строка передаётся как есть,
+// остальное кодируется в JSON; header - пары имя, значение.
+func (a *contractApp) do(t *testing.T, method, path, auth string, body any, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	a.handler.ServeHTTP(rec, req)
+
+	if op, ok := a.operation(method, path); ok {
+		a.covered[op] = true
+	}
+	return rec
+}
+
+// expect выполняет запрос и проверяет статус, тело ответа возвращается для разбора
+func (a *contractApp) expect(t *testing.T, status int, method, path, auth string, body any, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := a.do(t, method, path, auth, body, header...)
+	if rec.Code != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	return rec
+}
+
+// operation находит операцию api.yml для пути запроса; статический сегмент важнее параметра,
+// поэтому /user/promo/history не считается /user/promo/{id}
+func (a *contractApp) operation(method, path string) (openapi.Operation, bool) {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+
+	var found openapi.Operation
+	best := -1
+	for _, op := range a.spec.Operations() {
+		if op.Method != method {
+			continue
+		}
+		pattern := strings.Split(op.Path, "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+		static := 0
+		for i := range pattern {
+			if strings.HasPrefix(pattern[i], "{") {
+				continue
+			}
+			if pattern[i] != segments[i] {
+				static = -1
+				break
+			}
+			static++
+		}
+		if static > best {
+			found, best = op, static
+		}
+	}
+	return found, best >= 0
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode response %s: %v", rec.Body.String(), err)
+	}
+	return v
+}
+
+func bearer(token string) string { return "Bearer " + token }
+
+func apiKey(key string) string { return "ApiKey " + key }
+
+func (a *contractApp) signUpCompany(t *testing.T, name, email string) (string, string) {
+	t.Helper()
+
+	rec := a.expect(t, http.StatusOK, http.MethodPost, "/api/business/auth/sign-up", "",
+		map[string]any{"name": name, "email": email, "password": password})
+	resp := decode[struct {
+		Token     string `json:"token"`
+		CompanyID string `json:"company_id"`
+	}](t, rec)
+	return resp.Token, resp.CompanyID
+}
+
+func (a *contractApp) signUpUser(t *testing.T, name, email string, age int) string {
+	t.Helper()
+
+	rec := a.expect(t, http.StatusOK, http.MethodPost, "/api/user/auth/sign-up", "", map[string]any{
+		"name": name, "surname": "Contract", "email": email, "password": password,
+		"other": map[string]any{"age": age, "country": "ru"},
+	})
+	return decode[struct {
+		Token string `json:"token"`
+	}](t, rec).Token
+}
+
+func (a *contractApp) createPromo(t *testing.T, token, description, code string, target map[string]any) string {
+	t.Helper()
+
+	rec := a.expect(t, http.StatusCreated, http.MethodPost, "/api/business/promo", bearer(token), map[string]any{
+		"description": description, "mode": "COMMON", "promo_common": code, "max_count": 10, "target": target,
+	})
+	return decode[struct {
+		ID string `json:"id"`
+	}](t, rec).ID
+}
+
+func (a *contractApp) addComment(t *testing.T, token, promoID, text string) string {
+	t.Helper()
+
+	rec := a.expect(t, http.StatusCreated, http.MethodPost, "/api/user/promo/"+promoID+"/comments", bearer(token),
+		map[string]any{"text": text})
+	return decode[struct {
+		ID string `json:"id"`
+	}](t, rec).ID
+}
+
+// contractCase - запрос к API и ожидаемый ответ
+type contractCase struct {
+	name   string
+	method string
+	path   string
+	auth   string
+	body   any
+	header []string
+	status int
+	// total - ожидаемый X-Total-Count, пусто - заголовок не проверяется
+	total string
+}
+
+func (a *contractApp) run(t *testing.T, cases []contractCase) {
+	t.Helper()
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := a.do(t, tc.method, tc.path, tc.auth, tc.body, tc.header...)
+			if rec.Code != tc.status {
+				t.Fatalf("%s %s: status %d, want %d: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body.String())
+			}
+			if tc.total != "" {
+				if got := rec.Header().Get("X-Total-Count"); got != tc.total {
+					t.Errorf("X-Total-Count = %q, want %q", got, tc.total)
+				}
+			}
+		})
+	}
+}
+
+// TestContract проходит все операции api.yml через роутер приложения: успешные ответы, X-Total-Count списков
+// и ошибки 400/401/403/404 там, где их даёт обработчик. Случаи идут по порядку и опираются на состояние,
+// оставленное предыдущими.
+func TestContract(t *testing.T) {
+	a := newContractApp(t)
+
+	level := logger.Level()
+	t.Cleanup(func() { logger.SetLevel(strings.ToLower(level.String())) })
+
+	acmeToken, _ := a.signUpCompany(t, "Acme Corp", "acme@contract-test.com")
+	globexToken, _ := a.signUpCompany(t, "Globex Corp", "globex@contract-test.com")
+	_, initechID := a.signUpCompany(t, "Initech Corp", "initech@contract-test.com")
+
+	aliceToken := a.signUpUser(t, "Alice", "alice@contract-test.com", 25)
+	bobToken := a.signUpUser(t, "Bob", "bob@contract-test.com", 30)
+	carolToken := a.signUpUser(t, "Carol", "carol@contract-test.com", 35)
+
+	adminRec := a.expect(t, http.StatusOK, http.MethodPost, "/api/admin/auth/sign-in", "",
+		map[string]any{"email": adminEmail, "password": password})
+	adminToken := decode[struct {
+		Token string `json:"token"`
+	}](t, adminRec).Token
+
+	promoID := a.createPromo(t, acmeToken, "Летняя скидка на обувь для всей семьи", "SUMMER2026", map[string]any{})
+	seniorPromoID := a.createPromo(t, acmeToken, "Скидка для пенсионеров на лекарства", "SENIOR2026", map[string]any{"age_from": 60})
+	globexPromoID := a.createPromo(t, globexToken, "Бесплатная доставка при заказе от 1000 рублей", "GLOBEX2026", map[string]any{})
+
+	aliceCommentID := a.addComment(t, aliceToken, promoID, "Отличная скидка, спасибо!")
+	bobCommentID := a.addComment(t, bobToken, promoID, "Комментарий для модерации")
+
+	keyRec := a.expect(t, http.StatusCreated, http.MethodPost, "/api/business/api-keys", bearer(acmeToken),
+		map[string]any{"name": "reader", "scopes": []string{"promo:read"}})
+	key := decode[struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}](t, keyRec)
+
+	carolID := decode[[]struct {
+		ID string `json:"id"`
+	}](t, a.expect(t, http.StatusOK, http.MethodGet, "/api/admin/users?query=carol@contract-test.com", bearer(adminToken), nil))[0].ID
+
+	unknownID := uuid.NewString()
+	const badToken = "Bearer invalid-token"
+	const mergePatch = "application/merge-patch+json"
+
+	t.Run("ping", func(t *testing.T) {
+		a.run(t, []contractCase{
+			{name: "ok", method: http.MethodGet, path: "/api/ping", status: http.StatusOK},
+		})
+	})
+
+	t.Run("business auth", func(t *testing.T) {
+		a.run(t, []contractCase{
+			{name: "sign up", method: http.MethodPost, path: "/api/business/auth/sign-up",
+				body: map[string]any{"name": "Umbrella Corp", "email": "umbrella@contract-test.com", "password": password}, status: http.StatusOK},
+			{name: "sign up invalid", method: http.MethodPost, path: "/api/business/auth/sign-up",
+				body: map[string]any{"name": "U", "email": "umbrella", "password": "short"}, status: http.StatusBadRequest},
+			{name: "sign up duplicate email", method: http.MethodPost, path: "/api/business/auth/sign-up",
+				body: map[string]any{"name": "Acme Again", "email": "acme@contract-test.com", "password": password}, status: http.StatusConflict},
+			{name: "sign in", method: http.MethodPost, path: "/api/business/auth/sign-in",
+				body: map[string]any{"email": "umbrella@contract-test.com", "password": password}, status: http.StatusOK},
+			{name: "sign in invalid", method: http.MethodPost, path: "/api/business/auth/sign-in",
+				body: map[string]any{"email": "umbrella"}, status: http.StatusBadRequest},
+			{name: "sign in wrong password", method: http.MethodPost, path: "/api/business/auth/sign-in",
+				body: map[string]any{"email": "umbrella@contract-test.com", "password": "Wrong1!pass"}, status: http.StatusUnauthorized},
+		})
+	})
+
+	t.Run("business promo", func(t *testing.T) {
+		promo := "/api/business/promo/" + promoID
+		a.run(t, []contractCase{
+			{name: "create", method: http.MethodPost, path: "/api/business/promo", auth: bearer(acmeToken),
+				body: map[string]any{"description": "Кешбэк десять процентов на первую покупку", "mode": "COMMON",
+					"promo_common": "CASHBACK10", "max_count": 5, "target": map[string]any{}}, status: http.StatusCreated},
+			{name: "create invalid", method: http.MethodPost, path: "/api/business/promo", auth: bearer(acmeToken),
+				body: map[string]any{"mode": "COMMON"}, status: http.StatusBadRequest},
+			{name: "create without token", method: http.MethodPost, path: "/api/business/promo",
+				body: map[string]any{"mode": "COMMON"}, status: http.StatusUnauthorized},
+			{name: "create with read-only api key", method: http.MethodPost, path: "/api/business/promo", auth: apiKey(key.Key),
+				body: map[string]any{"description": "Ключ без promo:write", "mode": "COMMON",
+					"promo_common": "NOWRITE1", "max_count": 5, "target": map[string]any{}}, status: http.StatusForbidden},
+
+			{name: "list", method: http.MethodGet, path: "/api/business/promo?limit=2", auth: bearer(acmeToken), status: http.StatusOK, total: "3"},
+			{name: "list by api key", method: http.MethodGet, path: "/api/business/promo", auth: apiKey(key.Key), status: http.StatusOK, total: "3"},
+			{name: "list invalid limit", method: http.MethodGet, path: "/api/business/promo?limit=many", auth: bearer(acmeToken), status: http.StatusBadRequest},
+			{name: "list invalid token", method: http.MethodGet, path: "/api/business/promo", auth: badToken, status: http.StatusUnauthorized},
+
+			{name: "audience estimate", method: http.MethodPost, path: "/api/business/promo/audience-estimate", auth: bearer(acmeToken),
+				body: map[string]any{"age_from": 20, "age_until": 40}, status: http.StatusOK},
+			{name: "audience estimate invalid", method: http.MethodPost, path: "/api/business/promo/audience-estimate", auth: bearer(acmeToken),
+				body: map[string]any{"age_from": 40, "age_until": 20}, status: http.StatusBadRequest},
+			{name: "audience estimate without token", method: http.MethodPost, path: "/api/business/promo/audience-estimate",
+				body: map[string]any{}, status: http.StatusUnauthorized},
+
+			{name: "get", method: http.MethodGet, path: promo, auth: bearer(acmeToken), status: http.StatusOK},
+			{name: "get foreign", method: http.MethodGet, path: promo, auth: bearer(globexToken), status: http.StatusForbidden},
+			{name: "get unknown", method: http.MethodGet, path: "/api/business/promo/" + unknownID, auth: bearer(acmeToken), status: http.StatusNotFound},
+			{name: "get without token", method: http.MethodGet, path: promo, status: http.StatusUnauthorized},
+
+			{name: "patch", method: http.MethodPatch, path: promo, auth: bearer(acmeToken), header: []string{"Content-Type", mergePatch},
+				body: `{"description": "Осенняя распродажа обуви для всей семьи"}`, status: http.StatusOK},
+			{name: "patch invalid", method: http.MethodPatch, path: promo, auth: bearer(acmeToken), header: []string{"Content-Type", mergePatch},
+				body: `{"description": "short"}`, status: http.StatusBadRequest},
+			{name: "patch stale version", method: http.MethodPatch, path: promo, auth: bearer(acmeToken),
+				header: []string{"Content-Type", mergePatch, "If-Match", `"999"`}, body: `{"max_count": 20}`, status: http.StatusPreconditionFailed},
+			{name: "patch foreign", method: http.MethodPatch, path: promo, auth: bearer(globexToken), header: []string{"Content-Type", mergePatch},
+				body: `{"max_count": 20}`, status: http.StatusForbidden},
+			{name: "patch unknown", method: http.MethodPatch, path: "/api/business/promo/" + unknownID, auth: bearer(acmeToken),
+				header: []string{"Content-Type", mergePatch}, body: `{"max_count": 20}`, status: http.StatusNotFound},
+			{name: "patch without token", method: http.MethodPatch, path: promo, header: []string{"Content-Type", mergePatch},
+				body: `{"max_count": 20}`, status: http.StatusUnauthorized},
+
+			{name: "codes", method: http.MethodPut, path: promo + "/codes", auth: bearer(acmeToken),
+				body: map[string]any{"promo_common": "AUTUMN2026"}, status: http.StatusOK},
+			{name: "codes invalid", method: http.MethodPut, path: promo + "/codes", auth: bearer(acmeToken),
+				body: map[string]any{"mode": "SOMETIMES"}, status: http.StatusBadRequest},
+			{name: "codes foreign", method: http.MethodPut, path: promo + "/codes", auth: bearer(globexToken),
+				body: map[string]any{"promo_common": "FOREIGN2026"}, status: http.StatusForbidden},
+			{name: "codes unknown", method: http.MethodPut, path: "/api/business/promo/" + unknownID + "/codes", auth: bearer(acmeToken),
+				body: map[string]any{"promo_common": "UNKNOWN2026"}, status: http.StatusNotFound},
+			{name: "codes without token", method: http.MethodPut, path: promo + "/codes",
+				body: map[string]any{"promo_common": "NOAUTH2026"}, status: http.StatusUnauthorized},
+
+			{name: "stat", method: http.MethodGet, path: promo + "/stat", auth: bearer(acmeToken), status: http.StatusOK},
+			{name: "stat foreign", method: http.MethodGet, path: promo + "/stat", auth: bearer(globexToken), status: http.StatusForbidden},
+			{name: "stat without stats scope", method: http.MethodGet, path: promo + "/stat", auth: apiKey(key.Key), status: http.StatusForbidden},
+			{name: "stat unknown", method: http.MethodGet, path: "/api/business/promo/" + unknownID + "/stat", auth: bearer(acmeToken), status: http.StatusNotFound},
+			{name: "stat without token", method: http.MethodGet, path: promo + "/stat", status: http.StatusUnauthorized},
+
+			// создание, описание и коды - три записи; отклонённые изменения истории не пишут
+			{name: "history", method: http.MethodGet, path: promo + "/history?limit=1", auth: bearer(acmeToken), status: http.StatusOK, total: "3"},
+			{name: "history invalid offset", method: http.MethodGet, path: promo + "/history?offset=-1", auth: bearer(acmeToken), status: http.StatusBadRequest},
+			{name: "history foreign", method: http.MethodGet, path: promo + "/history", auth: bearer(globexToken), status: http.StatusForbidden},
+			{name: "history unknown", method: http.MethodGet, path: "/api/business/promo/" + unknownID + "/history", auth: bearer(acmeToken), status: http.StatusNotFound},
+			{name: "history without token", method: http.MethodGet, path: promo + "/history", status: http.StatusUnauthorized},
+		})
+
+		t.Run("not modified", func(t *testing.T) {
+			tag := a.expect(t, http.StatusOK, http.MethodGet, promo, bearer(acmeToken), nil).Header().Get("ETag")
+			a.expect(t, http.StatusNotModified, http.MethodGet, promo, bearer(acmeToken), nil, "If-None-Match", tag)
+		})
+	})
+
+	t.Run("business api keys", func(t *testing.T) {
+		a.run(t, []contractCase{
+			{name: "create", method: http.MethodPost, path: "/api/business/api-keys", auth: bearer(acmeToken),
+				body: map[string]any{"name": "stats", "scopes": []string{"stats:read"}}, status: http.StatusCreated},
+			{name: "create invalid scope", method: http.MethodPost, path: "/api/business/api-keys", auth: bearer(acmeToken),
+				body: map[string]any{"name": "admin", "scopes": []string{"promo:delete"}}, status: http.StatusBadRequest},
+			{name: "create by api key", method: http.MethodPost, path: "/api/business/api-keys", auth: apiKey(key.Key),
+				body: map[string]any{"name": "nested", "scopes": []string{"promo:read"}}, status: http.StatusForbidden},
+			{name: "create without token", method: http.MethodPost, path: "/api/business/api-keys",
+				body: map[string]any{"name": "anonymous", "scopes": []string{"promo:read"}}, status: http.StatusUnauthorized},
+
+			{name: "list", method: http.MethodGet, path: "/api/business/api-keys", auth: bearer(acmeToken), status: http.StatusOK},
+			{name: "list by api key", method: http.MethodGet, path: "/api/business/api-keys", auth: apiKey(key.Key), status: http.StatusForbidden},
+			{name: "list without token", method: http.MethodGet, path: "/api/business/api-keys", status: http.StatusUnauthorized},
+
+			{name: "revoke by api key", method: http.MethodDelete, path: "/api/business/api-keys/" + key.ID, auth: apiKey(key.Key), status: http.StatusForbidden},
+			{name: "revoke unknown", method: http.MethodDelete, path: "/api/business/api-keys/" + unknownID, auth: bearer(acmeToken), status: http.StatusNotFound},
+			{name: "revoke without token", method: http.MethodDelete, path: "/api/business/api-keys/" + key.ID, status: http.StatusUnauthorized},
+			{name: "revoke", method: http.MethodDelete, path: "/api/business/api-keys/" + key.ID, auth: bearer(acmeToken), status: http.StatusOK},
+			{name: "revoked key is rejected", method: http.MethodGet, path: "/api/business/promo", auth: apiKey(key.Key), status: http.StatusUnauthorized},
+		})
+	})
+
+	t.Run("user auth", func(t *testing.T) {
+		user := map[string]any{
+			"name": "Dave", "surname": "Contract", "email": "dave@contract-test.com", "password": password,
+			"other": map[string]any{"age": 40, "country": "fr"},
+		}
+		a.run(t, []contractCase{
+			{name: "sign up", method: http.MethodPost, path: "/api/user/auth/sign-up", body: user, status: http.StatusOK},
+			{name: "sign up invalid", method: http.MethodPost, path: "/api/user/auth/sign-up",
+				body: map[string]any{"name": "Eve", "email": "eve"}, status: http.StatusBadRequest},
+			{name: "sign up duplicate email", method: http.MethodPost, path: "/api/user/auth/sign-up", body: user, status: http.StatusConflict},
+			{name: "sign in", method: http.MethodPost, path: "/api/user/auth/sign-in",
+				body: map[string]any{"email": "dave@contract-test.com", "password": password}, status: http.StatusOK},
+			{name: "sign in invalid", method: http.MethodPost, path: "/api/user/auth/sign-in",
+				body: map[string]any{"password": password}, status: http.StatusBadRequest},
+			{name: "sign in wrong password", method: http.MethodPost, path: "/api/user/auth/sign-in",
+				body: map[string]any{"email": "dave@contract-test.com", "password": "Wrong1!pass"}, status: http.StatusUnauthorized},
+		})
+	})
+
+	t.Run("user profile", func(t *testing.T) {
+		a.run(t, []contractCase{
+			{name: "get", method: http.MethodGet, path: "/api/user/profile", auth: bearer(aliceToken), status: http.StatusOK},
+			{name: "get invalid token", method: http.MethodGet, path: "/api/user/profile", auth: badToken, status: http.StatusUnauthorized},
+			{name: "patch", method: http.MethodPatch, path: "/api/user/profile", auth: bearer(aliceToken),
+				header: []string{"Content-Type", mergePatch}, body: `{"surname": "Updated"}`, status: http.StatusOK},
+			{name: "patch clears name", method: http.MethodPatch, path: "/api/user/profile", auth: bearer(aliceToken),
+				header: []string{"Content-Type", mergePatch}, body: `{"name": null}`, status: http.StatusBadRequest},
+			{name: "patch stale version", method: http.MethodPatch, path: "/api/user/profile", auth: bearer(aliceToken),
+				header: []string{"Content-Type", mergePatch, "If-Match", `"999"`}, body: `{"surname": "Stale"}`, status: http.StatusPreconditionFailed},
+			{name: "patch without token", method: http.MethodPatch, path: "/api/user/profile",
+				header: []string{"Content-Type", mergePatch}, body: `{"surname": "Anonymous"}`, status: http.StatusUnauthorized},
+		})
+
+		t.Run("not modified", func(t *testing.T) {
+			tag := a.expect(t, http.StatusOK, http.MethodGet, "/api/user/profile", bearer(aliceToken), nil).Header().Get("ETag")
+			a.expect(t, http.StatusNotModified, http.MethodGet, "/api/user/profile", bearer(aliceToken), nil, "If-None-Match", tag)
+		})
+	})
+
+	t.Run("user promo", func(t *testing.T) {
+		promo := "/api/user/promo/" + promoID
+		unknown := "/api/user/promo/" + unknownID
+		a.run(t, []contractCase{
+			// промокод для пенсионеров Alice не показывается
+			{name: "feed", method: http.MethodGet, path: "/api/user/feed?limit=1", auth: bearer(aliceToken), status: http.StatusOK, total: "3"},
+			{name: "feed invalid sort", method: http.MethodGet, path: "/api/user/feed?sort=random", auth: bearer(aliceToken), status: http.StatusBadRequest},
+			{name: "feed without token", method: http.MethodGet, path: "/api/user/feed", status: http.StatusUnauthorized},
+
+			{name: "search", method: http.MethodGet, path: "/api/user/search?q=" + url.QueryEscape("распродажа"), auth: bearer(aliceToken), status: http.StatusOK, total: "1"},
+			{name: "search without query", method: http.MethodGet, path: "/api/user/search", auth: bearer(aliceToken), status: http.StatusBadRequest},
+			{name: "search without token", method: http.MethodGet, path: "/api/user/search?q=" + url.QueryEscape("скидка"), status: http.StatusUnauthorized},
+
+			{name: "get", method: http.MethodGet, path: promo, auth: bearer(aliceToken), status: http.StatusOK},
+			{name: "get unknown", method: http.MethodGet, path: unknown, auth: bearer(aliceToken), status: http.StatusNotFound},
+			{name: "get without token", method: http.MethodGet, path: promo, status: http.StatusUnauthorized},
+
+			{name: "like", method: http.MethodPost, path: promo + "/like", auth: bearer(aliceToken), status: http.StatusOK},
+			{name: "like unknown", method: http.MethodPost, path: unknown + "/like", auth: bearer(aliceToken), status: http.StatusNotFound},
+			{name: "like without token", method: http.MethodPost, path: promo + "/like", status: http.StatusUnauthorized},
+			{name: "unlike", method: http.MethodDelete, path: promo + "/like", auth: bearer(aliceToken), status: http.StatusOK},
+			{name: "unlike unknown", method: http.MethodDelete, path: unknown + "/like", auth: bearer(aliceToken), status: http.StatusNotFound},
+			{name: "unlike without token", method: http.MethodDelete, path: promo + "/like", status: http.StatusUnauthorized},
+
+			{name: "comment", method: http.MethodPost, path: promo + "/comments", auth: bearer(aliceToken),
+				body: map[string]any{"text": "Воспользовалась, всё работает"}, status: http.StatusCreated},
+			{name: "comment too short", method: http.MethodPost, path: promo + "/comments", auth: bearer(aliceToken),
+				body: map[string]any{"text": "ok"}, status: http.StatusBadRequest},
+			{name: "comment unknown promo", method: http.MethodPost, path: unknown + "/comments", auth: bearer(aliceToken),
+				body: map[string]any{"text": "Такого промокода нет"}, status: http.StatusNotFound},
+			{name: "comment without token", method: http.MethodPost, path: promo + "/comments",
+				body: map[string]any{"text": "Анонимный комментарий"}, status: http.StatusUnauthorized},
+
+			{name: "comments", method: http.MethodGet, path: promo + "/comments?limit=1", auth: bearer(aliceToken), status: http.StatusOK, total: "3"},
+			{name: "comments unknown promo", method: http.MethodGet, path: unknown + "/comments", auth: bearer(aliceToken), status: http.StatusNotFound},
+			{name: "comments without token", method: http.MethodGet, path: promo + "/comments", status: http.StatusUnauthorized},
+
+			{name: "get comment", method: http.MethodGet, path: promo + "/comments/" + aliceCommentID, auth: bearer(bobToken), status: http.StatusOK},
+			{name: "get unknown comment", method: http.MethodGet, path: promo + "/comments/" + unknownID, auth: bearer(bobToken), status: http.StatusNotFound},
+			{name: "get comment without token", method: http.MethodGet, path: promo + "/comments/" + aliceCommentID, status: http.StatusUnauthorized},
+
+			{name: "edit comment", method: http.MethodPut, path: promo + "/comments/" + aliceCommentID, auth: bearer(aliceToken),
+				body: map[string]any{"text": "Отличная скидка, спасибо ещё раз!"}, status: http.StatusOK},
+			{name: "edit comment without text", method: http.MethodPut, path: promo + "/comments/" + aliceCommentID, auth: bearer(aliceToken),
+				body: map[string]any{}, status: http.StatusBadRequest},
+			{name: "edit foreign comment", method: http.MethodPut, path: promo + "/comments/" + aliceCommentID, auth: bearer(bobToken),
+				body: map[string]any{"text": "Чужой комментарий"}, status: http.StatusForbidden},
+			{name: "edit unknown comment", method: http.MethodPut, path: promo + "/comments/" + unknownID, auth: bearer(aliceToken),
+				body: map[string]any{"text": "Такого комментария нет"}, status: http.StatusNotFound},
+			{name: "edit comment without token", method: http.MethodPut, path: promo + "/comments/" + aliceCommentID,
+				body: map[string]any{"text": "Анонимная правка"}, status: http.StatusUnauthorized},
+
+			{name: "delete foreign comment", method: http.MethodDelete, path: promo + "/comments/" + aliceCommentID, auth: bearer(bobToken), status: http.StatusForbidden},
+			{name: "delete unknown comment", method: http.MethodDelete, path: promo + "/comments/" + unknownID, auth: bearer(aliceToken), status: http.StatusNotFound},
+			{name: "delete comment without token", method: http.MethodDelete, path: promo + "/comments/" + aliceCommentID, status: http.StatusUnauthorized},
+			{name: "delete comment", method: http.MethodDelete, path: promo + "/comments/" + aliceCommentID, auth: bearer(aliceToken), status: http.StatusOK},
+
+			{name: "activate", method: http.MethodPost, path: promo + "/activate", auth: bearer(aliceToken), status: http.StatusOK},
+			{name: "activate not targeted", method: http.MethodPost, path: "/api/user/promo/" + seniorPromoID + "/activate", auth: bearer(aliceToken), status: http.StatusForbidden},
+			{name: "activate unknown", method: http.MethodPost, path: unknown + "/activate", auth: bearer(aliceToken), status: http.StatusNotFound},
+			{name: "activate without token", method: http.MethodPost, path: promo + "/activate", status: http.StatusUnauthorized},
+
+			{name: "history", method: http.MethodGet, path: "/api/user/promo/history", auth: bearer(aliceToken), status: http.StatusOK, total: "1"},
+			{name: "history invalid limit", method: http.MethodGet, path: "/api/user/promo/history?limit=-1", auth: bearer(aliceToken), status: http.StatusBadRequest},
+			{name: "history without token", method: http.MethodGet, path: "/api/user/promo/history", status: http.StatusUnauthorized},
+		})
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		admin := bearer(adminToken)
+		a.run(t, []contractCase{
+			{name: "sign in", method: http.MethodPost, path: "/api/admin/auth/sign-in",
+				body: map[string]any{"email": adminEmail, "password": password}, status: http.StatusOK},
+			{name: "sign in invalid", method: http.MethodPost, path: "/api/admin/auth/sign-in",
+				body: map[string]any{"email": adminEmail}, status: http.StatusBadRequest},
+			{name: "sign in wrong password", method: http.MethodPost, path: "/api/admin/auth/sign-in",
+				body: map[string]any{"email": adminEmail, "password": "Wrong1!pass"}, status: http.StatusUnauthorized},
+		})
+
+		// sign-in инвалидирует прежний токен администратора
+		admin = bearer(decode[struct {
+			Token string `json:"token"`
+		}](t, a.expect(t, http.StatusOK, http.MethodPost, "/api/admin/auth/sign-in", "",
+			map[string]any{"email": adminEmail, "password": password})).Token)
+
+		a.run(t, []contractCase{
+			{name: "users", method: http.MethodGet, path: "/api/admin/users?query=alice@contract-test.com", auth: admin, status: http.StatusOK, total: "1"},
+			{name: "users invalid limit", method: http.MethodGet, path: "/api/admin/users?limit=-1", auth: admin, status: http.StatusBadRequest},
+			{name: "users with user token", method: http.MethodGet, path: "/api/admin/users", auth: bearer(aliceToken), status: http.StatusUnauthorized},
+
+			{name: "block user", method: http.MethodPost, path: "/api/admin/users/" + carolID + "/block", auth: admin,
+				body: map[string]any{"reason": "spam"}, status: http.StatusOK},
+			{name: "blocked user token is revoked", method: http.MethodGet, path: "/api/user/profile", auth: bearer(carolToken), status: http.StatusUnauthorized},
+			{name: "blocked user sign in", method: http.MethodPost, path: "/api/user/auth/sign-in",
+				body: map[string]any{"email": "carol@contract-test.com", "password": password}, status: http.StatusForbidden},
+			{name: "block user invalid id", method: http.MethodPost, path: "/api/admin/users/carol/block", auth: admin, status: http.StatusBadRequest},
+			{name: "block unknown user", method: http.MethodPost, path: "/api/admin/users/" + unknownID + "/block", auth: admin, status: http.StatusNotFound},
+			{name: "block user without token", method: http.MethodPost, path: "/api/admin/users/" + carolID + "/block", status: http.StatusUnauthorized},
+			{name: "unblock user", method: http.MethodPost, path: "/api/admin/users/" + carolID + "/unblock", auth: admin, status: http.StatusOK},
+			{name: "unblocked user sign in", method: http.MethodPost, path: "/api/user/auth/sign-in",
+				body: map[string]any{"email": "carol@contract-test.com", "password": password}, status: http.StatusOK},
+			{name: "unblock user invalid id", method: http.MethodPost, path: "/api/admin/users/carol/unblock", auth: admin, status: http.StatusBadRequest},
+			{name: "unblock unknown user", method: http.MethodPost, path: "/api/admin/users/" + unknownID + "/unblock", auth: admin, status: http.StatusNotFound},
+			{name: "unblock user without token", method: http.MethodPost, path: "/api/admin/users/" + carolID + "/unblock", status: http.StatusUnauthorized},
+
+			{name: "companies", method: http.MethodGet, path: "/api/admin/companies?query=initech", auth: admin, status: http.StatusOK, total: "1"},
+			{name: "companies invalid offset", method: http.MethodGet, path: "/api/admin/companies?offset=-1", auth: admin, status: http.StatusBadRequest},
+			{name: "companies without token", method: http.MethodGet, path: "/api/admin/companies", status: http.StatusUnauthorized},
+
+			{name: "block company", method: http.MethodPost, path: "/api/admin/companies/" + initechID + "/block", auth: admin, status: http.StatusOK},
+			{name: "blocked company sign in", method: http.MethodPost, path: "/api/business/auth/sign-in",
+				body: map[string]any{"email": "initech@contract-test.com", "password": password}, status: http.StatusForbidden},
+			{name: "block company invalid id", method: http.MethodPost, path: "/api/admin/companies/initech/block", auth: admin, status: http.StatusBadRequest},
+			{name: "block unknown company", method: http.MethodPost, path: "/api/admin/companies/" + unknownID + "/block", auth: admin, status: http.StatusNotFound},
+			{name: "block company without token", method: http.MethodPost, path: "/api/admin/companies/" + initechID + "/block", status: http.StatusUnauthorized},
+			{name: "unblock company", method: http.MethodPost, path: "/api/admin/companies/" + initechID + "/unblock", auth: admin, status: http.StatusOK},
+			{name: "unblocked company sign in", method: http.MethodPost, path: "/api/business/auth/sign-in",
+				body: map[string]any{"email": "initech@contract-test.com", "password": password}, status: http.StatusOK},
+			{name: "unblock company invalid id", method: http.MethodPost, path: "/api/admin/companies/initech/unblock", auth: admin, status: http.StatusBadRequest},
+			{name: "unblock unknown company", method: http.MethodPost, path: "/api/admin/companies/" + unknownID + "/unblock", auth: admin, status: http.StatusNotFound},
+			{name: "unblock company without token", method: http.MethodPost, path: "/api/admin/companies/" + initechID + "/unblock", status: http.StatusUnauthorized},
+
+			{name: "archive promo", method: http.MethodPost, path: "/api/admin/promos/" + globexPromoID + "/archive", auth: admin,
+				body: map[string]any{"reason": "misleading"}, status: http.StatusOK},
+			{name: "archive archived promo", method: http.MethodPost, path: "/api/admin/promos/" + globexPromoID + "/archive", auth: admin, status: http.StatusConflict},
+			{name: "archived promo is hidden", method: http.MethodGet, path: "/api/user/promo/" + globexPromoID, auth: bearer(aliceToken), status: http.StatusNotFound},
+			{name: "archive promo invalid id", method: http.MethodPost, path: "/api/admin/promos/globex/archive", auth: admin, status: http.StatusBadRequest},
+			{name: "archive unknown promo", method: http.MethodPost, path: "/api/admin/promos/" + unknownID + "/archive", auth: admin, status: http.StatusNotFound},
+			{name: "archive promo without token", method: http.MethodPost, path: "/api/admin/promos/" + globexPromoID + "/archive", status: http.StatusUnauthorized},
+
+			{name: "remove comment reason too long", method: http.MethodDelete, path: "/api/admin/comments/" + bobCommentID + "?reason=" + strings.Repeat("x", 501),
+				auth: admin, status: http.StatusBadRequest},
+			{name: "remove comment", method: http.MethodDelete, path: "/api/admin/comments/" + bobCommentID + "?reason=offtopic", auth: admin, status: http.StatusOK},
+			{name: "remove unknown comment", method: http.MethodDelete, path: "/api/admin/comments/" + unknownID, auth: admin, status: http.StatusNotFound},
+			{name: "remove comment without token", method: http.MethodDelete, path: "/api/admin/comments/" + bobCommentID, status: http.StatusUnauthorized},
+
+			{name: "activations", method: http.MethodGet, path: "/api/admin/activations?promo_id=" + promoID, auth: admin, status: http.StatusOK, total: "1"},
+			{name: "activations invalid filter", method: http.MethodGet, path: "/api/admin/activations?user_id=alice", auth: admin, status: http.StatusBadRequest},
+			{name: "activations without token", method: http.MethodGet, path: "/api/admin/activations", status: http.StatusUnauthorized},
+
+			// блокировка и разблокировка пользователя и компании, архивация и удаление комментария
+			{name: "audit log", method: http.MethodGet, path: "/api/admin/audit-log?limit=2", auth: admin, status: http.StatusOK, total: "6"},
+			{name: "audit log invalid limit", method: http.MethodGet, path: "/api/admin/audit-log?limit=all", auth: admin, status: http.StatusBadRequest},
+			{name: "audit log without token", method: http.MethodGet, path: "/api/admin/audit-log", status: http.StatusUnauthorized},
+
+			{name: "log level", method: http.MethodGet, path: "/api/admin/log-level", auth: admin, status: http.StatusOK},
+			{name: "log level without token", method: http.MethodGet, path: "/api/admin/log-level", status: http.StatusUnauthorized},
+			{name: "set log level", method: http.MethodPut, path: "/api/admin/log-level", auth: admin,
+				body: map[string]any{"level": "error"}, status: http.StatusOK},
+			{name: "set unknown log level", method: http.MethodPut, path: "/api/admin/log-level", auth: admin,
+				body: map[string]any{"level": "loud"}, status: http.StatusBadRequest},
+			{name: "set log level without token", method: http.MethodPut, path: "/api/admin/log-level",
+				body: map[string]any{"level": "debug"}, status: http.StatusUnauthorized},
+		})
+	})
+
+	for _, op := range a.spec.Operations() {
+		if !a.covered[op] {
+			t.Errorf("api.yml operation %s is not covered by the contract test", op)
+		}
+	}
+}
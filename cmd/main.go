@@ -1,14 +1,18 @@
 package main
 
 import (
-	"log"
+	"log/slog"
+	"os"
 	"solution/cmd/app"
+	// база часовых поясов для промокодов не зависит от образа, в котором запущен сервер
+	_ "time/tzdata"
 )
 
 func main() {
 	application, err := app.NewApp()
 	if err != nil {
-		log.Fatalf("Failed to initialize application: %v", err)
+		slog.Error("Failed to initialize application", "error", err)
+		os.Exit(1)
 	}
 	application.Run()
 }
@@ -0,0 +1,219 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/admin"
+	"solution/internal/shared/models/admin/dto"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/storage/redis"
+)
+
+// AdminRepository - операции администраторов над данными платформы. Каждое изменение
+// записывается в журнал в той же транзакции, что и само изменение.
+type AdminRepository interface {
+	ListUsers(ctx context.Context, query string, limit, offset int) ([]b2c.User, int64, error)
+	ListCompanies(ctx context.Context, query string, limit, offset int) ([]b2b.Company, int64, error)
+	SetUserBlocked(ctx context.Context, userID string, blocked bool, entry *admin.AuditEntry) error
+	SetCompanyBlocked(ctx context.Context, companyID string, blocked bool, entry *admin.AuditEntry) error
+	RevokeToken(ctx context.Context, id string) error
+	ArchivePromo(ctx context.Context, promoID string, entry *admin.AuditEntry) error
+	GetComment(ctx context.Context, commentID string) (*b2c.Comment, error)
+	DeleteComment(ctx context.Context, commentID string, entry *admin.AuditEntry) error
+	GetActivations(ctx context.Context, filter dto.ActivationFilter, limit, offset int) ([]models.PromoActivation, int64, error)
+	GetAuditLog(ctx context.Context, limit, offset int) ([]admin.AuditEntry, int64, error)
+}
+
+type adminRepository struct {
+	db  *gorm.DB
+	rdb *redis.RDB
+}
+
+func NewAdminRepository(db *gorm.DB, rdb *redis.RDB) AdminRepository {
+	return &adminRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern - шаблон ILIKE для поиска подстроки, спецсимволы запроса экранируются
+func containsPattern(query string) string {
+	return "%" + likeEscaper.Replace(query) + "%"
+}
+
+func (r *adminRepository) ListUsers(ctx context.Context, query string, limit, offset int) ([]b2c.User, int64, error) {
+	tx := r.db.WithContext(ctx).Model(&b2c.User{})
+	if query != "" {
+		pattern := containsPattern(query)
+		tx = tx.Where("email ILIKE ? OR name ILIKE ? OR surname ILIKE ?", pattern, pattern, pattern)
+	}
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []b2c.User
+	if err := tx.Order("created_at DESC, id").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, totalCount, nil
+}
+
+func (r *adminRepository) ListCompanies(ctx context.Context, query string, limit, offset int) ([]b2b.Company, int64, error) {
+	tx := r.db.WithContext(ctx).Model(&b2b.Company{})
+	if query != "" {
+		pattern := containsPattern(query)
+		tx = tx.Where("email ILIKE ? OR name ILIKE ?", pattern, pattern)
+	}
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var companies []b2b.Company
+	if err := tx.Order("name, id").Limit(limit).Offset(offset).Find(&companies).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return companies, totalCount, nil
+}
+
+func (r *adminRepository) SetUserBlocked(ctx context.Context, userID string, blocked bool, entry *admin.AuditEntry) error {
+	return r.setBlocked(ctx, &b2c.User{}, userID, blocked, entry, dto.ErrUserNotFound)
+}
+
+func (r *adminRepository) SetCompanyBlocked(ctx context.Context, companyID string, blocked bool, entry *admin.AuditEntry) error {
+	return r.setBlocked(ctx, &b2b.Company{}, companyID, blocked, entry, dto.ErrCompanyNotFound)
+}
+
+func (r *adminRepository) setBlocked(ctx context.Context, model interface{}, id string, blocked bool, entry *admin.AuditEntry, notFound error) error {
+	var blockedAt *time.Time
+	if blocked {
+		now := time.Now().UTC()
+		blockedAt = &now
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(model).Where("id = ?", id).Update("blocked_at", blockedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return notFound
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// RevokeToken удаляет токен компании или пользователя из белого списка: следующий запрос с ним получит 401
+func (r *adminRepository) RevokeToken(ctx context.Context, id string) error {
+	return r.rdb.Client.Del(ctx, id).Err()
+}
+
+func (r *adminRepository) ArchivePromo(ctx context.Context, promoID string, entry *admin.AuditEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var promo models.Promo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", promoID).First(&promo).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dto.ErrPromoNotFound
+			}
+			return err
+		}
+		if promo.ArchivedAt != nil {
+			return dto.ErrPromoArchived
+		}
+
+		before := promo
+		archivedAt := time.Now().UTC()
+		promo.ArchivedAt = &archivedAt
+		if err := tx.Model(&models.Promo{}).
+			Where("id = ? AND archived_at IS NULL", promoID).
+			UpdateColumns(map[string]interface{}{"archived_at": archivedAt, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+
+		// архивация - смена состояния промокода, она видна компании в истории промокода
+		change, err := models.NewPromoChange(models.PromoChangeArchive, models.Actor{Type: models.ActorAdmin, ID: entry.AdminID}, &before, &promo)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *adminRepository) GetComment(ctx context.Context, commentID string) (*b2c.Comment, error) {
+	var comment b2c.Comment
+	if err := r.db.WithContext(ctx).Where("id = ?", commentID).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *adminRepository) DeleteComment(ctx context.Context, commentID string, entry *admin.AuditEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&b2c.Comment{}, "id = ?", commentID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return dto.ErrCommentNotFound
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *adminRepository) GetActivations(ctx context.Context, filter dto.ActivationFilter, limit, offset int) ([]models.PromoActivation, int64, error) {
+	tx := r.db.WithContext(ctx).Model(&models.PromoActivation{})
+	if filter.PromoID != "" {
+		tx = tx.Where("promo_id = ?", filter.PromoID)
+	}
+	if filter.UserID != "" {
+		tx = tx.Where("user_id = ?", filter.UserID)
+	}
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var activations []models.PromoActivation
+	if err := tx.Order("activated_at DESC, id").Limit(limit).Offset(offset).Find(&activations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return activations, totalCount, nil
+}
+
+func (r *adminRepository) GetAuditLog(ctx context.Context, limit, offset int) ([]admin.AuditEntry, int64, error) {
+	tx := r.db.WithContext(ctx).Model(&admin.AuditEntry{})
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []admin.AuditEntry
+	if err := tx.Order("created_at DESC, id").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, totalCount, nil
+}
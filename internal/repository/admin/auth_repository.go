@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	redisPkg "github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"solution/internal/shared/models/admin"
+	"solution/internal/shared/models/admin/dto"
+	"solution/internal/shared/storage/redis"
+)
+
+// tokenKeyPrefix отделяет токены администраторов от токенов компаний и пользователей,
+// которые хранятся в Redis по голому ID: токен одной области не проходит проверку в другой
+const tokenKeyPrefix = "admin:"
+
+type AuthRepository interface {
+	CreateAdmin(ctx context.Context, admin *admin.Admin) error
+	GetAdmin(ctx context.Context, email string) (*admin.Admin, error)
+	IsEmailRegistered(ctx context.Context, email string) bool
+	WhitelistToken(ctx context.Context, token, id string, ttl time.Duration) error
+	ValidateToken(ctx context.Context, adminID string) (string, error)
+}
+
+type authRepository struct {
+	db  *gorm.DB
+	rdb *redis.RDB
+}
+
+func NewAuthRepository(db *gorm.DB, rdb *redis.RDB) AuthRepository {
+	return &authRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+func (r *authRepository) CreateAdmin(ctx context.Context, admin *admin.Admin) error {
+	return r.db.WithContext(ctx).Create(admin).Error
+}
+
+func (r *authRepository) GetAdmin(ctx context.Context, email string) (*admin.Admin, error) {
+	var a admin.Admin
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (r *authRepository) IsEmailRegistered(ctx context.Context, email string) bool {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&admin.Admin{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return false
+	}
+
+	return count > 0
+}
+
+func (r *authRepository) WhitelistToken(ctx context.Context, token, id string, ttl time.Duration) error {
+	return r.rdb.Client.Set(ctx, tokenKeyPrefix+id, token, ttl).Err()
+}
+
+func (r *authRepository) ValidateToken(ctx context.Context, adminID string) (string, error) {
+	token, err := r.rdb.Client.Get(ctx, tokenKeyPrefix+adminID).Result()
+	if errors.Is(err, redisPkg.Nil) {
+		slog.DebugContext(ctx, "Token does not exist for the given admin")
+		return "", nil
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error while fetching token from Redis", "error", err)
+		return "", err
+	}
+	return token, nil
+}
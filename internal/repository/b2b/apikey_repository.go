@@ -0,0 +1,88 @@
+package b2b
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/storage/redis"
+	"time"
+)
+
+type ApiKeyRepository interface {
+	CreateApiKey(ctx context.Context, key *b2b.ApiKey) error
+	GetApiKeys(ctx context.Context, companyID string) ([]b2b.ApiKey, error)
+	GetApiKeyByHash(ctx context.Context, hash string) (*b2b.ApiKey, error)
+	RevokeApiKey(ctx context.Context, companyID, keyID string) error
+	TouchApiKey(ctx context.Context, keyID string) error
+}
+
+type apiKeyRepository struct {
+	db  *gorm.DB
+	rdb *redis.RDB
+}
+
+func NewApiKeyRepository(db *gorm.DB, rdb *redis.RDB) ApiKeyRepository {
+	return &apiKeyRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+func (r *apiKeyRepository) CreateApiKey(ctx context.Context, key *b2b.ApiKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetApiKeys(ctx context.Context, companyID string) ([]b2b.ApiKey, error) {
+	var keys []b2b.ApiKey
+
+	if err := r.db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) GetApiKeyByHash(ctx context.Context, hash string) (*b2b.ApiKey, error) {
+	var key b2b.ApiKey
+
+	if err := r.db.WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL", hash).
+		// ключи заблокированной компании не проходят авторизацию, пока её не разблокируют
+		Where("company_id IN (SELECT id FROM companies WHERE blocked_at IS NULL)").
+		First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrInvalidApiKey
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) RevokeApiKey(ctx context.Context, companyID, keyID string) error {
+	result := r.db.WithContext(ctx).
+		Model(&b2b.ApiKey{}).
+		Where("id = ? AND company_id = ? AND revoked_at IS NULL", keyID, companyID).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return dto.ErrApiKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) TouchApiKey(ctx context.Context, keyID string) error {
+	return r.db.WithContext(ctx).
+		Model(&b2b.ApiKey{}).
+		Where("id = ?", keyID).
+		UpdateColumn("last_used_at", time.Now().UTC()).Error
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"gorm.io/gorm"
-	"log"
 
+	"log/slog"
 	redis "solution/internal/shared/storage/redis"
 
 	redisPkg "github.com/go-redis/redis/v8"
@@ -20,11 +20,11 @@ var (
 )
 
 type AuthRepository interface {
-	CreateCompany(req dto.SignUpRequest) (string, error)
-	GetCompany(email string) (*b2b.Company, error)
-	IsEmailRegistered(email string) bool
-	WhitelistToken(token, id string) error
-	ValidateToken(userId string) (string, error)
+	CreateCompany(ctx context.Context, req dto.SignUpRequest) (string, error)
+	GetCompany(ctx context.Context, email string) (*b2b.Company, error)
+	IsEmailRegistered(ctx context.Context, email string) bool
+	WhitelistToken(ctx context.Context, token, id string, ttl time.Duration) error
+	ValidateToken(ctx context.Context, userId string) (string, error)
 }
 
 type authRepository struct {
@@ -39,10 +39,8 @@ func NewAuthRepository(db *gorm.DB, rdb *redis.RDB) AuthRepository {
 	}
 }
 
-func (r *authRepository) CreateCompany(req dto.SignUpRequest) (string, error) {
-	ctx := context.TODO()
-
-	if r.IsEmailRegistered(req.Email) {
+func (r *authRepository) CreateCompany(ctx context.Context, req dto.SignUpRequest) (string, error) {
+	if r.IsEmailRegistered(ctx, req.Email) {
 		return "", ErrEmailAlreadyRegistered
 	}
 
@@ -59,9 +57,7 @@ func (r *authRepository) CreateCompany(req dto.SignUpRequest) (string, error) {
 	return company.ID, nil
 }
 
-func (r *authRepository) GetCompany(email string) (*b2b.Company, error) {
-	ctx := context.TODO()
-
+func (r *authRepository) GetCompany(ctx context.Context, email string) (*b2b.Company, error) {
 	var company b2b.Company
 	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&company).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -73,9 +69,7 @@ func (r *authRepository) GetCompany(email string) (*b2b.Company, error) {
 	return &company, nil
 }
 
-func (r *authRepository) IsEmailRegistered(email string) bool {
-	ctx := context.TODO()
-
+func (r *authRepository) IsEmailRegistered(ctx context.Context, email string) bool {
 	var exists bool
 	err := r.db.WithContext(ctx).Model(&b2b.Company{}).Select("count(*) > 0").Where("email = ?", email).Scan(&exists).Error
 	if err != nil {
@@ -85,22 +79,21 @@ func (r *authRepository) IsEmailRegistered(email string) bool {
 	return exists
 }
 
-func (r *authRepository) WhitelistToken(token, id string) error {
-	err := r.rdb.Client.Set(context.TODO(), id, token, 24*time.Hour).Err()
+func (r *authRepository) WhitelistToken(ctx context.Context, token, id string, ttl time.Duration) error {
+	err := r.rdb.Client.Set(ctx, id, token, ttl).Err()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *authRepository) ValidateToken(userId string) (string, error) {
-	ctx := context.TODO()
+func (r *authRepository) ValidateToken(ctx context.Context, userId string) (string, error) {
 	token, err := r.rdb.Client.Get(ctx, userId).Result()
 	if errors.Is(err, redisPkg.Nil) {
-		log.Println("Token does not exist for the given userId")
+		slog.DebugContext(ctx, "Token does not exist for the given company")
 		return "", nil
 	} else if err != nil {
-		log.Println("Error while fetching token from Redis:", err)
+		slog.ErrorContext(ctx, "Error while fetching token from Redis", "error", err)
 		return "", err
 	}
 	return token, nil
@@ -3,26 +3,30 @@ package b2b
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
-	"log"
+	"log/slog"
 	"solution/internal/shared/models"
 	"solution/internal/shared/models/b2b"
 	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/models/b2c"
 	"solution/internal/shared/storage/redis"
+	"solution/internal/shared/targeting"
 	"sort"
 	"strings"
 )
 
 type PromoRepository interface {
-	CreatePromo(req dto.PromoCreateRequest) (string, error)
-	GetPromos(companyID string, limit, offset int, sortBy string, country []string) ([]models.Promo, int64, error)
-	GetPromoByID(promoID string) (*models.Promo, error)
-	UpdatePromo(promoID string, req dto.PromoPatchRequest) (*models.Promo, error)
-	GetPromoStatByID(promoID string) (*dto.PromoStatResponse, error)
-	GetCompanyById(id string) (*b2b.Company, error)
+	CreatePromo(ctx context.Context, req dto.PromoCreateRequest, actor models.Actor) (string, error)
+	GetPromos(ctx context.Context, companyID string, limit, offset int, sortBy string, country []string) ([]models.Promo, int64, error)
+	GetPromoByID(ctx context.Context, promoID string) (*models.Promo, error)
+	UpdatePromo(ctx context.Context, promoID string, update dto.PromoUpdate, actor models.Actor) (*models.Promo, error)
+	UpdatePromoCodes(ctx context.Context, promoID string, update dto.PromoCodesUpdate, actor models.Actor) (*models.Promo, error)
+	GetPromoHistory(ctx context.Context, promoID string, limit, offset int) ([]models.PromoChange, int64, error)
+	GetPromoStatByID(ctx context.Context, promoID string) (*dto.PromoStatResponse, error)
+	GetCompanyById(ctx context.Context, id string) (*b2b.Company, error)
+	EstimateAudience(ctx context.Context, target models.Target) (*dto.AudienceEstimateResponse, error)
 }
 
 type promoRepository struct {
@@ -37,9 +41,7 @@ func NewPromoRepository(db *gorm.DB, rdb *redis.RDB) PromoRepository {
 	}
 }
 
-func (r *promoRepository) CreatePromo(req dto.PromoCreateRequest) (string, error) {
-	ctx := context.TODO()
-
+func (r *promoRepository) CreatePromo(ctx context.Context, req dto.PromoCreateRequest, actor models.Actor) (string, error) {
 	promo := models.Promo{
 		CompanyID:   req.CompanyID,
 		Description: req.Description,
@@ -51,34 +53,47 @@ func (r *promoRepository) CreatePromo(req dto.PromoCreateRequest) (string, error
 		MaxCount:    *req.MaxCount,
 		ActiveFrom:  req.ActiveFrom,
 		ActiveUntil: req.ActiveUntil,
+		Timezone:    req.Timezone,
+	}
+	if req.Limits != nil {
+		promo.Limits = *req.Limits
+	}
+	if err := promo.UpdateWindow(); err != nil {
+		return "", err
 	}
 
-	if err := r.db.WithContext(ctx).Create(&promo).Error; err != nil {
-		log.Printf("Error creating promo: %v", err)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&promo).Error; err != nil {
+			return err
+		}
+		return createPromoChange(tx, models.PromoChangeCreate, actor, nil, &promo)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating promo", "error", err)
 		return "", err
 	}
 
 	return promo.ID, nil
 }
 
-func (r *promoRepository) GetPromos(companyID string, limit, offset int, sortBy string, country []string) ([]models.Promo, int64, error) {
-	ctx := context.TODO()
+func (r *promoRepository) GetPromos(ctx context.Context, companyID string, limit, offset int, sortBy string, country []string) ([]models.Promo, int64, error) {
 	var promos []models.Promo
 
-	fmt.Println(companyID)
-
-	tx := r.db.Debug().WithContext(ctx).Model(&models.Promo{}).Where("company_id = ?", companyID)
+	tx := r.db.WithContext(ctx).Model(&models.Promo{}).Where("company_id = ?", companyID)
 
 	if len(country) > 0 {
-		lowerCountries := make([]string, len(country))
+		// Промокод подходит, если хотя бы одна из стран фильтра входит в его таргетинг
+		conditions := make([]string, len(country))
+		args := make([]interface{}, 0, len(country)*2)
 		for i, c := range country {
-			lowerCountries[i] = strings.ToLower(c)
+			conditions[i] = "((target->'countries' IS NULL OR target @> ?::jsonb) AND NOT target @> ?::jsonb)"
+			args = append(args,
+				models.CountryContainment("countries", c),
+				models.CountryContainment("exclude_countries", c),
+			)
 		}
 
-		tx = tx.Where(
-			"(LOWER(target->>'country') IN ? OR target->>'country' IS NULL OR target->>'country' = '')",
-			lowerCountries,
-		)
+		tx = tx.Where("("+strings.Join(conditions, " OR ")+")", args...)
 	}
 
 	if sortBy != "" {
@@ -103,13 +118,10 @@ func (r *promoRepository) GetPromos(companyID string, limit, offset int, sortBy
 		return nil, 0, err
 	}
 
-	fmt.Println(promos)
-
 	return promos, totalCount, nil
 }
 
-func (r *promoRepository) GetPromoByID(promoID string) (*models.Promo, error) {
-	ctx := context.TODO()
+func (r *promoRepository) GetPromoByID(ctx context.Context, promoID string) (*models.Promo, error) {
 	var promo models.Promo
 
 	if err := r.db.WithContext(ctx).First(&promo, "id = ?", promoID).Error; err != nil {
@@ -119,43 +131,122 @@ func (r *promoRepository) GetPromoByID(promoID string) (*models.Promo, error) {
 	return &promo, nil
 }
 
-func (r *promoRepository) UpdatePromo(promoID string, req dto.PromoPatchRequest) (*models.Promo, error) {
-	ctx := context.TODO()
+func (r *promoRepository) UpdatePromo(ctx context.Context, promoID string, update dto.PromoUpdate, actor models.Actor) (*models.Promo, error) {
 	var promo models.Promo
 
-	if err := r.db.WithContext(ctx).First(&promo, "id = ?", promoID).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// блокировка строки: параллельные изменения не перепутают старые значения в истории
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promo, "id = ?", promoID).Error; err != nil {
+			return err
+		}
+		if update.ExpectedVersion != 0 && promo.Version != update.ExpectedVersion {
+			return dto.ErrorPromoModified
+		}
+		// активации не меняют версию, поэтому used_count сверяется под той же блокировкой
+		if promo.Mode == "COMMON" && *update.MaxCount < promo.UsedCount {
+			return dto.ErrorMaxCountBelowUsed
+		}
+		before := promo
+
+		promo.Description = update.Description
+		promo.ImageURL = update.ImageURL
+		promo.Target = update.Target
+		promo.MaxCount = *update.MaxCount
+		promo.Limits = update.Limits
+		promo.ActiveFrom = update.ActiveFrom
+		promo.ActiveUntil = update.ActiveUntil
+		promo.Timezone = update.Timezone
+		if err := promo.UpdateWindow(); err != nil {
+			return err
+		}
+
+		change, err := models.NewPromoChange(models.PromoChangeUpdate, actor, &before, &promo)
+		if err != nil || change == nil {
+			// ничего не изменилось: версия и история остаются прежними
+			return err
+		}
+		promo.Version++
+
+		if err := tx.Save(&promo).Error; err != nil {
+			slog.ErrorContext(ctx, "Error updating promo", "error", err)
+			return err
+		}
+		return tx.Create(change).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	if req.Description != "" {
-		promo.Description = req.Description
-	}
-	if req.ImageURL != "" {
-		promo.ImageURL = req.ImageURL
-	}
-	if req.Target != nil {
-		promo.Target = *req.Target
-	}
-	if req.MaxCount != nil {
-		promo.MaxCount = *req.MaxCount
-	}
-	if req.ActiveFrom != nil {
-		promo.ActiveFrom = req.ActiveFrom
-	}
-	if req.ActiveUntil != nil {
-		promo.ActiveUntil = req.ActiveUntil
-	}
+	return &promo, nil
+}
 
-	if err := r.db.WithContext(ctx).Save(&promo).Error; err != nil {
-		log.Printf("Error updating promo: %v", err)
+func (r *promoRepository) UpdatePromoCodes(ctx context.Context, promoID string, update dto.PromoCodesUpdate, actor models.Actor) (*models.Promo, error) {
+	var promo models.Promo
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// блокировка строки: активации и выдача кодов ждут, пока изменение не запишется
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promo, "id = ?", promoID).Error; err != nil {
+			return err
+		}
+		if update.ExpectedVersion != 0 && promo.Version != update.ExpectedVersion {
+			return dto.ErrorPromoModified
+		}
+		if promo.UsedCount != update.UsedCount {
+			return dto.ErrorPromoActivated
+		}
+		before := promo
+
+		promo.Mode = update.Mode
+		promo.PromoCommon = update.PromoCommon
+		promo.PromoUnique = update.PromoUnique
+		promo.RetiredCodes = update.RetiredCodes
+		promo.MaxCount = update.MaxCount
+
+		change, err := models.NewPromoChange(models.PromoChangeUpdate, actor, &before, &promo)
+		if err != nil || change == nil {
+			return err
+		}
+		promo.Version++
+
+		if err := tx.Save(&promo).Error; err != nil {
+			slog.ErrorContext(ctx, "Error updating promo codes", "error", err)
+			return err
+		}
+		return tx.Create(change).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &promo, nil
 }
 
-func (r *promoRepository) GetPromoStatByID(promoID string) (*dto.PromoStatResponse, error) {
-	ctx := context.TODO()
+func (r *promoRepository) GetPromoHistory(ctx context.Context, promoID string, limit, offset int) ([]models.PromoChange, int64, error) {
+	tx := r.db.WithContext(ctx).Model(&models.PromoChange{}).Where("promo_id = ?", promoID)
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var changes []models.PromoChange
+	if err := tx.Order("created_at DESC, id").Limit(limit).Offset(offset).Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return changes, totalCount, nil
+}
+
+// createPromoChange пишет запись истории в транзакции изменения
+func createPromoChange(tx *gorm.DB, action string, actor models.Actor, before, after *models.Promo) error {
+	change, err := models.NewPromoChange(action, actor, before, after)
+	if err != nil || change == nil {
+		return err
+	}
+	return tx.Create(change).Error
+}
+
+func (r *promoRepository) GetPromoStatByID(ctx context.Context, promoID string) (*dto.PromoStatResponse, error) {
 	var promo models.Promo
 	var countryActivations []dto.CountryActivation
 
@@ -198,8 +289,7 @@ func (r *promoRepository) GetPromoStatByID(promoID string) (*dto.PromoStatRespon
 	return promoStat, nil
 }
 
-func (r *promoRepository) GetCompanyById(id string) (*b2b.Company, error) {
-	ctx := context.TODO()
+func (r *promoRepository) GetCompanyById(ctx context.Context, id string) (*b2b.Company, error) {
 	var company b2b.Company
 
 	if err := r.db.WithContext(ctx).First(&company, "id = ?", id).Error; err != nil {
@@ -211,3 +301,52 @@ func (r *promoRepository) GetCompanyById(id string) (*b2b.Company, error) {
 
 	return &company, nil
 }
+
+func (r *promoRepository) EstimateAudience(ctx context.Context, target models.Target) (*dto.AudienceEstimateResponse, error) {
+	var rows []struct {
+		Country    string
+		Age        int
+		UsersCount int64
+	}
+
+	tx := r.db.WithContext(ctx).Model(&b2c.User{})
+	tx = targeting.Apply(tx, targeting.UserConditions(target))
+	if err := tx.
+		Select("UPPER(users.country) AS country, users.age AS age, COUNT(*) AS users_count").
+		Group("UPPER(users.country), users.age").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	estimate := &dto.AudienceEstimateResponse{
+		Countries: []dto.AudienceCountry{},
+		AgeGroups: []dto.AudienceAgeGroup{},
+	}
+	countries := make(map[string]int64)
+	ageGroups := make(map[b2c.UserSegment]int64)
+	for _, row := range rows {
+		estimate.UsersCount += row.UsersCount
+		countries[row.Country] += row.UsersCount
+		ageGroups[b2c.SegmentOf(row.Age, "")] += row.UsersCount
+	}
+
+	for country, users := range countries {
+		estimate.Countries = append(estimate.Countries, dto.AudienceCountry{Country: country, Users: users})
+	}
+	sort.Slice(estimate.Countries, func(i, j int) bool {
+		return estimate.Countries[i].Country < estimate.Countries[j].Country
+	})
+
+	for segment, users := range ageGroups {
+		estimate.AgeGroups = append(estimate.AgeGroups, dto.AudienceAgeGroup{
+			AgeFrom:  segment.AgeFrom,
+			AgeUntil: segment.AgeUntil,
+			Users:    users,
+		})
+	}
+	sort.Slice(estimate.AgeGroups, func(i, j int) bool {
+		return estimate.AgeGroups[i].AgeFrom < estimate.AgeGroups[j].AgeFrom
+	})
+
+	return estimate, nil
+}
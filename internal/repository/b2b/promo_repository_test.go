@@ -0,0 +1,134 @@
+package b2b
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/storage/postgres/pgtest"
+)
+
+var errAudit = errors.New("audit is unavailable")
+
+// TestPromoChangeRollsBackWithPromo проверяет, что запись истории пишется в транзакции изменения:
+// если она не записалась, откатывается и само изменение. Нужен TEST_POSTGRES_DSN.
+func TestPromoChangeRollsBackWithPromo(t *testing.T) {
+	db := pgtest.Open(t)
+	ctx := context.Background()
+
+	failAudit := false
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_promo_changes", func(tx *gorm.DB) {
+		if failAudit && tx.Statement.Table == "promo_changes" {
+			tx.AddError(errAudit)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	company := b2b.Company{Name: "Company", Email: "company@example.com", Password: "hash"}
+	if err := db.Create(&company).Error; err != nil {
+		t.Fatal(err)
+	}
+	actor := models.Actor{Type: models.ActorCompany, ID: company.ID}
+	repo := NewPromoRepository(db, nil)
+
+	createPromo := func(description string) (string, error) {
+		maxCount := 10
+		return repo.CreatePromo(ctx, dto.PromoCreateRequest{
+			CompanyID:   company.ID,
+			Description: description,
+			Mode:        "COMMON",
+			PromoCommon: "CODE",
+			Target:      &models.Target{},
+			MaxCount:    &maxCount,
+		}, actor)
+	}
+
+	tests := []struct {
+		name  string
+		write func(promoID string) error
+	}{
+		{
+			name: "create",
+			write: func(string) error {
+				_, err := createPromo("Rolled back promo")
+				return err
+			},
+		},
+		{
+			name: "update",
+			write: func(promoID string) error {
+				_, err := repo.UpdatePromo(ctx, promoID, dto.PromoUpdate{Description: "Updated", MaxCount: intPtr(20)}, actor)
+				return err
+			},
+		},
+		{
+			name: "codes",
+			write: func(promoID string) error {
+				_, err := repo.UpdatePromoCodes(ctx, promoID, dto.PromoCodesUpdate{Mode: "COMMON", PromoCommon: "NEW", MaxCount: 10}, actor)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failAudit = false
+			promoID, err := createPromo("Promo description")
+			if err != nil {
+				t.Fatal(err)
+			}
+			promos, changes := countRows(t, db)
+
+			failAudit = true
+			err = tt.write(promoID)
+			failAudit = false
+			if !errors.Is(err, errAudit) {
+				t.Fatalf("write error = %v, want %v", err, errAudit)
+			}
+
+			if gotPromos, gotChanges := countRows(t, db); gotPromos != promos || gotChanges != changes {
+				t.Errorf("rows = %d promos, %d changes, want %d, %d", gotPromos, gotChanges, promos, changes)
+			}
+			promo, err := repo.GetPromoByID(ctx, promoID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if promo.Version != 1 || promo.Description != "Promo description" || promo.MaxCount != 10 || promo.PromoCommon != "CODE" {
+				t.Errorf("promo = version %d, %q, max_count %d, code %q, want it unchanged",
+					promo.Version, promo.Description, promo.MaxCount, promo.PromoCommon)
+			}
+
+			// без сбоя то же изменение пишет ровно одну запись истории
+			if tt.name == "create" {
+				return
+			}
+			if err := tt.write(promoID); err != nil {
+				t.Fatal(err)
+			}
+			if _, total, err := repo.GetPromoHistory(ctx, promoID, 10, 0); err != nil || total != 2 {
+				t.Errorf("history total = %d, %v, want 2", total, err)
+			}
+		})
+	}
+}
+
+func countRows(t *testing.T, db *gorm.DB) (promos, changes int64) {
+	t.Helper()
+	if err := db.Model(&models.Promo{}).Count(&promos).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.PromoChange{}).Count(&changes).Error; err != nil {
+		t.Fatal(err)
+	}
+	return promos, changes
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,120 @@
+package b2c
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	redisPkg "github.com/go-redis/redis/v8"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c/dto"
+	"solution/internal/shared/storage/redis"
+)
+
+const (
+	// userCountTTL ограничивает жизнь счётчика активаций пользователя: после истечения
+	// limits.per_user проверяет только Postgres
+	userCountTTL = 30 * 24 * time.Hour
+	// dailyCountTTL - счётчик суток переживает сами сутки в любом часовом поясе
+	dailyCountTTL = 48 * time.Hour
+)
+
+// ActivationLimiter резервирует активацию в Redis до транзакции: атомарные счётчики отсекают
+// превышение limits без блокировки строки промокода в Postgres. Окончательную проверку делает
+// PromoRepository.ActivatePromo, поэтому без Redis limits всё равно соблюдаются.
+type ActivationLimiter interface {
+	// Reserve учитывает активацию в счётчиках; release отменяет резерв, если активация не состоялась
+	Reserve(ctx context.Context, promo *models.Promo, userID string, now time.Time) (release func(), err error)
+}
+
+type activationLimiter struct {
+	rdb *redis.RDB
+}
+
+func NewActivationLimiter(rdb *redis.RDB) ActivationLimiter {
+	return &activationLimiter{rdb: rdb}
+}
+
+// reserveScript проверяет все limits и только затем увеличивает счётчики, поэтому отказ ничего не меняет.
+// KEYS: счётчик пользователя, ключ cooldown, счётчик суток.
+// ARGV: per_user, cooldown_seconds, daily, TTL счётчика пользователя и счётчика суток в секундах.
+var reserveScript = redisPkg.NewScript(`
+local perUser = tonumber(ARGV[1])
+local cooldown = tonumber(ARGV[2])
+local daily = tonumber(ARGV[3])
+
+if perUser > 0 and tonumber(redis.call('GET', KEYS[1]) or '0') >= perUser then
+    return 'per_user'
+end
+if cooldown > 0 and redis.call('EXISTS', KEYS[2]) == 1 then
+    return 'cooldown'
+end
+if daily > 0 and tonumber(redis.call('GET', KEYS[3]) or '0') >= daily then
+    return 'daily'
+end
+
+if perUser > 0 then
+    redis.call('INCR', KEYS[1])
+    redis.call('EXPIRE', KEYS[1], ARGV[4])
+end
+if cooldown > 0 then
+    redis.call('SET', KEYS[2], '1', 'EX', cooldown)
+end
+if daily > 0 then
+    redis.call('INCR', KEYS[3])
+    redis.call('EXPIRE', KEYS[3], ARGV[5])
+end
+return ''
+`)
+
+// releaseScript возвращает счётчики к состоянию до резерва с теми же KEYS и ARGV
+var releaseScript = redisPkg.NewScript(`
+if tonumber(ARGV[1]) > 0 and tonumber(redis.call('GET', KEYS[1]) or '0') > 0 then
+    redis.call('DECR', KEYS[1])
+end
+if tonumber(ARGV[2]) > 0 then
+    redis.call('DEL', KEYS[2])
+end
+if tonumber(ARGV[3]) > 0 and tonumber(redis.call('GET', KEYS[3]) or '0') > 0 then
+    redis.call('DECR', KEYS[3])
+end
+return 0
+`)
+
+func (l *activationLimiter) Reserve(ctx context.Context, promo *models.Promo, userID string, now time.Time) (func(), error) {
+	noop := func() {}
+	limits := promo.Limits
+	if limits.PerUser == 0 && limits.CooldownSeconds == 0 && limits.Daily == 0 {
+		return noop, nil
+	}
+
+	keys := []string{
+		fmt.Sprintf("activation:%s:user:%s:count", promo.ID, userID),
+		fmt.Sprintf("activation:%s:user:%s:cooldown", promo.ID, userID),
+		fmt.Sprintf("activation:%s:day:%s", promo.ID, promo.DayStart(now).Format(time.RFC3339)),
+	}
+	args := []interface{}{
+		limits.PerUser, limits.CooldownSeconds, limits.Daily,
+		int(userCountTTL.Seconds()), int(dailyCountTTL.Seconds()),
+	}
+
+	limit, err := reserveScript.Run(ctx, l.rdb.Client, keys, args...).Text()
+	if err != nil {
+		// без Redis limits проверяет только транзакция в Postgres
+		slog.WarnContext(ctx, "Activation limits are not reserved in Redis", "promo_id", promo.ID, "error", err)
+		return noop, nil
+	}
+	if err := dto.LimitError(models.Limit(limit)); err != nil {
+		return noop, err
+	}
+
+	release := func() {
+		// резерв отменяется и тогда, когда запрос уже отменён клиентом
+		ctx := context.WithoutCancel(ctx)
+		if err := releaseScript.Run(ctx, l.rdb.Client, keys, args...).Err(); err != nil {
+			slog.WarnContext(ctx, "Failed to release activation reservation", "promo_id", promo.ID, "error", err)
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,115 @@
+package b2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c/dto"
+	"solution/internal/shared/storage/redis/redistest"
+)
+
+// reservation - резерв пользователя после сдвига времени на wait; nextDay переносит его на следующие сутки
+type reservation struct {
+	user    string
+	wait    time.Duration
+	nextDay bool
+	wantErr error
+}
+
+func TestActivationLimiter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		limits  models.ActivationLimits
+		reserve []reservation
+	}{
+		{
+			name:   "per user",
+			limits: models.ActivationLimits{PerUser: 2},
+			reserve: []reservation{
+				{user: "u1"}, {user: "u1"}, {user: "u1", wantErr: dto.ErrActivationLimit}, {user: "u2"},
+			},
+		},
+		{
+			name:   "cooldown",
+			limits: models.ActivationLimits{CooldownSeconds: 60},
+			reserve: []reservation{
+				{user: "u1"}, {user: "u1", wait: 59 * time.Second, wantErr: dto.ErrActivationCooldown}, {user: "u2"}, {user: "u1", wait: time.Second},
+			},
+		},
+		{
+			name:   "daily",
+			limits: models.ActivationLimits{Daily: 2},
+			reserve: []reservation{
+				{user: "u1"}, {user: "u2"}, {user: "u3", wantErr: dto.ErrActivationDailyLimit}, {user: "u3", nextDay: true},
+			},
+		},
+		{
+			name:    "no limits",
+			reserve: []reservation{{user: "u1"}, {user: "u1"}, {user: "u1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdb, server := redistest.Open(t)
+			limiter := NewActivationLimiter(rdb)
+			promo := &models.Promo{ID: "promo", Timezone: "UTC", Limits: tt.limits}
+
+			at := now
+			for i, r := range tt.reserve {
+				server.FastForward(r.wait)
+				at = at.Add(r.wait)
+				if r.nextDay {
+					at = at.Add(24 * time.Hour)
+				}
+				_, err := limiter.Reserve(context.Background(), promo, r.user, at)
+				if !errors.Is(err, r.wantErr) {
+					t.Fatalf("reserve %d for %s: error = %v, want %v", i, r.user, err, r.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestActivationLimiterRelease(t *testing.T) {
+	ctx := context.Background()
+	rdb, _ := redistest.Open(t)
+	limiter := NewActivationLimiter(rdb)
+	promo := &models.Promo{ID: "promo", Timezone: "UTC", Limits: models.ActivationLimits{PerUser: 1, CooldownSeconds: 60, Daily: 1}}
+	now := time.Now()
+
+	release, err := limiter.Reserve(ctx, promo, "u1", now)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	release()
+
+	// отменённый резерв не занимает ни один limit
+	if _, err := limiter.Reserve(ctx, promo, "u1", now); err != nil {
+		t.Fatalf("Reserve() after release error = %v", err)
+	}
+	if _, err := limiter.Reserve(ctx, promo, "u1", now); !errors.Is(err, dto.ErrActivationLimit) {
+		t.Fatalf("Reserve() over limit error = %v, want %v", err, dto.ErrActivationLimit)
+	}
+}
+
+func TestActivationLimiterWithoutRedis(t *testing.T) {
+	rdb, server := redistest.Open(t)
+	limiter := NewActivationLimiter(rdb)
+	promo := &models.Promo{ID: "promo", Timezone: "UTC", Limits: models.ActivationLimits{PerUser: 1}}
+	server.Close()
+
+	// без Redis резерв пропускает активацию, limits проверит транзакция в Postgres
+	for i := 0; i < 2; i++ {
+		release, err := limiter.Reserve(context.Background(), promo, "u1", time.Now())
+		if err != nil {
+			t.Fatalf("Reserve() error = %v, want nil", err)
+		}
+		release()
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	redisPkg "github.com/go-redis/redis/v8"
-	"log"
+	"log/slog"
 	models "solution/internal/shared/models/b2c"
 	"solution/internal/shared/storage/redis"
 	"time"
@@ -17,11 +17,11 @@ var (
 )
 
 type AuthRepository interface {
-	CreateUser(user *models.User) (string, error)
-	GetUserByEmail(email string) (*models.User, error)
-	IsEmailRegistered(email string) bool
-	WhitelistToken(token, id string) error
-	ValidateToken(userId string) (string, error)
+	CreateUser(ctx context.Context, user *models.User) (string, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	IsEmailRegistered(ctx context.Context, email string) bool
+	WhitelistToken(ctx context.Context, token, id string, ttl time.Duration) error
+	ValidateToken(ctx context.Context, userId string) (string, error)
 }
 
 type authRepository struct {
@@ -36,10 +36,8 @@ func NewAuthRepository(db *gorm.DB, rdb *redis.RDB) AuthRepository {
 	}
 }
 
-func (r *authRepository) CreateUser(user *models.User) (string, error) {
-	ctx := context.TODO()
-
-	if r.IsEmailRegistered(user.Email) {
+func (r *authRepository) CreateUser(ctx context.Context, user *models.User) (string, error) {
+	if r.IsEmailRegistered(ctx, user.Email) {
 		return "", ErrEmailAlreadyRegistered
 	}
 
@@ -50,9 +48,7 @@ func (r *authRepository) CreateUser(user *models.User) (string, error) {
 	return user.ID, nil
 }
 
-func (r *authRepository) GetUserByEmail(email string) (*models.User, error) {
-	ctx := context.TODO()
-
+func (r *authRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -64,9 +60,7 @@ func (r *authRepository) GetUserByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *authRepository) IsEmailRegistered(email string) bool {
-	ctx := context.TODO()
-
+func (r *authRepository) IsEmailRegistered(ctx context.Context, email string) bool {
 	var count int64
 	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
 		return false
@@ -75,22 +69,21 @@ func (r *authRepository) IsEmailRegistered(email string) bool {
 	return count > 0
 }
 
-func (r *authRepository) WhitelistToken(token, id string) error {
-	err := r.rdb.Client.Set(context.TODO(), id, token, 24*time.Hour).Err()
+func (r *authRepository) WhitelistToken(ctx context.Context, token, id string, ttl time.Duration) error {
+	err := r.rdb.Client.Set(ctx, id, token, ttl).Err()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *authRepository) ValidateToken(userId string) (string, error) {
-	ctx := context.TODO()
+func (r *authRepository) ValidateToken(ctx context.Context, userId string) (string, error) {
 	token, err := r.rdb.Client.Get(ctx, userId).Result()
 	if errors.Is(err, redisPkg.Nil) {
-		log.Println("Token does not exist for the given userId")
+		slog.DebugContext(ctx, "Token does not exist for the given user")
 		return "", nil
 	} else if err != nil {
-		log.Println("Error while fetching token from Redis:", err)
+		slog.ErrorContext(ctx, "Error while fetching token from Redis", "error", err)
 		return "", err
 	}
 	return token, nil
@@ -10,8 +10,8 @@ import (
 )
 
 type ProfileRepository interface {
-	GetProfile(userID string) (*b2c.User, error)
-	UpdateProfile(userID string, req *dto.ProfileUpdateRequest) error
+	GetProfile(ctx context.Context, userID string) (*b2c.User, error)
+	UpdateProfile(ctx context.Context, userID string, req *dto.ProfileUpdateRequest) error
 }
 
 type profileRepository struct {
@@ -26,11 +26,9 @@ func NewProfileRepository(db *gorm.DB, rdb *redis.RDB) ProfileRepository {
 	}
 }
 
-func (r *profileRepository) GetProfile(userID string) (*b2c.User, error) {
-	c := context.TODO()
-
+func (r *profileRepository) GetProfile(ctx context.Context, userID string) (*b2c.User, error) {
 	var user b2c.User
-	if err := r.db.WithContext(c).Where("id = ?", userID).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -40,11 +38,9 @@ func (r *profileRepository) GetProfile(userID string) (*b2c.User, error) {
 	return &user, nil
 }
 
-func (r *profileRepository) UpdateProfile(userID string, req *dto.ProfileUpdateRequest) error {
+func (r *profileRepository) UpdateProfile(ctx context.Context, userID string, req *dto.ProfileUpdateRequest) error {
 	updates := make(map[string]interface{})
 
-	ctx := context.TODO()
-
 	if req.Name != nil {
 		updates["name"] = req.Name
 	}
@@ -58,17 +54,36 @@ func (r *profileRepository) UpdateProfile(userID string, req *dto.ProfileUpdateR
 		updates["password"] = req.Password
 	}
 
+	tx := r.db.WithContext(ctx).Model(&b2c.User{}).Where("id = ?", userID)
+	if req.ExpectedVersion != 0 {
+		tx = tx.Where("version = ?", req.ExpectedVersion)
+	}
+
 	if len(updates) == 0 {
+		// пустое обновление ничего не меняет, но устаревший If-Match всё равно должен получить 412
+		if req.ExpectedVersion != 0 {
+			var count int64
+			if err := tx.Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return dto.ErrProfileModified
+			}
+		}
 		return dto.ErrNoFieldsToUpdate
 	}
+	updates["version"] = gorm.Expr("version + 1")
 
-	result := r.db.WithContext(ctx).Model(&b2c.User{}).Where("id = ?", userID).Updates(updates)
+	result := tx.Updates(updates)
 
 	if result.Error != nil {
 		return result.Error
 	}
 
 	if result.RowsAffected == 0 {
+		if req.ExpectedVersion != 0 {
+			return dto.ErrProfileModified
+		}
 		return dto.ErrNoFieldsToUpdate
 	}
 
@@ -0,0 +1,117 @@
+package b2c
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/models/b2c/dto"
+)
+
+// ActivatePromo блокирует строку промокода до конца транзакции, поэтому used_count и активации
+// пользователя не меняются между проверкой и записью: Postgres - окончательная проверка limits,
+// даже если Redis недоступен или потерял счётчики.
+func (r *promoRepository) ActivatePromo(ctx context.Context, promoID, userID string, now time.Time) (string, error) {
+	var code string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var promo models.Promo
+		err := excludeArchived(tx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", promoID).First(&promo).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		var user b2c.User
+		err = tx.Where("id = ?", userID).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		if err := dto.CheckActivation(&promo, &user, now); err != nil {
+			return err
+		}
+
+		usage, err := activationUsage(tx, &promo, userID, now)
+		if err != nil {
+			return err
+		}
+		if err := dto.LimitError(promo.Limits.Check(usage, now)); err != nil {
+			return err
+		}
+
+		code = promo.NextCode()
+		activation := models.PromoActivation{PromoID: promoID, UserID: userID, ActivatedAt: now}
+		if err := tx.Create(&activation).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Promo{}).Where("id = ?", promoID).
+			UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// activationUsage считает активации, от которых зависят limits промокода
+func activationUsage(tx *gorm.DB, promo *models.Promo, userID string, now time.Time) (models.ActivationUsage, error) {
+	var byUser struct {
+		Count           int
+		LastActivatedAt *time.Time
+	}
+	err := tx.Model(&models.PromoActivation{}).
+		Select("COUNT(*) AS count, MAX(activated_at) AS last_activated_at").
+		Where("promo_id = ? AND user_id = ?", promo.ID, userID).
+		Scan(&byUser).Error
+	if err != nil {
+		return models.ActivationUsage{}, err
+	}
+	usage := models.ActivationUsage{UserCount: byUser.Count, LastByUser: byUser.LastActivatedAt}
+
+	if promo.Limits.Daily > 0 {
+		var today int64
+		err := tx.Model(&models.PromoActivation{}).
+			Where("promo_id = ? AND activated_at >= ?", promo.ID, promo.DayStart(now)).
+			Count(&today).Error
+		if err != nil {
+			return models.ActivationUsage{}, err
+		}
+		usage.Today = int(today)
+	}
+	return usage, nil
+}
+
+// GetActivationHistory возвращает промокоды в порядке активаций пользователем, от последней к первой.
+// Промокод, активированный несколько раз, повторяется; архивные промокоды не показываются.
+func (r *promoRepository) GetActivationHistory(ctx context.Context, userID string, limit, offset int) ([]dto.PromoForUser, int64, error) {
+	tx := excludeArchived(r.db.WithContext(ctx).Model(&models.Promo{})).
+		Joins("JOIN promo_activations ON promo_activations.promo_id = promos.id").
+		Where("promo_activations.user_id = ?", userID)
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var promos []models.Promo
+	err := tx.Select("promos.*").
+		Order("promo_activations.activated_at DESC, promo_activations.id").
+		Limit(limit).Offset(offset).
+		Find(&promos).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	promoDTOs, err := r.buildPromoDTOs(ctx, userID, promos)
+	if err != nil {
+		return nil, 0, err
+	}
+	return promoDTOs, totalCount, nil
+}
@@ -2,29 +2,38 @@ package b2c
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"solution/internal/shared/models"
 	"solution/internal/shared/models/b2b"
 	"solution/internal/shared/models/b2c"
 	"solution/internal/shared/models/b2c/dto"
 	"solution/internal/shared/storage/redis"
+	"solution/internal/shared/targeting"
 	"strings"
 	"time"
 )
 
 type PromoRepository interface {
-	GetPromosForUser(userID string, limit, offset int, category string, active *bool) ([]dto.PromoForUser, int64, error)
-	GetPromoByID(id string) (*models.Promo, error)
-	GetPromoForUserByID(promoId, userId string) (*dto.PromoForUser, error)
-	LikePromo(promoID, userID string) error
-	UnlikePromo(promoID, userID string) error
-	AddComment(comment *b2c.Comment) error
-	GetComments(promoID string, limit, offset int) ([]b2c.Comment, int64, error)
-	GetCommentByID(commentID string) (*b2c.Comment, error)
-	UpdateComment(comment *b2c.Comment) error
-	DeleteComment(commentID string) error
-	GetUserByID(userID string) (*b2c.User, error)
+	GetPromosForUser(ctx context.Context, userID string, limit, offset int, category string, active *bool, ranking *dto.FeedRanking) ([]dto.PromoForUser, int64, error)
+	SearchPromosForUser(ctx context.Context, userID, query string, limit, offset int, active *bool) ([]dto.PromoSearchResult, int64, error)
+	GetPromoByID(ctx context.Context, id string) (*models.Promo, error)
+	GetPromoForUserByID(ctx context.Context, promoId, userId string) (*dto.PromoForUser, error)
+	// LikePromo ставит лайк и сообщает, добавлен ли он: повторный лайк ничего не меняет
+	LikePromo(ctx context.Context, promoID, userID string) (bool, error)
+	UnlikePromo(ctx context.Context, promoID, userID string) error
+	AddComment(ctx context.Context, comment *b2c.Comment) error
+	GetComments(ctx context.Context, promoID string, limit, offset int) ([]b2c.Comment, int64, error)
+	GetCommentByID(ctx context.Context, commentID string) (*b2c.Comment, error)
+	UpdateComment(ctx context.Context, comment *b2c.Comment) error
+	DeleteComment(ctx context.Context, commentID string) error
+	GetUserByID(ctx context.Context, userID string) (*b2c.User, error)
+	// ActivatePromo выдаёт пользователю следующий код промокода, проверяя доступность и limits под блокировкой строки
+	ActivatePromo(ctx context.Context, promoID, userID string, now time.Time) (string, error)
+	GetActivationHistory(ctx context.Context, userID string, limit, offset int) ([]dto.PromoForUser, int64, error)
 }
 
 type promoRepository struct {
@@ -39,9 +48,9 @@ func NewPromoRepository(db *gorm.DB, rdb *redis.RDB) PromoRepository {
 	}
 }
 
-func (r *promoRepository) GetUserByID(userID string) (*b2c.User, error) {
+func (r *promoRepository) GetUserByID(ctx context.Context, userID string) (*b2c.User, error) {
 	var user b2c.User
-	err := r.db.Where("id = ?", userID).First(&user).Error
+	err := r.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrNotFound
@@ -51,15 +60,15 @@ func (r *promoRepository) GetUserByID(userID string) (*b2c.User, error) {
 	return &user, nil
 }
 
-func (r *promoRepository) AddComment(comment *b2c.Comment) error {
-	return r.db.Create(comment).Error
+func (r *promoRepository) AddComment(ctx context.Context, comment *b2c.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
 }
 
-func (r *promoRepository) GetComments(promoID string, limit, offset int) ([]b2c.Comment, int64, error) {
+func (r *promoRepository) GetComments(ctx context.Context, promoID string, limit, offset int) ([]b2c.Comment, int64, error) {
 	var comments []b2c.Comment
 	var totalCount int64
 
-	query := r.db.Model(&b2c.Comment{}).Where("promo_id = ?", promoID).Order("created_at DESC")
+	query := r.db.WithContext(ctx).Model(&b2c.Comment{}).Where("promo_id = ?", promoID).Order("created_at DESC")
 
 	if err := query.Count(&totalCount).Error; err != nil {
 		return nil, 0, err
@@ -72,22 +81,22 @@ func (r *promoRepository) GetComments(promoID string, limit, offset int) ([]b2c.
 	return comments, totalCount, nil
 }
 
-func (r *promoRepository) GetCommentByID(commentID string) (*b2c.Comment, error) {
+func (r *promoRepository) GetCommentByID(ctx context.Context, commentID string) (*b2c.Comment, error) {
 	var comment b2c.Comment
-	err := r.db.Where("id = ?", commentID).First(&comment).Error
+	err := r.db.WithContext(ctx).Where("id = ?", commentID).First(&comment).Error
 	return &comment, err
 }
 
-func (r *promoRepository) UpdateComment(comment *b2c.Comment) error {
-	return r.db.Save(comment).Error
+func (r *promoRepository) UpdateComment(ctx context.Context, comment *b2c.Comment) error {
+	return r.db.WithContext(ctx).Save(comment).Error
 }
 
-func (r *promoRepository) DeleteComment(commentID string) error {
-	return r.db.Delete(&b2c.Comment{}, "id = ?", commentID).Error
+func (r *promoRepository) DeleteComment(ctx context.Context, commentID string) error {
+	return r.db.WithContext(ctx).Delete(&b2c.Comment{}, "id = ?", commentID).Error
 }
 
-func (r *promoRepository) UnlikePromo(promoID, userID string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *promoRepository) UnlikePromo(ctx context.Context, promoID, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var promo models.Promo
 		if err := tx.Where("id = ?", promoID).First(&promo).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -117,33 +126,30 @@ func (r *promoRepository) UnlikePromo(promoID, userID string) error {
 	})
 }
 
-func (r promoRepository) LikePromo(promoID, userID string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		var userLike b2c.UserLike
-		err := tx.Where("user_id = ? AND promo_id = ?", userID, promoID).First(&userLike).Error
-		if err == nil {
-			return nil
-		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-			return err
-		}
-		userLike = b2c.UserLike{
-			UserID:  userID,
-			PromoID: promoID,
+func (r *promoRepository) LikePromo(ctx context.Context, promoID, userID string) (bool, error) {
+	var inserted bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// вставка без проверки заранее: параллельные лайки не падают на первичном ключе
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&b2c.UserLike{UserID: userID, PromoID: promoID})
+		if result.Error != nil {
+			return result.Error
 		}
-		if err := tx.Create(&userLike).Error; err != nil {
-			return err
+		if result.RowsAffected == 0 {
+			return nil
 		}
-		if err := tx.Model(&models.Promo{}).
+		inserted = true
+
+		return tx.Model(&models.Promo{}).
 			Where("id = ?", promoID).
-			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error; err != nil {
-			return err
-		}
-		return nil
+			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error
 	})
+	if err != nil {
+		return false, err
+	}
+	return inserted, nil
 }
 
-func (r *promoRepository) GetPromosForUser(userID string, limit, offset int, category string, active *bool) ([]dto.PromoForUser, int64, error) {
-	ctx := context.TODO()
+func (r *promoRepository) GetPromosForUser(ctx context.Context, userID string, limit, offset int, category string, active *bool, ranking *dto.FeedRanking) ([]dto.PromoForUser, int64, error) {
 	var promos []models.Promo
 
 	// 1. Получаем данные пользователя
@@ -166,51 +172,10 @@ func (r *promoRepository) GetPromosForUser(userID string, limit, offset int, cat
         `, lowerCategory)
 	}
 
-	// 3. Фильтрация по возрасту пользователя
-	if user.Age != 0 {
-		tx = tx.Where("COALESCE(target->>'age_from', '0')::int <= ?", user.Age)
-		tx = tx.Where("COALESCE(target->>'age_until', '100')::int >= ?", user.Age)
-	}
-
-	// 4. Фильтрация по стране пользователя
-	if user.Country != "" {
-		lowerCountry := strings.ToLower(user.Country)
-		tx = tx.Where(`
-        (
-            target->>'country' IS NULL
-            OR LOWER(target->>'country') = ?
-        )
-    `, lowerCountry)
-	}
-
-	if active != nil {
-		currentTime := time.Now().UTC()
-		if *active {
-			// Условия для активных промокодов
-			tx = tx.Where(`
-            (
-                (active_from IS NULL OR active_from <= ?) AND
-                (active_until IS NULL OR active_until >= ?) AND
-                (
-                    (mode = 'COMMON' AND (used_count < max_count OR max_count IS NULL)) OR
-                    (mode = 'UNIQUE' AND (used_count < COALESCE(array_length(promo_unique, 1), 0)))
-                )
-            )
-        `, currentTime, currentTime)
-		} else {
-			// Условия для неактивных промокодов
-			tx = tx.Where(`
-            NOT (
-                (active_from IS NULL OR active_from <= ?) AND
-                (active_until IS NULL OR active_until >= ?) AND
-                (
-                    (mode = 'COMMON' AND (used_count < max_count OR max_count IS NULL)) OR
-                    (mode = 'UNIQUE' AND (used_count < COALESCE(array_length(promo_unique, 1), 0)))
-                )
-            )
-        `, currentTime, currentTime)
-		}
-	}
+	// 3-5. Фильтрация по таргетингу пользователя и активности, архивные промокоды не показываются
+	tx = excludeArchived(tx)
+	tx = applyUserTargeting(tx, &user)
+	tx = applyActiveFilter(tx, active)
 
 	// 6. Получаем общее количество промокодов после фильтрации
 	var totalCount int64
@@ -219,19 +184,73 @@ func (r *promoRepository) GetPromosForUser(userID string, limit, offset int, cat
 	}
 
 	// 7. Применяем сортировку и пагинацию
-	tx = tx.Order("created_at DESC").Limit(limit).Offset(offset)
+	if ranking != nil {
+		tx = applyRanking(tx, ranking)
+	} else {
+		tx = tx.Order("created_at DESC")
+	}
+	tx = tx.Limit(limit).Offset(offset)
 
 	// 8. Получаем список промокодов
 	if err := tx.Find(&promos).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Вызываем SetActiveStatus для каждого промокода
+	// 9-10. Формирование DTO для ответа
+	promoDTOs, err := r.buildPromoDTOs(ctx, userID, promos)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return promoDTOs, totalCount, nil
+}
+
+// excludeArchived скрывает промокоды, архивированные администратором
+func excludeArchived(tx *gorm.DB) *gorm.DB {
+	return tx.Where("promos.archived_at IS NULL")
+}
+
+// applyUserTargeting оставляет только промокоды, таргетинг которых подходит пользователю
+func applyUserTargeting(tx *gorm.DB, user *b2c.User) *gorm.DB {
+	return targeting.Apply(tx, targeting.PromoConditions(targeting.AudienceOf(user)))
+}
+
+// applyRanking сортирует по предрасчитанному скору сегмента и интересу пользователя к категориям промокода
+func applyRanking(tx *gorm.DB, ranking *dto.FeedRanking) *gorm.DB {
+	affinity, _ := json.Marshal(ranking.CategoryAffinity)
+
+	return tx.
+		Joins("LEFT JOIN promo_segment_scores AS pss ON pss.promo_id = promos.id AND pss.segment = ?", ranking.Segment).
+		Joins(`LEFT JOIN LATERAL (
+            SELECT SUM((?::jsonb ->> LOWER(pc))::float8) AS affinity
+            FROM jsonb_array_elements_text(promos.target->'categories') AS pc
+        ) AS aff ON true`, string(affinity)).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "COALESCE(pss.score, 0) + ? * COALESCE(aff.affinity, 0) DESC, promos.created_at DESC",
+			Vars:               []interface{}{ranking.AffinityWeight},
+			WithoutParentheses: true,
+		}})
+}
+
+// applyActiveFilter фильтрует промокоды по активности, nil - без фильтра. Условие то же, что у SetActiveStatus.
+func applyActiveFilter(tx *gorm.DB, active *bool) *gorm.DB {
+	if active == nil {
+		return tx
+	}
+
+	now := sql.Named("now", time.Now().UTC())
+	if *active {
+		return tx.Where(models.ActiveCondition, now)
+	}
+	return tx.Where("NOT ("+models.ActiveCondition+")", now)
+}
+
+// buildPromoDTOs дополняет промокоды данными компании, комментариями, лайками и активациями пользователя
+func (r *promoRepository) buildPromoDTOs(ctx context.Context, userID string, promos []models.Promo) ([]dto.PromoForUser, error) {
 	for i := range promos {
 		promos[i].SetActiveStatus()
 	}
 
-	// 9. Обработка активаций и лайков пользователем
 	promoIDs := make([]string, len(promos))
 	for i, promo := range promos {
 		promoIDs[i] = promo.ID
@@ -240,29 +259,28 @@ func (r *promoRepository) GetPromosForUser(userID string, limit, offset int, cat
 	// Получение информации об активации промокодов пользователем
 	activatedPromoMap, err := r.getActivatedPromos(ctx, userID, promoIDs)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	// Получение информации о лайках промокодов пользователем
 	likedPromoMap, err := r.getLikedPromos(ctx, userID, promoIDs)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// 10. Формирование DTO для ответа
 	promoDTOs := make([]dto.PromoForUser, len(promos))
 	for i, promo := range promos {
 		var company b2b.Company
 
 		result := r.db.WithContext(ctx).Where("id = ?", promo.CompanyID).First(&company)
 		if result.Error != nil {
-			return nil, 0, result.Error
+			return nil, result.Error
 		}
 
 		var commentCount int64
-		result = r.db.Model(&b2c.Comment{}).Where("promo_id = ?", promo.ID).Count(&commentCount)
+		result = r.db.WithContext(ctx).Model(&b2c.Comment{}).Where("promo_id = ?", promo.ID).Count(&commentCount)
 		if result.Error != nil {
-			return nil, 0, result.Error
+			return nil, result.Error
 		}
 
 		promoDTOs[i] = dto.PromoForUser{
@@ -279,69 +297,27 @@ func (r *promoRepository) GetPromosForUser(userID string, limit, offset int, cat
 		}
 	}
 
-	return promoDTOs, totalCount, nil
+	return promoDTOs, nil
 }
 
-func (r *promoRepository) GetPromoForUserByID(promoId, userId string) (*dto.PromoForUser, error) {
-	ctx := context.Background()
+func (r *promoRepository) GetPromoForUserByID(ctx context.Context, promoId, userId string) (*dto.PromoForUser, error) {
 	var promo models.Promo
-	err := r.db.WithContext(ctx).Where("id = ?", promoId).First(&promo).Error
+	err := excludeArchived(r.db.WithContext(ctx)).Where("id = ?", promoId).First(&promo).Error
 	if err != nil {
 		return nil, err
 	}
 
-	promo.SetActiveStatus()
-
-	promoIDs := make([]string, 0)
-
-	promoIDs = append(promoIDs, promo.ID)
-
-	activatedPromoMap, err := r.getActivatedPromos(ctx, userId, promoIDs)
-	if err != nil {
-		return nil, err
-	}
-
-	//3 Получение информации о лайках промокодов пользователем
-	likedPromoMap, err := r.getLikedPromos(ctx, userId, promoIDs)
+	promoDTOs, err := r.buildPromoDTOs(ctx, userId, []models.Promo{promo})
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. Формирование DTO для ответа
-
-	var company b2b.Company
-
-	result := r.db.WithContext(ctx).Where("id = ?", promo.CompanyID).First(&company)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	var commentCount int64
-	result = r.db.Model(&b2c.Comment{}).Where("promo_id = ?", promo.ID).Count(&commentCount)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	promoDTO := dto.PromoForUser{
-		PromoID:           promo.ID,
-		CompanyID:         promo.CompanyID,
-		CompanyName:       company.Name,
-		Description:       promo.Description,
-		ImageURL:          promo.ImageURL,
-		Active:            promo.Active,
-		IsActivatedByUser: activatedPromoMap[promo.ID],
-		LikeCount:         promo.LikeCount,
-		IsLikedByUser:     likedPromoMap[promo.ID],
-		CommentCount:      commentCount,
-	}
-
-	return &promoDTO, nil
+	return &promoDTOs[0], nil
 }
 
-func (r *promoRepository) GetPromoByID(id string) (*models.Promo, error) {
-	ctx := context.TODO()
+func (r *promoRepository) GetPromoByID(ctx context.Context, id string) (*models.Promo, error) {
 	var promo models.Promo
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&promo).Error
+	err := excludeArchived(r.db.WithContext(ctx)).Where("id = ?", id).First(&promo).Error
 	if err != nil {
 		return nil, err
 	}
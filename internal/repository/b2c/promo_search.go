@@ -0,0 +1,93 @@
+package b2c
+
+import (
+	"context"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/models/b2c/dto"
+)
+
+// Запрос строится по всем конфигурациям, которыми проиндексирован search_vector.
+// Запросы отдельных конфигураций нужны для подсветки.
+const searchQuerySQL = `CROSS JOIN (
+    SELECT q.russian || q.english || q.simple AS query, q.russian, q.english, q.simple
+    FROM (SELECT websearch_to_tsquery('russian', ?) AS russian,
+                 websearch_to_tsquery('english', ?) AS english,
+                 websearch_to_tsquery('simple', ?) AS simple) AS q
+) AS search`
+
+const searchHighlightOptions = "StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// searchHighlightSource - описание с экранированным HTML. ts_headline вставляет <b> в текст как есть,
+// поэтому разметка из описания промокода иначе попала бы в ответ. Экранирование как у html.EscapeString.
+const searchHighlightSource = `replace(replace(replace(replace(replace(promos.description,
+    '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+
+// searchHighlightSQL подсвечивает описание той конфигурацией, чей запрос совпал с описанием: ts_headline
+// разбирает текст одной конфигурацией, и лексемы запроса другой конфигурации в нём не находятся.
+// Промокод, найденный только по компании или категории, получает фрагмент описания без подсветки.
+// Параметры - три раза searchHighlightOptions.
+const searchHighlightSQL = `CASE
+    WHEN to_tsvector('russian', promos.description) @@ search.russian
+        THEN ts_headline('russian', ` + searchHighlightSource + `, search.russian, ?)
+    WHEN to_tsvector('english', promos.description) @@ search.english
+        THEN ts_headline('english', ` + searchHighlightSource + `, search.english, ?)
+    ELSE ts_headline('simple', ` + searchHighlightSource + `, search.simple, ?)
+END`
+
+type promoSearchRow struct {
+	models.Promo `gorm:"embedded"`
+	Rank         float64
+	Highlight    string
+}
+
+func (r *promoRepository) SearchPromosForUser(ctx context.Context, userID, query string, limit, offset int, active *bool) ([]dto.PromoSearchResult, int64, error) {
+	var user b2c.User
+	if err := r.db.WithContext(ctx).Model(&b2c.User{}).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, 0, err
+	}
+
+	tx := r.db.WithContext(ctx).Model(&models.Promo{}).
+		Joins(searchQuerySQL, query, query, query).
+		Where("promos.search_vector @@ search.query")
+
+	tx = excludeArchived(tx)
+	tx = applyUserTargeting(tx, &user)
+	tx = applyActiveFilter(tx, active)
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []promoSearchRow
+	if err := tx.
+		Select("promos.*, ts_rank(promos.search_vector, search.query) AS rank, "+searchHighlightSQL+" AS highlight",
+			searchHighlightOptions, searchHighlightOptions, searchHighlightOptions).
+		Order("rank DESC, promos.created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	promos := make([]models.Promo, len(rows))
+	for i := range rows {
+		promos[i] = rows[i].Promo
+	}
+
+	promoDTOs, err := r.buildPromoDTOs(ctx, userID, promos)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]dto.PromoSearchResult, len(rows))
+	for i := range rows {
+		results[i] = dto.PromoSearchResult{
+			PromoForUser: promoDTOs[i],
+			Rank:         rows[i].Rank,
+			Highlight:    rows[i].Highlight,
+		}
+	}
+
+	return results, totalCount, nil
+}
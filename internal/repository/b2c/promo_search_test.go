@@ -0,0 +1,63 @@
+package b2c
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/storage/postgres/pgtest"
+)
+
+// TestSearchHighlight проверяет подсветку совпадений, найденных разными конфигурациями поиска.
+// Нужен TEST_POSTGRES_DSN.
+func TestSearchHighlight(t *testing.T) {
+	db := pgtest.Open(t)
+	ctx := context.Background()
+
+	company := b2b.Company{Name: "Acme", Email: "company@example.com", Password: "hash"}
+	if err := db.Create(&company).Error; err != nil {
+		t.Fatal(err)
+	}
+	user := b2c.User{Name: "Name", Surname: "Surname", Email: "user@example.com", Password: "hash", Age: 20, Country: "ru"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		query       string
+		want        string
+	}{
+		{name: "russian stem", description: "Большие скидки на обувь", query: "скидка", want: "<b>скидки</b>"},
+		{name: "english stem", description: "Discounts on running shoes", query: "runs", want: "<b>running</b>"},
+		{name: "russian stop word", description: "Скидка на все товары", query: "все", want: "<b>все</b>"},
+		{name: "english stop word", description: "Deal of the week", query: "the", want: "<b>the</b>"},
+		{name: "found by company name only", description: "Скидка на обувь", query: "acme", want: "Скидка на обувь"},
+	}
+
+	repo := NewPromoRepository(db, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promo := models.Promo{CompanyID: company.ID, Description: tt.description, Mode: "COMMON", PromoCommon: "CODE", MaxCount: 10}
+			if err := db.Create(&promo).Error; err != nil {
+				t.Fatal(err)
+			}
+			defer db.Delete(&promo)
+
+			results, _, err := repo.SearchPromosForUser(ctx, user.ID, tt.query, 10, 0, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			if !strings.Contains(results[0].Highlight, tt.want) {
+				t.Errorf("highlight = %q, want it to contain %q", results[0].Highlight, tt.want)
+			}
+		})
+	}
+}
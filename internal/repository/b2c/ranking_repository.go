@@ -0,0 +1,143 @@
+package b2c
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"solution/internal/shared/config"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/storage/redis"
+	"strings"
+	"time"
+)
+
+type RankingRepository interface {
+	GetUserSegments(ctx context.Context) ([]b2c.UserSegment, error)
+	RefreshSegmentScores(ctx context.Context, segment b2c.UserSegment, weights *config.Ranking, computedAt time.Time) error
+	DeleteStaleScores(ctx context.Context, before time.Time) error
+	GetCategoryAffinity(ctx context.Context, userID string, weights *config.Ranking) (map[string]float64, error)
+}
+
+type rankingRepository struct {
+	db  *gorm.DB
+	rdb *redis.RDB
+}
+
+func NewRankingRepository(db *gorm.DB, rdb *redis.RDB) RankingRepository {
+	return &rankingRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// Скор промокода для сегмента: глобальная популярность, популярность внутри сегмента,
+// экспоненциальное затухание по возрасту промокода и доля оставшихся активаций
+const refreshSegmentScoresSQL = `
+INSERT INTO promo_segment_scores (segment, promo_id, score, computed_at)
+SELECT @segment, p.id,
+    @popularity * LN(1 + p.like_count + p.used_count)
+    + @segment_popularity * LN(1 + (
+        SELECT COUNT(*) FROM user_likes ul JOIN users u ON u.id = ul.user_id
+        WHERE ul.promo_id = p.id AND LOWER(u.country) = @country AND u.age BETWEEN @age_from AND @age_until
+    ) + (
+        SELECT COUNT(*) FROM promo_activations pa JOIN users u ON u.id = pa.user_id
+        WHERE pa.promo_id = p.id AND LOWER(u.country) = @country AND u.age BETWEEN @age_from AND @age_until
+    ))
+    + @freshness * EXP(-LN(2) * GREATEST(EXTRACT(EPOCH FROM (@now - p.created_at)), 0) / 86400 / @half_life)
+    + @inventory * COALESCE(CASE
+        WHEN p.mode = 'COMMON' THEN GREATEST(p.max_count - p.used_count, 0)::float8 / NULLIF(p.max_count, 0)
+        WHEN p.mode = 'UNIQUE' THEN GREATEST(COALESCE(array_length(p.promo_unique, 1), 0) - p.used_count, 0)::float8
+            / NULLIF(array_length(p.promo_unique, 1), 0)
+    END, 0),
+    @now
+FROM promos p
+ON CONFLICT (segment, promo_id) DO UPDATE SET score = EXCLUDED.score, computed_at = EXCLUDED.computed_at`
+
+const categoryAffinitySQL = `
+SELECT LOWER(c) AS category, SUM(ev.weight) AS weight
+FROM (
+    SELECT promo_id, @like::float8 AS weight FROM user_likes WHERE user_id = @user
+    UNION ALL
+    SELECT promo_id, @activation::float8 FROM promo_activations WHERE user_id = @user
+    UNION ALL
+    SELECT promo_id, @comment::float8 FROM comments WHERE user_id = @user
+) AS ev
+JOIN promos p ON p.id = ev.promo_id
+CROSS JOIN LATERAL jsonb_array_elements_text(p.target->'categories') AS c
+GROUP BY LOWER(c)`
+
+func (r *rankingRepository) GetUserSegments(ctx context.Context) ([]b2c.UserSegment, error) {
+	var rows []struct {
+		Country string
+		Age     int
+	}
+	if err := r.db.WithContext(ctx).
+		Raw("SELECT DISTINCT LOWER(country) AS country, age FROM users").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	segments := make([]b2c.UserSegment, 0)
+	for _, row := range rows {
+		segment := b2c.SegmentOf(row.Age, row.Country)
+		if _, ok := seen[segment.Key()]; ok {
+			continue
+		}
+		seen[segment.Key()] = struct{}{}
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+func (r *rankingRepository) RefreshSegmentScores(ctx context.Context, segment b2c.UserSegment, weights *config.Ranking, computedAt time.Time) error {
+	return r.db.WithContext(ctx).Exec(refreshSegmentScoresSQL, map[string]interface{}{
+		"segment":            segment.Key(),
+		"country":            segment.Country,
+		"age_from":           segment.AgeFrom,
+		"age_until":          segment.AgeUntil,
+		"popularity":         weights.PopularityWeight,
+		"segment_popularity": weights.SegmentPopularityWeight,
+		"freshness":          weights.FreshnessWeight,
+		"half_life":          weights.FreshnessHalfLifeDays,
+		"inventory":          weights.InventoryWeight,
+		"now":                computedAt,
+	}).Error
+}
+
+func (r *rankingRepository) DeleteStaleScores(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("computed_at < ?", before).Delete(&b2c.PromoSegmentScore{}).Error
+}
+
+// GetCategoryAffinity возвращает интерес пользователя к категориям, нормированный в [0, 1]
+func (r *rankingRepository) GetCategoryAffinity(ctx context.Context, userID string, weights *config.Ranking) (map[string]float64, error) {
+	var rows []struct {
+		Category string
+		Weight   float64
+	}
+	if err := r.db.WithContext(ctx).Raw(categoryAffinitySQL, map[string]interface{}{
+		"user":       userID,
+		"like":       weights.LikeWeight,
+		"activation": weights.ActivationWeight,
+		"comment":    weights.CommentWeight,
+	}).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	maxWeight := 0.0
+	for _, row := range rows {
+		if row.Weight > maxWeight {
+			maxWeight = row.Weight
+		}
+	}
+
+	affinity := make(map[string]float64, len(rows))
+	if maxWeight <= 0 {
+		return affinity, nil
+	}
+	for _, row := range rows {
+		affinity[strings.ToLower(row.Category)] = row.Weight / maxWeight
+	}
+
+	return affinity, nil
+}
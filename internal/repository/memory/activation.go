@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c/dto"
+)
+
+// ActivatePromo проверяет и записывает активацию под одной блокировкой хранилища, как GORM реализация
+// под блокировкой строки промокода
+func (r *b2cPromoRepository) ActivatePromo(_ context.Context, promoID, userID string, now time.Time) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	promo, err := r.store.unarchivedPromo(promoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", dto.ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	user, ok := r.store.users[userID]
+	if !ok {
+		return "", dto.ErrNotFound
+	}
+
+	if err := dto.CheckActivation(promo, &user, now); err != nil {
+		return "", err
+	}
+	if err := dto.LimitError(promo.Limits.Check(r.store.activationUsage(promo, userID, now), now)); err != nil {
+		return "", err
+	}
+
+	code := promo.NextCode()
+	r.store.activations = append(r.store.activations, models.PromoActivation{
+		ID:          uuid.NewString(),
+		PromoID:     promoID,
+		UserID:      userID,
+		ActivatedAt: now.UTC().Truncate(time.Microsecond),
+	})
+	stored := r.store.promos[promoID]
+	stored.UsedCount++
+	r.store.promos[promoID] = stored
+	return code, nil
+}
+
+// activationUsage считает активации, от которых зависят limits промокода. Вызывается под s.mu.
+func (s *Store) activationUsage(promo *models.Promo, userID string, now time.Time) models.ActivationUsage {
+	var usage models.ActivationUsage
+	dayStart := promo.DayStart(now)
+	for _, activation := range s.activations {
+		if activation.PromoID != promo.ID {
+			continue
+		}
+		if activation.UserID == userID {
+			usage.UserCount++
+			if usage.LastByUser == nil || activation.ActivatedAt.After(*usage.LastByUser) {
+				last := activation.ActivatedAt
+				usage.LastByUser = &last
+			}
+		}
+		if promo.Limits.Daily > 0 && !activation.ActivatedAt.Before(dayStart) {
+			usage.Today++
+		}
+	}
+	return usage
+}
+
+func (r *b2cPromoRepository) GetActivationHistory(_ context.Context, userID string, limit, offset int) ([]dto.PromoForUser, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var activations []models.PromoActivation
+	for _, activation := range r.store.activations {
+		if activation.UserID == userID {
+			activations = append(activations, activation)
+		}
+	}
+	// порядок как ORDER BY activated_at DESC, id: uuid в Postgres сравниваются как их строки
+	sort.Slice(activations, func(i, j int) bool {
+		if !activations[i].ActivatedAt.Equal(activations[j].ActivatedAt) {
+			return activations[i].ActivatedAt.After(activations[j].ActivatedAt)
+		}
+		return activations[i].ID < activations[j].ID
+	})
+
+	var promos []models.Promo
+	for _, activation := range activations {
+		promo, err := r.store.unarchivedPromo(activation.PromoID)
+		if err != nil {
+			continue
+		}
+		promos = append(promos, *promo)
+	}
+
+	dtos, err := r.store.promoDTOs(userID, page(promos, limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+	return dtos, int64(len(promos)), nil
+}
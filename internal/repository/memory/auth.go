@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	b2b_repo "solution/internal/repository/b2b"
+	b2c_repo "solution/internal/repository/b2c"
+	"solution/internal/shared/models/b2b"
+	b2b_dto "solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/models/b2c"
+)
+
+type b2bAuthRepository struct {
+	store *Store
+}
+
+func NewB2BAuthRepository(store *Store) b2b_repo.AuthRepository {
+	return &b2bAuthRepository{store: store}
+}
+
+func (r *b2bAuthRepository) CreateCompany(_ context.Context, req b2b_dto.SignUpRequest) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.companyByEmail(req.Email) != nil {
+		return "", b2b_repo.ErrEmailAlreadyRegistered
+	}
+
+	company := b2b.Company{
+		ID:       uuid.NewString(),
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+	}
+	r.store.companies[company.ID] = company
+	return company.ID, nil
+}
+
+func (r *b2bAuthRepository) GetCompany(_ context.Context, email string) (*b2b.Company, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	company := r.store.companyByEmail(email)
+	if company == nil {
+		return nil, b2b_repo.ErrInvalidCredentials
+	}
+	return company, nil
+}
+
+func (r *b2bAuthRepository) IsEmailRegistered(_ context.Context, email string) bool {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.companyByEmail(email) != nil
+}
+
+// WhitelistToken не ограничивает срок жизни: токен в памяти живёт до конца теста
+func (r *b2bAuthRepository) WhitelistToken(_ context.Context, token, id string, _ time.Duration) error {
+	r.store.setToken(id, token)
+	return nil
+}
+
+func (r *b2bAuthRepository) ValidateToken(_ context.Context, userId string) (string, error) {
+	return r.store.token(userId), nil
+}
+
+type b2cAuthRepository struct {
+	store *Store
+}
+
+func NewB2CAuthRepository(store *Store) b2c_repo.AuthRepository {
+	return &b2cAuthRepository{store: store}
+}
+
+func (r *b2cAuthRepository) CreateUser(_ context.Context, user *b2c.User) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.userByEmail(user.Email) != nil {
+		return "", b2c_repo.ErrEmailAlreadyRegistered
+	}
+
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+	user.CreatedAt = r.store.timestamp()
+	user.Version = 1
+	r.store.users[user.ID] = *user
+	return user.ID, nil
+}
+
+func (r *b2cAuthRepository) GetUserByEmail(_ context.Context, email string) (*b2c.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.userByEmail(email), nil
+}
+
+func (r *b2cAuthRepository) IsEmailRegistered(_ context.Context, email string) bool {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.userByEmail(email) != nil
+}
+
+func (r *b2cAuthRepository) WhitelistToken(_ context.Context, token, id string, _ time.Duration) error {
+	r.store.setToken(id, token)
+	return nil
+}
+
+func (r *b2cAuthRepository) ValidateToken(_ context.Context, userId string) (string, error) {
+	return r.store.token(userId), nil
+}
+
+// companyByEmail и userByEmail сравнивают email точно, как уникальный индекс в БД. Вызываются под s.mu.
+func (s *Store) companyByEmail(email string) *b2b.Company {
+	for _, company := range s.companies {
+		if company.Email == email {
+			return &company
+		}
+	}
+	return nil
+}
+
+func (s *Store) userByEmail(email string) *b2c.User {
+	for _, user := range s.users {
+		if user.Email == email {
+			return &user
+		}
+	}
+	return nil
+}
+
+// токены, как и в Redis, хранятся по id владельца; отсутствующий токен - пустая строка без ошибки
+func (s *Store) setToken(id, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[id] = token
+}
+
+func (s *Store) token(id string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tokens[id]
+}
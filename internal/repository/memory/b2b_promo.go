@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	b2b_repo "solution/internal/repository/b2b"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/targeting"
+)
+
+type b2bPromoRepository struct {
+	store *Store
+}
+
+func NewB2BPromoRepository(store *Store) b2b_repo.PromoRepository {
+	return &b2bPromoRepository{store: store}
+}
+
+func (r *b2bPromoRepository) CreatePromo(_ context.Context, req dto.PromoCreateRequest, actor models.Actor) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	promo := clonePromo(models.Promo{
+		ID:          uuid.NewString(),
+		CompanyID:   req.CompanyID,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+		Mode:        req.Mode,
+		PromoCommon: req.PromoCommon,
+		PromoUnique: pq.StringArray(req.PromoUnique),
+		MaxCount:    *req.MaxCount,
+		ActiveFrom:  req.ActiveFrom,
+		ActiveUntil: req.ActiveUntil,
+		Timezone:    req.Timezone,
+	})
+	promo.Target = storedTarget(*req.Target)
+	if req.Limits != nil {
+		promo.Limits = *req.Limits
+	}
+	promo.ActiveFrom = storedDate(promo.ActiveFrom)
+	promo.ActiveUntil = storedDate(promo.ActiveUntil)
+	if err := promo.UpdateWindow(); err != nil {
+		return "", err
+	}
+	promo.CreatedAt = r.store.timestamp()
+	promo.Version = 1
+
+	change, err := models.NewPromoChange(models.PromoChangeCreate, actor, nil, &promo)
+	if err != nil {
+		return "", err
+	}
+	r.store.addPromoChange(change)
+	r.store.promos[promo.ID] = promo
+	return promo.ID, nil
+}
+
+func (r *b2bPromoRepository) GetPromos(_ context.Context, companyID string, limit, offset int, sortBy string, country []string) ([]models.Promo, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var promos []models.Promo
+	for _, promo := range r.store.promos {
+		if promo.CompanyID != companyID {
+			continue
+		}
+		if len(country) > 0 && !targetsAnyCountry(promo.Target, country) {
+			continue
+		}
+		promos = append(promos, clonePromo(promo))
+	}
+
+	switch sortBy {
+	case "active_from":
+		sortByDateDesc(promos, func(p models.Promo) *b2b.Date { return p.ActiveFrom })
+	case "active_until":
+		sortByDateDesc(promos, func(p models.Promo) *b2b.Date { return p.ActiveUntil })
+	default:
+		sortByCreatedDesc(promos)
+	}
+
+	return page(promos, limit, offset), int64(len(promos)), nil
+}
+
+func (r *b2bPromoRepository) GetPromoByID(_ context.Context, promoID string) (*models.Promo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.promo(promoID)
+}
+
+func (r *b2bPromoRepository) UpdatePromo(_ context.Context, promoID string, update dto.PromoUpdate, actor models.Actor) (*models.Promo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	promo, err := r.store.promo(promoID)
+	if err != nil {
+		return nil, err
+	}
+	if update.ExpectedVersion != 0 && promo.Version != update.ExpectedVersion {
+		return nil, dto.ErrorPromoModified
+	}
+	if promo.Mode == "COMMON" && *update.MaxCount < promo.UsedCount {
+		return nil, dto.ErrorMaxCountBelowUsed
+	}
+	before := clonePromo(*promo)
+
+	promo.Description = update.Description
+	promo.ImageURL = update.ImageURL
+	promo.Target = cloneTarget(update.Target)
+	promo.MaxCount = *update.MaxCount
+	promo.Limits = update.Limits
+	promo.ActiveFrom = cloneDate(update.ActiveFrom)
+	promo.ActiveUntil = cloneDate(update.ActiveUntil)
+	promo.Timezone = update.Timezone
+	if err := promo.UpdateWindow(); err != nil {
+		return nil, err
+	}
+
+	change, err := models.NewPromoChange(models.PromoChangeUpdate, actor, &before, promo)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		// ничего не изменилось: версия и история остаются прежними
+		return promo, nil
+	}
+	promo.Version++
+	r.store.addPromoChange(change)
+
+	stored := clonePromo(*promo)
+	stored.Target = storedTarget(stored.Target)
+	stored.ActiveFrom = storedDate(stored.ActiveFrom)
+	stored.ActiveUntil = storedDate(stored.ActiveUntil)
+	r.store.promos[promoID] = stored
+	return promo, nil
+}
+
+func (r *b2bPromoRepository) UpdatePromoCodes(_ context.Context, promoID string, update dto.PromoCodesUpdate, actor models.Actor) (*models.Promo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	promo, err := r.store.promo(promoID)
+	if err != nil {
+		return nil, err
+	}
+	if update.ExpectedVersion != 0 && promo.Version != update.ExpectedVersion {
+		return nil, dto.ErrorPromoModified
+	}
+	if promo.UsedCount != update.UsedCount {
+		return nil, dto.ErrorPromoActivated
+	}
+	before := clonePromo(*promo)
+
+	promo.Mode = update.Mode
+	promo.PromoCommon = update.PromoCommon
+	promo.PromoUnique = append(pq.StringArray(nil), update.PromoUnique...)
+	promo.RetiredCodes = append(pq.StringArray(nil), update.RetiredCodes...)
+	promo.MaxCount = update.MaxCount
+
+	change, err := models.NewPromoChange(models.PromoChangeUpdate, actor, &before, promo)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return promo, nil
+	}
+	promo.Version++
+	r.store.addPromoChange(change)
+
+	r.store.promos[promoID] = clonePromo(*promo)
+	return promo, nil
+}
+
+func (r *b2bPromoRepository) GetPromoHistory(_ context.Context, promoID string, limit, offset int) ([]models.PromoChange, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// записи добавляются по возрастанию времени, новые первыми - с конца
+	var changes []models.PromoChange
+	for i := len(r.store.promoChanges) - 1; i >= 0; i-- {
+		if r.store.promoChanges[i].PromoID == promoID {
+			changes = append(changes, r.store.promoChanges[i])
+		}
+	}
+
+	return page(changes, limit, offset), int64(len(changes)), nil
+}
+
+func (r *b2bPromoRepository) GetPromoStatByID(_ context.Context, promoID string) (*dto.PromoStatResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	promo, ok := r.store.promos[promoID]
+	if !ok {
+		return nil, dto.ErrorPromoNotFound
+	}
+
+	// как JOIN с users: активации удалённых пользователей не учитываются, страна - как у пользователя
+	byCountry := make(map[string]int)
+	for _, activation := range r.store.activations {
+		if activation.PromoID != promoID {
+			continue
+		}
+		if user, ok := r.store.users[activation.UserID]; ok {
+			byCountry[user.Country]++
+		}
+	}
+
+	countries := make([]dto.CountryActivation, 0, len(byCountry))
+	for country, activations := range byCountry {
+		countries = append(countries, dto.CountryActivation{Country: dto.Country{Code: country}, Activations: activations})
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		return strings.ToLower(countries[i].Country.Code) < strings.ToLower(countries[j].Country.Code)
+	})
+
+	return &dto.PromoStatResponse{
+		ActivationsCount: promo.UsedCount,
+		Countries:        countries,
+	}, nil
+}
+
+func (r *b2bPromoRepository) GetCompanyById(_ context.Context, id string) (*b2b.Company, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	company, ok := r.store.companies[id]
+	if !ok {
+		return nil, dto.ErrNotFound
+	}
+	return &company, nil
+}
+
+func (r *b2bPromoRepository) EstimateAudience(_ context.Context, target models.Target) (*dto.AudienceEstimateResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	estimate := &dto.AudienceEstimateResponse{
+		Countries: []dto.AudienceCountry{},
+		AgeGroups: []dto.AudienceAgeGroup{},
+	}
+	countries := make(map[string]int64)
+	ageGroups := make(map[b2c.UserSegment]int64)
+	for _, user := range r.store.users {
+		if !targeting.Matches(target, targeting.AudienceOf(&user)) {
+			continue
+		}
+		estimate.UsersCount++
+		countries[strings.ToUpper(user.Country)]++
+		ageGroups[b2c.SegmentOf(user.Age, "")]++
+	}
+
+	for country, users := range countries {
+		estimate.Countries = append(estimate.Countries, dto.AudienceCountry{Country: country, Users: users})
+	}
+	sort.Slice(estimate.Countries, func(i, j int) bool {
+		return estimate.Countries[i].Country < estimate.Countries[j].Country
+	})
+
+	for segment, users := range ageGroups {
+		estimate.AgeGroups = append(estimate.AgeGroups, dto.AudienceAgeGroup{
+			AgeFrom:  segment.AgeFrom,
+			AgeUntil: segment.AgeUntil,
+			Users:    users,
+		})
+	}
+	sort.Slice(estimate.AgeGroups, func(i, j int) bool {
+		return estimate.AgeGroups[i].AgeFrom < estimate.AgeGroups[j].AgeFrom
+	})
+
+	return estimate, nil
+}
+
+// promo возвращает копию промокода; отсутствующий промокод - gorm.ErrRecordNotFound, как у First.
+// Вызывается под s.mu.
+func (s *Store) promo(id string) (*models.Promo, error) {
+	promo, ok := s.promos[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	promo = clonePromo(promo)
+	return &promo, nil
+}
+
+// targetsAnyCountry - фильтр списка компании по странам: хотя бы одна страна входит в таргетинг и не исключена
+func targetsAnyCountry(t models.Target, countries []string) bool {
+	for _, country := range countries {
+		if targeting.Matches(models.Target{Countries: t.Countries, ExcludeCountries: t.ExcludeCountries}, targeting.Audience{Country: country}) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortByCreatedDesc(promos []models.Promo) {
+	sort.SliceStable(promos, func(i, j int) bool {
+		return promos[i].CreatedAt.After(promos[j].CreatedAt)
+	})
+}
+
+// sortByDateDesc повторяет ORDER BY ... DESC в Postgres: пустые даты идут первыми
+func sortByDateDesc(promos []models.Promo, date func(models.Promo) *b2b.Date) {
+	sortByCreatedDesc(promos)
+	sort.SliceStable(promos, func(i, j int) bool {
+		a, b := date(promos[i]), date(promos[j])
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Time.After(b.Time)
+	})
+}
@@ -0,0 +1,392 @@
+package memory
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	b2c_repo "solution/internal/repository/b2c"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/models/b2c/dto"
+	"solution/internal/shared/targeting"
+)
+
+type b2cPromoRepository struct {
+	store *Store
+}
+
+func NewB2CPromoRepository(store *Store) b2c_repo.PromoRepository {
+	return &b2cPromoRepository{store: store}
+}
+
+func (r *b2cPromoRepository) GetPromosForUser(_ context.Context, userID string, limit, offset int, category string, active *bool, ranking *dto.FeedRanking) ([]dto.PromoForUser, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[userID]
+	if !ok {
+		return nil, 0, gorm.ErrRecordNotFound
+	}
+
+	var promos []models.Promo
+	for _, promo := range r.store.visiblePromos(&user, active) {
+		if category != "" && !containsFold(promo.Target.Categories, category) {
+			continue
+		}
+		promos = append(promos, promo)
+	}
+
+	sortByCreatedDesc(promos)
+	if ranking != nil {
+		sort.SliceStable(promos, func(i, j int) bool {
+			return affinity(promos[i], ranking) > affinity(promos[j], ranking)
+		})
+	}
+
+	dtos, err := r.store.promoDTOs(userID, page(promos, limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+	return dtos, int64(len(promos)), nil
+}
+
+func (r *b2cPromoRepository) SearchPromosForUser(_ context.Context, userID, query string, limit, offset int, active *bool) ([]dto.PromoSearchResult, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[userID]
+	if !ok {
+		return nil, 0, gorm.ErrRecordNotFound
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	type match struct {
+		promo models.Promo
+		rank  float64
+	}
+	var matches []match
+	for _, promo := range r.store.visiblePromos(&user, active) {
+		if rank := searchRank(promo.Description, terms); rank > 0 {
+			matches = append(matches, match{promo: promo, rank: rank})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank > matches[j].rank
+		}
+		return matches[i].promo.CreatedAt.After(matches[j].promo.CreatedAt)
+	})
+	total := int64(len(matches))
+	matches = page(matches, limit, offset)
+
+	promos := make([]models.Promo, len(matches))
+	for i := range matches {
+		promos[i] = matches[i].promo
+	}
+	dtos, err := r.store.promoDTOs(userID, promos)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]dto.PromoSearchResult, len(matches))
+	for i := range matches {
+		results[i] = dto.PromoSearchResult{
+			PromoForUser: dtos[i],
+			Rank:         matches[i].rank,
+			Highlight:    highlight(matches[i].promo.Description, terms),
+		}
+	}
+	return results, total, nil
+}
+
+func (r *b2cPromoRepository) GetPromoByID(_ context.Context, id string) (*models.Promo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.unarchivedPromo(id)
+}
+
+func (r *b2cPromoRepository) GetPromoForUserByID(_ context.Context, promoId, userId string) (*dto.PromoForUser, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	promo, err := r.store.unarchivedPromo(promoId)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos, err := r.store.promoDTOs(userId, []models.Promo{*promo})
+	if err != nil {
+		return nil, err
+	}
+	return &dtos[0], nil
+}
+
+// LikePromo, как и GORM реализация, не проверяет существование промокода - это делает сервис
+func (r *b2cPromoRepository) LikePromo(_ context.Context, promoID, userID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	like := b2c.UserLike{UserID: userID, PromoID: promoID}
+	if r.store.likes[like] {
+		return false, nil
+	}
+	r.store.likes[like] = true
+
+	if promo, ok := r.store.promos[promoID]; ok {
+		promo.LikeCount++
+		r.store.promos[promoID] = promo
+	}
+	return true, nil
+}
+
+func (r *b2cPromoRepository) UnlikePromo(_ context.Context, promoID, userID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	promo, ok := r.store.promos[promoID]
+	if !ok {
+		return dto.ErrNotFound
+	}
+
+	like := b2c.UserLike{UserID: userID, PromoID: promoID}
+	if !r.store.likes[like] {
+		return nil
+	}
+	delete(r.store.likes, like)
+
+	if promo.LikeCount > 0 {
+		promo.LikeCount--
+		r.store.promos[promoID] = promo
+	}
+	return nil
+}
+
+func (r *b2cPromoRepository) AddComment(_ context.Context, comment *b2c.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if comment.ID == "" {
+		comment.ID = uuid.NewString()
+	}
+	now := r.store.timestamp()
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = now
+	}
+	if comment.UpdatedAt.IsZero() {
+		comment.UpdatedAt = now
+	}
+
+	stored := *comment
+	stored.User = b2c.User{}
+	r.store.comments[comment.ID] = stored
+	return nil
+}
+
+func (r *b2cPromoRepository) GetComments(_ context.Context, promoID string, limit, offset int) ([]b2c.Comment, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var comments []b2c.Comment
+	for _, comment := range r.store.comments {
+		if comment.PromoID == promoID {
+			comments = append(comments, comment)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.After(comments[j].CreatedAt)
+	})
+
+	return page(comments, limit, offset), int64(len(comments)), nil
+}
+
+func (r *b2cPromoRepository) GetCommentByID(_ context.Context, commentID string) (*b2c.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comment, ok := r.store.comments[commentID]
+	if !ok {
+		return &b2c.Comment{}, gorm.ErrRecordNotFound
+	}
+	return &comment, nil
+}
+
+// UpdateComment сохраняет комментарий целиком, как Save: несуществующий комментарий создаётся
+func (r *b2cPromoRepository) UpdateComment(_ context.Context, comment *b2c.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if comment.ID == "" {
+		comment.ID = uuid.NewString()
+	}
+	now := r.store.timestamp()
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = now
+	}
+	comment.UpdatedAt = now
+
+	stored := *comment
+	stored.User = b2c.User{}
+	r.store.comments[comment.ID] = stored
+	return nil
+}
+
+func (r *b2cPromoRepository) DeleteComment(_ context.Context, commentID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.comments, commentID)
+	return nil
+}
+
+func (r *b2cPromoRepository) GetUserByID(_ context.Context, userID string) (*b2c.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[userID]
+	if !ok {
+		return nil, dto.ErrNotFound
+	}
+	return &user, nil
+}
+
+// unarchivedPromo - промокод для пользователя: архивный промокод считается отсутствующим.
+// Вызывается под s.mu.
+func (s *Store) unarchivedPromo(id string) (*models.Promo, error) {
+	promo, err := s.promo(id)
+	if err != nil {
+		return nil, err
+	}
+	if promo.ArchivedAt != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return promo, nil
+}
+
+// visiblePromos - промокоды, таргетинг которых подходит пользователю, с фильтром по активности.
+// Вызывается под s.mu.
+func (s *Store) visiblePromos(user *b2c.User, active *bool) []models.Promo {
+	audience := targeting.AudienceOf(user)
+
+	var promos []models.Promo
+	for _, promo := range s.promos {
+		if promo.ArchivedAt != nil || !targeting.Matches(promo.Target, audience) {
+			continue
+		}
+		promo = clonePromo(promo)
+		promo.SetActiveStatus()
+		if active != nil && promo.Active != *active {
+			continue
+		}
+		promos = append(promos, promo)
+	}
+	return promos
+}
+
+// promoDTOs дополняет промокоды компанией, числом комментариев, лайком и активацией пользователя.
+// Вызывается под s.mu.
+func (s *Store) promoDTOs(userID string, promos []models.Promo) ([]dto.PromoForUser, error) {
+	dtos := make([]dto.PromoForUser, len(promos))
+	for i, promo := range promos {
+		promo.SetActiveStatus()
+
+		company, ok := s.companies[promo.CompanyID]
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+
+		var commentCount int64
+		for _, comment := range s.comments {
+			if comment.PromoID == promo.ID {
+				commentCount++
+			}
+		}
+
+		activated := false
+		for _, activation := range s.activations {
+			if activation.PromoID == promo.ID && activation.UserID == userID {
+				activated = true
+				break
+			}
+		}
+
+		dtos[i] = dto.PromoForUser{
+			PromoID:           promo.ID,
+			CompanyID:         promo.CompanyID,
+			CompanyName:       company.Name,
+			Description:       promo.Description,
+			ImageURL:          promo.ImageURL,
+			Active:            promo.Active,
+			IsActivatedByUser: activated,
+			LikeCount:         promo.LikeCount,
+			IsLikedByUser:     s.likes[b2c.UserLike{UserID: userID, PromoID: promo.ID}],
+			CommentCount:      commentCount,
+		}
+	}
+	return dtos, nil
+}
+
+// affinity - интерес пользователя к категориям промокода с весом из ранжирования; скор сегмента не хранится
+func affinity(promo models.Promo, ranking *dto.FeedRanking) float64 {
+	var sum float64
+	for _, category := range promo.Target.Categories {
+		sum += ranking.CategoryAffinity[strings.ToLower(category)]
+	}
+	return ranking.AffinityWeight * sum
+}
+
+// searchRank - число вхождений слов запроса; промокод подходит, только если встречаются все слова
+func searchRank(description string, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	text := strings.ToLower(description)
+	var rank float64
+	for _, term := range terms {
+		count := strings.Count(text, term)
+		if count == 0 {
+			return 0
+		}
+		rank += float64(count)
+	}
+	return rank
+}
+
+// highlight выделяет слова запроса так же, как ts_headline с StartSel=<b>, StopSel=</b>; HTML описания экранируется
+func highlight(description string, terms []string) string {
+	text := strings.ToLower(description)
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(description); {
+		matched := ""
+		for _, term := range terms {
+			if strings.HasPrefix(text[i:], term) && len(term) > len(matched) {
+				matched = term
+			}
+		}
+		if matched == "" {
+			i++
+			continue
+		}
+		b.WriteString(html.EscapeString(description[start:i]))
+		b.WriteString("<b>" + html.EscapeString(description[i:i+len(matched)]) + "</b>")
+		i += len(matched)
+		start = i
+	}
+	b.WriteString(html.EscapeString(description[start:]))
+	return b.String()
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
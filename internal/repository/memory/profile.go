@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+
+	b2c_repo "solution/internal/repository/b2c"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/models/b2c/dto"
+)
+
+type profileRepository struct {
+	store *Store
+}
+
+func NewProfileRepository(store *Store) b2c_repo.ProfileRepository {
+	return &profileRepository{store: store}
+}
+
+func (r *profileRepository) GetProfile(_ context.Context, userID string) (*b2c.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (r *profileRepository) UpdateProfile(_ context.Context, userID string, req *dto.ProfileUpdateRequest) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[userID]
+	if req.ExpectedVersion != 0 && (!ok || user.Version != req.ExpectedVersion) {
+		return dto.ErrProfileModified
+	}
+	if req.Name == nil && req.Surname == nil && req.AvatarURL == nil && req.Password == nil {
+		return dto.ErrNoFieldsToUpdate
+	}
+	if !ok {
+		// GORM не различает отсутствующего пользователя и пустое обновление: ни одна строка не изменилась
+		return dto.ErrNoFieldsToUpdate
+	}
+
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Surname != nil {
+		user.Surname = *req.Surname
+	}
+	if req.AvatarURL != nil {
+		user.AvatarURL = *req.AvatarURL
+	}
+	if req.Password != nil {
+		user.Password = *req.Password
+	}
+	user.Version++
+	r.store.users[userID] = user
+	return nil
+}
@@ -0,0 +1,180 @@
+// Package memory - репозитории в памяти с тем же поведением, что и GORM реализации: ошибки, сортировки,
+// таргетинг и подсчёты совпадают. Предназначены для тестов сервисов; совпадение с GORM проверяет repotest.
+//
+// Не моделируются предрасчитанные скоры ранжирования (лента с ranking сортируется только по интересу
+// к категориям) и морфология полнотекстового поиска (ищется вхождение слов запроса без учёта регистра).
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2c"
+)
+
+// Store - общие данные всех репозиториев в памяти, как одна база для GORM репозиториев
+type Store struct {
+	mu sync.RWMutex
+
+	companies   map[string]b2b.Company
+	users       map[string]b2c.User
+	promos      map[string]models.Promo
+	likes       map[b2c.UserLike]bool
+	comments    map[string]b2c.Comment
+	activations []models.PromoActivation
+	tokens      map[string]string
+	// promoChanges - история промокодов в порядке записи
+	promoChanges []models.PromoChange
+
+	lastTimestamp time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		companies: make(map[string]b2b.Company),
+		users:     make(map[string]b2c.User),
+		promos:    make(map[string]models.Promo),
+		likes:     make(map[b2c.UserLike]bool),
+		comments:  make(map[string]b2c.Comment),
+		tokens:    make(map[string]string),
+	}
+}
+
+// AddActivation записывает активацию так же, как её записала бы активация промокода:
+// строка активации и увеличение used_count
+func (s *Store) AddActivation(_ context.Context, promoID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.activations = append(s.activations, models.PromoActivation{
+		ID:          uuid.NewString(),
+		PromoID:     promoID,
+		UserID:      userID,
+		ActivatedAt: s.timestamp(),
+	})
+	if promo, ok := s.promos[promoID]; ok {
+		promo.UsedCount++
+		s.promos[promoID] = promo
+	}
+	return nil
+}
+
+// addPromoChange записывает изменение в историю с ID и временем, как их выставила бы БД. Вызывается под s.mu.
+func (s *Store) addPromoChange(change *models.PromoChange) {
+	change.ID = uuid.NewString()
+	change.CreatedAt = s.timestamp()
+	s.promoChanges = append(s.promoChanges, *change)
+}
+
+// timestamp возвращает строго возрастающее время с точностью Postgres, чтобы сортировка по created_at
+// была такой же однозначной, как у записей, созданных по очереди. Вызывается под s.mu.
+func (s *Store) timestamp() time.Time {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(s.lastTimestamp) {
+		now = s.lastTimestamp.Add(time.Microsecond)
+	}
+	s.lastTimestamp = now
+	return now
+}
+
+// page применяет LIMIT/OFFSET к уже отсортированной выборке
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// clonePromo копирует промокод вместе со срезами и указателями: изменения вызывающего не попадают в хранилище,
+// как не попадают в БД изменения загруженной модели
+func clonePromo(p models.Promo) models.Promo {
+	p.PromoUnique = append(p.PromoUnique[:0:0], p.PromoUnique...)
+	p.RetiredCodes = append(p.RetiredCodes[:0:0], p.RetiredCodes...)
+	p.Target = cloneTarget(p.Target)
+	if p.ActiveFrom != nil {
+		activeFrom := *p.ActiveFrom
+		p.ActiveFrom = &activeFrom
+	}
+	if p.ActiveUntil != nil {
+		activeUntil := *p.ActiveUntil
+		p.ActiveUntil = &activeUntil
+	}
+	if p.StartsAt != nil {
+		startsAt := *p.StartsAt
+		p.StartsAt = &startsAt
+	}
+	if p.EndsAt != nil {
+		endsAt := *p.EndsAt
+		p.EndsAt = &endsAt
+	}
+	if p.ArchivedAt != nil {
+		archivedAt := *p.ArchivedAt
+		p.ArchivedAt = &archivedAt
+	}
+	return p
+}
+
+func cloneTarget(t models.Target) models.Target {
+	if t.AgeFrom != nil {
+		ageFrom := *t.AgeFrom
+		t.AgeFrom = &ageFrom
+	}
+	if t.AgeUntil != nil {
+		ageUntil := *t.AgeUntil
+		t.AgeUntil = &ageUntil
+	}
+	if t.RegisteredFrom != nil {
+		registeredFrom := *t.RegisteredFrom
+		t.RegisteredFrom = &registeredFrom
+	}
+	if t.RegisteredUntil != nil {
+		registeredUntil := *t.RegisteredUntil
+		t.RegisteredUntil = &registeredUntil
+	}
+	t.Countries = append(t.Countries[:0:0], t.Countries...)
+	t.ExcludeCountries = append(t.ExcludeCountries[:0:0], t.ExcludeCountries...)
+	t.Categories = append(t.Categories[:0:0], t.Categories...)
+	return t
+}
+
+func cloneDate(d *b2b.Date) *b2b.Date {
+	if d == nil {
+		return nil
+	}
+	date := *d
+	return &date
+}
+
+// storedDate - дата в том виде, в каком её возвращает колонка date: пустая дата хранится как NULL
+func storedDate(d *b2b.Date) *b2b.Date {
+	if d == nil || d.Time.IsZero() {
+		return nil
+	}
+	year, month, day := d.Time.Date()
+	return &b2b.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// storedTarget - таргетинг в том виде, в каком его возвращает jsonb колонка: страны и пол в верхнем регистре,
+// пустые списки отсутствуют
+func storedTarget(t models.Target) models.Target {
+	t = cloneTarget(t)
+	t.Normalize()
+	if len(t.Countries) == 0 {
+		t.Countries = nil
+	}
+	if len(t.ExcludeCountries) == 0 {
+		t.ExcludeCountries = nil
+	}
+	if len(t.Categories) == 0 {
+		t.Categories = nil
+	}
+	return t
+}
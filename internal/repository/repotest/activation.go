@@ -0,0 +1,103 @@
+package repotest
+
+import (
+	"time"
+
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b/dto"
+	b2c_dto "solution/internal/shared/models/b2c/dto"
+)
+
+func (s *suite) activation() error {
+	company, err := s.company()
+	if err != nil {
+		return err
+	}
+	user, err := s.user(25, "ru")
+	if err != nil {
+		return err
+	}
+	other, err := s.user(25, "fr")
+	if err != nil {
+		return err
+	}
+
+	limited, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.Limits = &models.ActivationLimits{PerUser: 2, CooldownSeconds: 60}
+	})
+	if err != nil {
+		return err
+	}
+	unique, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.Mode = "UNIQUE"
+		req.PromoCommon = ""
+		req.PromoUnique = []string{"unique-1", "unique-2"}
+		req.MaxCount = intPtr(1)
+		req.Target = &models.Target{Countries: []string{"ru"}}
+	})
+	if err != nil {
+		return err
+	}
+	daily, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.Limits = &models.ActivationLimits{Daily: 1}
+	})
+	if err != nil {
+		return err
+	}
+
+	// моменты активаций задаются явно, как их передаёт сервис
+	now := time.Now().UTC().Truncate(time.Second)
+	activate := func(what, promoID, userID string, at time.Time, want string, wantErr error) {
+		code, err := s.repos.B2CPromo.ActivatePromo(s.ctx, promoID, userID, at)
+		if wantErr != nil {
+			s.errorIs(what, err, wantErr)
+			return
+		}
+		if s.noError(what, err) {
+			s.equal(what+" code", code, want)
+		}
+	}
+
+	activate("first", limited, user.ID, now, "CODE", nil)
+	activate("during cooldown", limited, user.ID, now.Add(59*time.Second), "", b2c_dto.ErrActivationCooldown)
+	activate("after cooldown", limited, user.ID, now.Add(61*time.Second), "CODE", nil)
+	activate("over per_user", limited, user.ID, now.Add(time.Hour), "", b2c_dto.ErrActivationLimit)
+	activate("another user", limited, other.ID, now, "CODE", nil)
+
+	activate("first unique", unique, user.ID, now.Add(time.Second), "unique-1", nil)
+	activate("not targeted", unique, other.ID, now, "", b2c_dto.ErrPromoNotTargeted)
+	activate("second unique", unique, user.ID, now.Add(2*time.Second), "unique-2", nil)
+	activate("exhausted", unique, user.ID, now.Add(3*time.Second), "", b2c_dto.ErrPromoExhausted)
+
+	activate("daily", daily, user.ID, now.Add(3*time.Second), "CODE", nil)
+	activate("over daily", daily, other.ID, now.Add(4*time.Second), "", b2c_dto.ErrActivationDailyLimit)
+	activate("next day", daily, other.ID, now.Add(24*time.Hour), "CODE", nil)
+
+	activate("unknown promo", missingID(), user.ID, now, "", b2c_dto.ErrNotFound)
+
+	promo, err := s.repos.B2CPromo.GetPromoByID(s.ctx, limited)
+	if s.noError("get activated promo", err) {
+		s.equal("used_count", promo.UsedCount, 3)
+	}
+
+	history, total, err := s.repos.B2CPromo.GetActivationHistory(s.ctx, user.ID, 10, 0)
+	if s.noError("history", err) {
+		ids := make([]string, len(history))
+		for i := range history {
+			ids[i] = history[i].PromoID
+		}
+		s.equal("history ids", ids, []string{limited, daily, unique, unique, limited})
+		s.equal("history total", total, int64(5))
+		if len(history) > 0 {
+			s.equal("activated by user", history[0].IsActivatedByUser, true)
+		}
+	}
+	history, total, err = s.repos.B2CPromo.GetActivationHistory(s.ctx, user.ID, 2, 1)
+	if s.noError("history page", err) && len(history) == 2 {
+		s.equal("page first", history[0].PromoID, daily)
+		s.equal("page total", total, int64(5))
+	} else if err == nil {
+		s.errorf("history page: got %d promos, want 2", len(history))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package repotest
+
+import (
+	"context"
+	"time"
+
+	b2b_repo "solution/internal/repository/b2b"
+	b2c_repo "solution/internal/repository/b2c"
+	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/models/b2c"
+	b2c_dto "solution/internal/shared/models/b2c/dto"
+)
+
+func (s *suite) b2bAuth() error {
+	email := s.email("company")
+	id, err := s.repos.B2BAuth.CreateCompany(s.ctx, dto.SignUpRequest{Name: "Company", Email: email, Password: "hash"})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repos.B2BAuth.CreateCompany(s.ctx, dto.SignUpRequest{Name: "Other", Email: email, Password: "hash"})
+	s.errorIs("duplicate email", err, b2b_repo.ErrEmailAlreadyRegistered)
+
+	s.equal("registered email", s.repos.B2BAuth.IsEmailRegistered(s.ctx, email), true)
+	s.equal("unknown email", s.repos.B2BAuth.IsEmailRegistered(s.ctx, s.email("company")), false)
+
+	company, err := s.repos.B2BAuth.GetCompany(s.ctx, email)
+	if s.noError("get company", err) {
+		s.equal("company id", company.ID, id)
+		s.equal("company name", company.Name, "Company")
+		s.equal("company password", company.Password, "hash")
+	}
+	_, err = s.repos.B2BAuth.GetCompany(s.ctx, s.email("company"))
+	s.errorIs("get unknown company", err, b2b_repo.ErrInvalidCredentials)
+
+	s.tokens(s.repos.B2BAuth.WhitelistToken, s.repos.B2BAuth.ValidateToken, id)
+	return nil
+}
+
+func (s *suite) b2cAuth() error {
+	email := s.email("user")
+	user := &b2c.User{Name: "Name", Surname: "Surname", Email: email, Password: "hash", Age: 20, Country: "ru"}
+	id, err := s.repos.B2CAuth.CreateUser(s.ctx, user)
+	if err != nil {
+		return err
+	}
+	s.equal("id is set on the model", user.ID, id)
+	s.equal("created_at is set on the model", user.CreatedAt.IsZero(), false)
+
+	_, err = s.repos.B2CAuth.CreateUser(s.ctx, &b2c.User{Name: "Other", Surname: "Other", Email: email, Password: "hash"})
+	s.errorIs("duplicate email", err, b2c_repo.ErrEmailAlreadyRegistered)
+
+	s.equal("registered email", s.repos.B2CAuth.IsEmailRegistered(s.ctx, email), true)
+	s.equal("unknown email", s.repos.B2CAuth.IsEmailRegistered(s.ctx, s.email("user")), false)
+
+	found, err := s.repos.B2CAuth.GetUserByEmail(s.ctx, email)
+	if s.noError("get user", err) && found != nil {
+		s.equal("user id", found.ID, id)
+		s.equal("user country", found.Country, "ru")
+	} else if found == nil {
+		s.errorf("get user: not found")
+	}
+
+	// отсутствующий пользователь - не ошибка, а nil: сервис сам решает, что ответить
+	found, err = s.repos.B2CAuth.GetUserByEmail(s.ctx, s.email("user"))
+	s.noError("get unknown user", err)
+	s.equal("unknown user", found, (*b2c.User)(nil))
+
+	s.tokens(s.repos.B2CAuth.WhitelistToken, s.repos.B2CAuth.ValidateToken, id)
+	return nil
+}
+
+func (s *suite) tokens(whitelist func(ctx context.Context, token, id string, ttl time.Duration) error, validate func(ctx context.Context, id string) (string, error), id string) {
+	s.noError("whitelist token", whitelist(s.ctx, "token-"+s.unique, id, time.Hour))
+
+	token, err := validate(s.ctx, id)
+	s.noError("validate token", err)
+	s.equal("whitelisted token", token, "token-"+s.unique)
+
+	token, err = validate(s.ctx, missingID())
+	s.noError("validate unknown token", err)
+	s.equal("unknown token", token, "")
+}
+
+func (s *suite) profile() error {
+	user, err := s.user(30, "ru")
+	if err != nil {
+		return err
+	}
+
+	missing, err := s.repos.Profile.GetProfile(s.ctx, missingID())
+	s.noError("get unknown profile", err)
+	s.equal("unknown profile", missing, (*b2c.User)(nil))
+
+	s.errorIs("empty update", s.repos.Profile.UpdateProfile(s.ctx, user.ID, &b2c_dto.ProfileUpdateRequest{}), b2c_dto.ErrNoFieldsToUpdate)
+
+	name := "Renamed"
+	avatar := "https://example.com/avatar.png"
+	s.errorIs("update unknown profile",
+		s.repos.Profile.UpdateProfile(s.ctx, missingID(), &b2c_dto.ProfileUpdateRequest{Name: &name}), b2c_dto.ErrNoFieldsToUpdate)
+
+	s.noError("update profile", s.repos.Profile.UpdateProfile(s.ctx, user.ID, &b2c_dto.ProfileUpdateRequest{Name: &name, AvatarURL: &avatar}))
+
+	updated, err := s.repos.Profile.GetProfile(s.ctx, user.ID)
+	if s.noError("get profile", err) && updated != nil {
+		s.equal("updated name", updated.Name, name)
+		s.equal("updated avatar", updated.AvatarURL, avatar)
+		s.equal("untouched surname", updated.Surname, user.Surname)
+		s.equal("version after update", updated.Version, 2)
+	}
+
+	stale := &b2c_dto.ProfileUpdateRequest{Name: &name, ExpectedVersion: 1}
+	s.errorIs("update with stale version", s.repos.Profile.UpdateProfile(s.ctx, user.ID, stale), b2c_dto.ErrProfileModified)
+	s.errorIs("empty update with stale version",
+		s.repos.Profile.UpdateProfile(s.ctx, user.ID, &b2c_dto.ProfileUpdateRequest{ExpectedVersion: 1}), b2c_dto.ErrProfileModified)
+	s.errorIs("empty update with current version",
+		s.repos.Profile.UpdateProfile(s.ctx, user.ID, &b2c_dto.ProfileUpdateRequest{ExpectedVersion: 2}), b2c_dto.ErrNoFieldsToUpdate)
+
+	surname := "Resurnamed"
+	s.noError("update with current version",
+		s.repos.Profile.UpdateProfile(s.ctx, user.ID, &b2c_dto.ProfileUpdateRequest{Surname: &surname, ExpectedVersion: 2}))
+	updated, err = s.repos.Profile.GetProfile(s.ctx, user.ID)
+	if s.noError("get profile after versioned update", err) && updated != nil {
+		s.equal("versioned surname", updated.Surname, surname)
+		s.equal("version after versioned update", updated.Version, 3)
+	}
+	return nil
+}
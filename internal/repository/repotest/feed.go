@@ -0,0 +1,253 @@
+package repotest
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/models/b2c"
+	b2c_dto "solution/internal/shared/models/b2c/dto"
+)
+
+// feedPromos - промокоды с уникальной для прогона категорией: лента по ней не видит чужих данных
+type feedPromos struct {
+	user *b2c.User
+	// подходит пользователю и активен
+	matching string
+	// не подходит пользователю по стране
+	hidden string
+	// подходит пользователю, но неактивен
+	inactive string
+	// подходит пользователю, но без категории
+	uncategorized string
+	category      string
+	companyID     string
+	companyName   string
+}
+
+func (s *suite) feedPromos() (*feedPromos, error) {
+	company, err := s.company()
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.user(25, "ru")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &feedPromos{user: user, category: "cat" + s.unique, companyID: company.ID, companyName: company.Name}
+	promos := []struct {
+		id     *string
+		target models.Target
+		edit   func(req *dto.PromoCreateRequest)
+	}{
+		{id: &f.matching, target: models.Target{AgeFrom: intPtr(20), AgeUntil: intPtr(30), Categories: []string{f.category}}},
+		{id: &f.hidden, target: models.Target{Countries: []string{"fr"}, Categories: []string{f.category}}},
+		{id: &f.inactive, target: models.Target{Categories: []string{f.category}}, edit: func(req *dto.PromoCreateRequest) {
+			req.MaxCount = intPtr(0)
+		}},
+		{id: &f.uncategorized, target: models.Target{}},
+	}
+	for _, p := range promos {
+		target := p.target
+		edit := p.edit
+		*p.id, err = s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+			req.Description = "Feed promo " + s.unique
+			req.Target = &target
+			if edit != nil {
+				edit(req)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (s *suite) feed() error {
+	f, err := s.feedPromos()
+	if err != nil {
+		return err
+	}
+
+	list := func(what, category string, active *bool, want []string) {
+		promos, total, err := s.repos.B2CPromo.GetPromosForUser(s.ctx, f.user.ID, 10, 0, category, active, nil)
+		if !s.noError(what, err) {
+			return
+		}
+		ids := make([]string, len(promos))
+		for i := range promos {
+			ids[i] = promos[i].PromoID
+		}
+		s.equal(what+" ids", ids, want)
+		s.equal(what+" total", total, int64(len(want)))
+	}
+	active, inactive := true, false
+	list("feed", f.category, nil, []string{f.inactive, f.matching})
+	list("upper case category", strings.ToUpper(f.category), nil, []string{f.inactive, f.matching})
+	list("active", f.category, &active, []string{f.matching})
+	list("inactive", f.category, &inactive, []string{f.inactive})
+
+	promos, _, err := s.repos.B2CPromo.GetPromosForUser(s.ctx, f.user.ID, 1, 1, f.category, nil, nil)
+	if s.noError("feed page", err) && len(promos) == 1 {
+		s.equal("page", promos[0].PromoID, f.matching)
+		s.equal("company name", promos[0].CompanyName, f.companyName)
+		s.equal("active", promos[0].Active, true)
+		s.equal("like count", promos[0].LikeCount, 0)
+		s.equal("comment count", promos[0].CommentCount, int64(0))
+	} else if err == nil {
+		s.errorf("feed page: got %d promos, want 1", len(promos))
+	}
+
+	_, _, err = s.repos.B2CPromo.GetPromosForUser(s.ctx, missingID(), 10, 0, f.category, nil, nil)
+	s.errorIs("feed of unknown user", err, gorm.ErrRecordNotFound)
+	return nil
+}
+
+func (s *suite) likes() error {
+	f, err := s.feedPromos()
+	if err != nil {
+		return err
+	}
+
+	promo := func(what string) *b2c_dto.PromoForUser {
+		p, err := s.repos.B2CPromo.GetPromoForUserByID(s.ctx, f.matching, f.user.ID)
+		if !s.noError(what, err) {
+			return &b2c_dto.PromoForUser{}
+		}
+		return p
+	}
+
+	like := func(what string, want bool) {
+		inserted, err := s.repos.B2CPromo.LikePromo(s.ctx, f.matching, f.user.ID)
+		if s.noError(what, err) {
+			s.equal(what+" inserted", inserted, want)
+		}
+	}
+	like("like", true)
+	like("like again", false)
+	liked := promo("liked promo")
+	s.equal("like count", liked.LikeCount, 1)
+	s.equal("liked by user", liked.IsLikedByUser, true)
+
+	stored, err := s.repos.B2CPromo.GetPromoByID(s.ctx, f.matching)
+	if s.noError("get liked promo", err) {
+		s.equal("stored like count", stored.LikeCount, 1)
+	}
+
+	s.noError("unlike", s.repos.B2CPromo.UnlikePromo(s.ctx, f.matching, f.user.ID))
+	s.noError("unlike again", s.repos.B2CPromo.UnlikePromo(s.ctx, f.matching, f.user.ID))
+	unliked := promo("unliked promo")
+	s.equal("like count after unlike", unliked.LikeCount, 0)
+	s.equal("not liked by user", unliked.IsLikedByUser, false)
+
+	s.errorIs("unlike unknown promo", s.repos.B2CPromo.UnlikePromo(s.ctx, missingID(), f.user.ID), b2c_dto.ErrNotFound)
+
+	s.equal("not activated", promo("promo").IsActivatedByUser, false)
+	if err := s.repos.Seeder.AddActivation(s.ctx, f.matching, f.user.ID); err != nil {
+		return err
+	}
+	s.equal("activated", promo("activated promo").IsActivatedByUser, true)
+	return nil
+}
+
+func (s *suite) comments() error {
+	f, err := s.feedPromos()
+	if err != nil {
+		return err
+	}
+
+	c1 := &b2c.Comment{UserID: f.user.ID, PromoID: f.matching, Text: "first"}
+	c2 := &b2c.Comment{UserID: f.user.ID, PromoID: f.matching, Text: "second"}
+	for _, c := range []*b2c.Comment{c1, c2} {
+		if err := s.repos.B2CPromo.AddComment(s.ctx, c); err != nil {
+			return err
+		}
+	}
+	s.equal("id is set on the model", c1.ID != "" && c2.ID != "", true)
+
+	list := func(what string, limit, offset int, want []string, wantTotal int64) {
+		comments, total, err := s.repos.B2CPromo.GetComments(s.ctx, f.matching, limit, offset)
+		if !s.noError(what, err) {
+			return
+		}
+		ids := make([]string, len(comments))
+		for i := range comments {
+			ids[i] = comments[i].ID
+		}
+		s.equal(what+" ids", ids, want)
+		s.equal(what+" total", total, wantTotal)
+	}
+	list("comments", 10, 0, []string{c2.ID, c1.ID}, 2)
+	list("comments page", 1, 1, []string{c1.ID}, 2)
+
+	promo, err := s.repos.B2CPromo.GetPromoForUserByID(s.ctx, f.matching, f.user.ID)
+	if s.noError("get commented promo", err) {
+		s.equal("comment count", promo.CommentCount, int64(2))
+	}
+
+	comment, err := s.repos.B2CPromo.GetCommentByID(s.ctx, c1.ID)
+	if s.noError("get comment", err) {
+		comment.Text = "edited"
+		s.noError("update comment", s.repos.B2CPromo.UpdateComment(s.ctx, comment))
+	}
+	comment, err = s.repos.B2CPromo.GetCommentByID(s.ctx, c1.ID)
+	if s.noError("get edited comment", err) {
+		s.equal("edited text", comment.Text, "edited")
+		s.equal("author", comment.UserID, f.user.ID)
+	}
+
+	s.noError("delete comment", s.repos.B2CPromo.DeleteComment(s.ctx, c2.ID))
+	s.noError("delete deleted comment", s.repos.B2CPromo.DeleteComment(s.ctx, c2.ID))
+	list("comments after delete", 10, 0, []string{c1.ID}, 1)
+	_, err = s.repos.B2CPromo.GetCommentByID(s.ctx, c2.ID)
+	s.errorIs("get deleted comment", err, gorm.ErrRecordNotFound)
+
+	_, err = s.repos.B2CPromo.GetPromoForUserByID(s.ctx, missingID(), f.user.ID)
+	s.errorIs("get unknown promo for user", err, gorm.ErrRecordNotFound)
+	_, err = s.repos.B2CPromo.GetPromoByID(s.ctx, missingID())
+	s.errorIs("get unknown promo", err, gorm.ErrRecordNotFound)
+
+	user, err := s.repos.B2CPromo.GetUserByID(s.ctx, f.user.ID)
+	if s.noError("get user", err) {
+		s.equal("user email", user.Email, f.user.Email)
+	}
+	_, err = s.repos.B2CPromo.GetUserByID(s.ctx, missingID())
+	s.errorIs("get unknown user", err, b2c_dto.ErrNotFound)
+	return nil
+}
+
+func (s *suite) search() error {
+	f, err := s.feedPromos()
+	if err != nil {
+		return err
+	}
+	word := "w" + s.unique
+	patch := `{"description": "Sale <i>` + word + `</i> & more"}`
+	if _, err := s.updatePromo(f.matching, patch, 0, companyActor(f.companyID)); err != nil {
+		return err
+	}
+	if _, err := s.updatePromo(f.hidden, patch, 0, companyActor(f.companyID)); err != nil {
+		return err
+	}
+
+	results, total, err := s.repos.B2CPromo.SearchPromosForUser(s.ctx, f.user.ID, word, 10, 0, nil)
+	if s.noError("search", err) {
+		s.equal("total", total, int64(1))
+		if len(results) == 1 {
+			s.equal("found promo", results[0].PromoID, f.matching)
+			s.equal("highlighted", strings.Contains(results[0].Highlight, "<b>"+word+"</b>"), true)
+			s.equal("escaped markup", strings.Contains(results[0].Highlight, "<i>"), false)
+			s.equal("escaped entity", strings.Contains(results[0].Highlight, "&lt;i&gt;"), true)
+			s.equal("ranked", results[0].Rank > 0, true)
+		} else {
+			s.errorf("search: got %d results, want 1", len(results))
+		}
+	}
+
+	_, _, err = s.repos.B2CPromo.SearchPromosForUser(s.ctx, missingID(), word, 10, 0, nil)
+	s.errorIs("search of unknown user", err, gorm.ErrRecordNotFound)
+	return nil
+}
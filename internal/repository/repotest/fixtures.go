@@ -0,0 +1,76 @@
+package repotest
+
+import (
+	"time"
+
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2b/dto"
+	b2c_models "solution/internal/shared/models/b2c"
+)
+
+func (s *suite) company() (*b2b.Company, error) {
+	email := s.email("company")
+	id, err := s.repos.B2BAuth.CreateCompany(s.ctx, dto.SignUpRequest{Name: "Company " + s.unique, Email: email, Password: "hash"})
+	if err != nil {
+		return nil, err
+	}
+	return &b2b.Company{ID: id, Name: "Company " + s.unique, Email: email}, nil
+}
+
+func (s *suite) user(age int, country string) (*b2c_models.User, error) {
+	user := &b2c_models.User{
+		Name:     "Name",
+		Surname:  "Surname",
+		Email:    s.email("user"),
+		Password: "hash",
+		Age:      age,
+		Country:  country,
+	}
+	if _, err := s.repos.B2CAuth.CreateUser(s.ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// promo создаёт активный COMMON промокод компании; edit меняет запрос перед созданием
+func (s *suite) promo(companyID string, edit func(req *dto.PromoCreateRequest)) (string, error) {
+	req := dto.PromoCreateRequest{
+		CompanyID:   companyID,
+		Description: "Promo " + s.unique,
+		Mode:        "COMMON",
+		PromoCommon: "CODE",
+		Target:      &models.Target{},
+		MaxCount:    intPtr(10),
+	}
+	if edit != nil {
+		edit(&req)
+	}
+	return s.repos.B2BPromo.CreatePromo(s.ctx, req, companyActor(companyID))
+}
+
+// updatePromo применяет JSON Merge Patch к промокоду так же, как сервис; version - ожидаемая версия, 0 - без проверки
+func (s *suite) updatePromo(promoID, patch string, version int, actor models.Actor) (*models.Promo, error) {
+	promo, err := s.repos.B2BPromo.GetPromoByID(s.ctx, promoID)
+	if err != nil {
+		return nil, err
+	}
+	update, err := dto.MergePromoPatch(promo, []byte(patch))
+	if err != nil {
+		return nil, err
+	}
+	update.ExpectedVersion = version
+	return s.repos.B2BPromo.UpdatePromo(s.ctx, promoID, *update, actor)
+}
+
+func companyActor(companyID string) models.Actor {
+	return models.Actor{Type: models.ActorCompany, ID: companyID}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func date(year int, month time.Month, day int) *b2b.Date {
+	return &b2b.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
@@ -0,0 +1,405 @@
+package repotest
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2b/dto"
+)
+
+func (s *suite) b2bPromos() error {
+	company, err := s.company()
+	if err != nil {
+		return err
+	}
+
+	p1, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.Target = &models.Target{Countries: []string{"ru"}}
+		req.ActiveFrom = date(2024, 1, 1)
+	})
+	if err != nil {
+		return err
+	}
+	p2, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.Mode = "UNIQUE"
+		req.PromoCommon = ""
+		req.PromoUnique = []string{"A", "B"}
+		req.Target = &models.Target{ExcludeCountries: []string{"ru"}}
+	})
+	if err != nil {
+		return err
+	}
+	p3, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.ActiveFrom = date(2025, 1, 1)
+	})
+	if err != nil {
+		return err
+	}
+
+	promo, err := s.repos.B2BPromo.GetPromoByID(s.ctx, p1)
+	if s.noError("get promo", err) {
+		s.equal("company", promo.CompanyID, company.ID)
+		s.equal("normalized countries", promo.Target.Countries, []string{"RU"})
+		s.equal("active_from", promo.ActiveFrom, date(2024, 1, 1))
+		s.equal("active_until", promo.ActiveUntil, (*b2b.Date)(nil))
+		s.equal("initial version", promo.Version, 1)
+	}
+	_, err = s.repos.B2BPromo.GetPromoByID(s.ctx, missingID())
+	s.errorIs("get unknown promo", err, gorm.ErrRecordNotFound)
+
+	list := func(what string, limit, offset int, sortBy string, country []string, want []string, wantTotal int64) {
+		promos, total, err := s.repos.B2BPromo.GetPromos(s.ctx, company.ID, limit, offset, sortBy, country)
+		if !s.noError(what, err) {
+			return
+		}
+		ids := make([]string, len(promos))
+		for i := range promos {
+			ids[i] = promos[i].ID
+		}
+		s.equal(what+" ids", ids, want)
+		s.equal(what+" total", total, wantTotal)
+	}
+	list("list", 10, 0, "", nil, []string{p3, p2, p1}, 3)
+	list("page", 1, 1, "", nil, []string{p2}, 3)
+	list("by country", 10, 0, "", []string{"ru"}, []string{p3, p1}, 2)
+	list("by active_from", 10, 0, "active_from", nil, []string{p2, p3, p1}, 3)
+
+	promos, total, err := s.repos.B2BPromo.GetPromos(s.ctx, missingID(), 10, 0, "", nil)
+	if s.noError("list of other company", err) {
+		s.equal("other company promos", len(promos), 0)
+		s.equal("other company total", total, int64(0))
+	}
+
+	updated, err := s.updatePromo(p1, `{"description": "Updated description", "max_count": 20}`, 0, companyActor(company.ID))
+	if s.noError("update promo", err) {
+		s.equal("updated description", updated.Description, "Updated description")
+		s.equal("updated max_count", updated.MaxCount, 20)
+	}
+	promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, p1)
+	if s.noError("get updated promo", err) {
+		s.equal("stored description", promo.Description, "Updated description")
+		s.equal("stored max_count", promo.MaxCount, 20)
+		s.equal("untouched countries", promo.Target.Countries, []string{"RU"})
+	}
+	_, err = s.repos.B2BPromo.UpdatePromo(s.ctx, missingID(), dto.PromoUpdate{Description: "Updated description", MaxCount: intPtr(1)}, companyActor(company.ID))
+	s.errorIs("update unknown promo", err, gorm.ErrRecordNotFound)
+
+	_, err = s.updatePromo(p1, `{"description": "Stale description"}`, 1, companyActor(company.ID))
+	s.errorIs("update with stale version", err, dto.ErrorPromoModified)
+	updated, err = s.updatePromo(p1, `{"description": "Current description"}`, 2, companyActor(company.ID))
+	if s.noError("update with current version", err) {
+		s.equal("version after versioned update", updated.Version, 3)
+	}
+	promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, p1)
+	if s.noError("get versioned promo", err) {
+		s.equal("stored version", promo.Version, 3)
+		s.equal("stale update not applied", promo.Description, "Current description")
+	}
+
+	if _, err := s.updatePromo(p1, `{"description": "Current description"}`, 3, companyActor(company.ID)); s.noError("update without changes", err) {
+		promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, p1)
+		if s.noError("get unchanged promo", err) {
+			s.equal("version without changes", promo.Version, 3)
+		}
+	}
+
+	_, err = s.updatePromo(p1, `{"active_from": null, "target": {"countries": null, "categories": ["food"]}}`, 0, companyActor(company.ID))
+	if s.noError("clear fields", err) {
+		promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, p1)
+		if s.noError("get cleared promo", err) {
+			s.equal("cleared active_from", promo.ActiveFrom, (*b2b.Date)(nil))
+			s.equal("cleared countries", len(promo.Target.Countries), 0)
+			s.equal("merged categories", promo.Target.Categories, []string{"food"})
+			s.equal("kept description", promo.Description, "Current description")
+		}
+	}
+
+	limited, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.Limits = &models.ActivationLimits{PerUser: 1, CooldownSeconds: 60, Daily: 100}
+	})
+	if err != nil {
+		return err
+	}
+	promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, limited)
+	if s.noError("get promo with limits", err) {
+		s.equal("created limits", promo.Limits, models.ActivationLimits{PerUser: 1, CooldownSeconds: 60, Daily: 100})
+	}
+	if _, err := s.updatePromo(limited, `{"limits": {"per_user": 2, "daily": null}}`, 0, companyActor(company.ID)); s.noError("update limits", err) {
+		promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, limited)
+		if s.noError("get updated limits", err) {
+			s.equal("updated limits", promo.Limits, models.ActivationLimits{PerUser: 2, CooldownSeconds: 60})
+		}
+	}
+
+	found, err := s.repos.B2BPromo.GetCompanyById(s.ctx, company.ID)
+	if s.noError("get company", err) {
+		s.equal("company name", found.Name, company.Name)
+	}
+	_, err = s.repos.B2BPromo.GetCompanyById(s.ctx, missingID())
+	s.errorIs("get unknown company", err, dto.ErrNotFound)
+	return nil
+}
+
+func (s *suite) promoHistory() error {
+	company, err := s.company()
+	if err != nil {
+		return err
+	}
+	promoID, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.Target = &models.Target{Countries: []string{"ru"}}
+	})
+	if err != nil {
+		return err
+	}
+
+	apiKey := models.Actor{Type: models.ActorApiKey, ID: missingID()}
+	if _, err := s.updatePromo(promoID, `{"max_count": 20, "active_from": "2024-01-01"}`, 0, apiKey); err != nil {
+		return err
+	}
+	// то же значение и таргетинг, отличающийся только регистром, - не изменение
+	if _, err := s.updatePromo(promoID, `{"max_count": 20, "target": {"countries": ["RU"]}}`, 0, apiKey); err != nil {
+		return err
+	}
+
+	changes, total, err := s.repos.B2BPromo.GetPromoHistory(s.ctx, promoID, 10, 0)
+	if !s.noError("history", err) {
+		return nil
+	}
+	s.equal("history total", total, int64(2))
+	if len(changes) != 2 {
+		s.errorf("history: got %d changes, want 2", len(changes))
+		return nil
+	}
+
+	update, create := changes[0], changes[1]
+	s.equal("update action", update.Action, models.PromoChangeUpdate)
+	s.equal("update actor", models.Actor{Type: update.ActorType, ID: update.ActorID}, apiKey)
+	s.equal("update diff", jsonObject(update.Changes), jsonObject(`{
+		"max_count": {"old": 10, "new": 20},
+		"active_from": {"old": null, "new": "2024-01-01"}
+	}`))
+	s.equal("create action", create.Action, models.PromoChangeCreate)
+	s.equal("create actor", models.Actor{Type: create.ActorType, ID: create.ActorID}, companyActor(company.ID))
+	s.equal("created max_count", jsonObject(create.Changes)["max_count"], map[string]interface{}{"old": nil, "new": 10.0})
+	s.equal("created id is set", create.PromoID, promoID)
+
+	page, total, err := s.repos.B2BPromo.GetPromoHistory(s.ctx, promoID, 1, 1)
+	if s.noError("history page", err) && len(page) == 1 {
+		s.equal("page", page[0].ID, create.ID)
+		s.equal("page total", total, int64(2))
+	} else if err == nil {
+		s.errorf("history page: got %d changes, want 1", len(page))
+	}
+
+	_, err = s.repos.B2BPromo.UpdatePromo(s.ctx, missingID(), dto.PromoUpdate{Description: "Unknown promo", MaxCount: intPtr(1)}, apiKey)
+	s.errorIs("update unknown promo", err, gorm.ErrRecordNotFound)
+	changes, total, err = s.repos.B2BPromo.GetPromoHistory(s.ctx, missingID(), 10, 0)
+	if s.noError("history of unknown promo", err) {
+		s.equal("unknown promo history", len(changes), 0)
+		s.equal("unknown promo total", total, int64(0))
+	}
+	return nil
+}
+
+func (s *suite) promoCodes() error {
+	company, err := s.company()
+	if err != nil {
+		return err
+	}
+	promoID, err := s.promo(company.ID, nil)
+	if err != nil {
+		return err
+	}
+	user, err := s.user(20, "ru")
+	if err != nil {
+		return err
+	}
+
+	update := dto.PromoCodesUpdate{Mode: "UNIQUE", PromoUnique: []string{"code-1", "code-2"}, MaxCount: 1, ExpectedVersion: 1}
+	updated, err := s.repos.B2BPromo.UpdatePromoCodes(s.ctx, promoID, update, companyActor(company.ID))
+	if s.noError("switch mode", err) {
+		s.equal("switched version", updated.Version, 2)
+	}
+	promo, err := s.repos.B2BPromo.GetPromoByID(s.ctx, promoID)
+	if s.noError("get switched promo", err) {
+		s.equal("mode", promo.Mode, "UNIQUE")
+		s.equal("common code", promo.PromoCommon, "")
+		s.equal("unique codes", []string(promo.PromoUnique), []string{"code-1", "code-2"})
+		s.equal("max_count", promo.MaxCount, 1)
+	}
+
+	_, err = s.repos.B2BPromo.UpdatePromoCodes(s.ctx, promoID, update, companyActor(company.ID))
+	s.errorIs("stale version", err, dto.ErrorPromoModified)
+
+	if err := s.repos.Seeder.AddActivation(s.ctx, promoID, user.ID); err != nil {
+		return err
+	}
+	// изменение проверено для промокода без активаций и не должно записаться после активации
+	update = dto.PromoCodesUpdate{Mode: "UNIQUE", PromoUnique: []string{"code-2"}, MaxCount: 1}
+	_, err = s.repos.B2BPromo.UpdatePromoCodes(s.ctx, promoID, update, companyActor(company.ID))
+	s.errorIs("activated during change", err, dto.ErrorPromoActivated)
+
+	update = dto.PromoCodesUpdate{Mode: "UNIQUE", PromoUnique: []string{"code-1", "code-3"}, MaxCount: 1, UsedCount: 1}
+	if _, err := s.repos.B2BPromo.UpdatePromoCodes(s.ctx, promoID, update, companyActor(company.ID)); s.noError("replace unissued code", err) {
+		promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, promoID)
+		if s.noError("get promo", err) {
+			s.equal("replaced codes", []string(promo.PromoUnique), []string{"code-1", "code-3"})
+			s.equal("used_count kept", promo.UsedCount, 1)
+		}
+	}
+
+	changes, total, err := s.repos.B2BPromo.GetPromoHistory(s.ctx, promoID, 1, 0)
+	if s.noError("history", err) && len(changes) == 1 {
+		s.equal("history total", total, int64(3))
+		s.equal("codes diff", jsonObject(changes[0].Changes), jsonObject(`{
+			"promo_unique": {"old": ["code-1", "code-2"], "new": ["code-1", "code-3"]}
+		}`))
+	}
+
+	_, err = s.repos.B2BPromo.UpdatePromoCodes(s.ctx, missingID(), update, companyActor(company.ID))
+	s.errorIs("unknown promo", err, gorm.ErrRecordNotFound)
+	return nil
+}
+
+func (s *suite) promoWindow() error {
+	company, err := s.company()
+	if err != nil {
+		return err
+	}
+	promoID, err := s.promo(company.ID, func(req *dto.PromoCreateRequest) {
+		req.ActiveFrom = date(2024, 1, 10)
+		req.ActiveUntil = date(2024, 1, 10)
+		req.Timezone = "Asia/Vladivostok"
+	})
+	if err != nil {
+		return err
+	}
+
+	// 10 января во Владивостоке (UTC+10) - с 9 января 14:00 до 10 января 14:00 UTC
+	promo, err := s.repos.B2BPromo.GetPromoByID(s.ctx, promoID)
+	if s.noError("get promo", err) {
+		s.equal("timezone", promo.Timezone, "Asia/Vladivostok")
+		s.equal("window", window(promo), [2]time.Time{
+			time.Date(2024, 1, 9, 14, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 10, 14, 0, 0, 0, time.UTC),
+		})
+		s.equal("active at the end of the last day", promo.IsActiveAt(time.Date(2024, 1, 10, 13, 59, 59, 0, time.UTC)), true)
+		s.equal("inactive after the last day", promo.IsActiveAt(time.Date(2024, 1, 10, 14, 0, 0, 0, time.UTC)), false)
+		s.equal("inactive before the first day", promo.IsActiveAt(time.Date(2024, 1, 9, 13, 59, 59, 0, time.UTC)), false)
+	}
+
+	if _, err := s.updatePromo(promoID, `{"timezone": null, "active_from": null}`, 0, companyActor(company.ID)); s.noError("reset timezone", err) {
+		promo, err = s.repos.B2BPromo.GetPromoByID(s.ctx, promoID)
+		if s.noError("get updated promo", err) {
+			s.equal("default timezone", promo.Timezone, models.DefaultTimezone)
+			s.equal("window in UTC", window(promo), [2]time.Time{{}, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)})
+		}
+	}
+	return nil
+}
+
+// window - начало и конец окна действия в UTC, нулевое время - границы нет
+func window(p *models.Promo) [2]time.Time {
+	var w [2]time.Time
+	if p.StartsAt != nil {
+		w[0] = p.StartsAt.UTC()
+	}
+	if p.EndsAt != nil {
+		w[1] = p.EndsAt.UTC()
+	}
+	return w
+}
+
+// jsonObject разбирает JSON для сравнения без учёта порядка ключей и форматирования
+func jsonObject(data string) map[string]interface{} {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &object); err != nil {
+		return map[string]interface{}{"invalid json": data}
+	}
+	return object
+}
+
+func (s *suite) promoStats() error {
+	company, err := s.company()
+	if err != nil {
+		return err
+	}
+	promoID, err := s.promo(company.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	stat, err := s.repos.B2BPromo.GetPromoStatByID(s.ctx, promoID)
+	if s.noError("empty stats", err) {
+		s.equal("no activations", stat.ActivationsCount, 0)
+		s.equal("no countries", stat.Countries, []dto.CountryActivation{})
+	}
+
+	for _, country := range []string{"ru", "ru", "fr"} {
+		user, err := s.user(25, country)
+		if err != nil {
+			return err
+		}
+		if err := s.repos.Seeder.AddActivation(s.ctx, promoID, user.ID); err != nil {
+			return err
+		}
+	}
+
+	stat, err = s.repos.B2BPromo.GetPromoStatByID(s.ctx, promoID)
+	if s.noError("stats", err) {
+		s.equal("activations", stat.ActivationsCount, 3)
+		s.equal("countries", stat.Countries, []dto.CountryActivation{
+			{Country: dto.Country{Code: "fr"}, Activations: 1},
+			{Country: dto.Country{Code: "ru"}, Activations: 2},
+		})
+	}
+
+	_, err = s.repos.B2BPromo.GetPromoStatByID(s.ctx, missingID())
+	s.errorIs("stats of unknown promo", err, dto.ErrorPromoNotFound)
+
+	// активации не меняют версию, поэтому лимит проверяется по used_count в момент записи
+	_, err = s.updatePromo(promoID, `{"max_count": 2}`, 0, companyActor(company.ID))
+	s.errorIs("max_count below activations", err, dto.ErrorMaxCountBelowUsed)
+	updated, err := s.updatePromo(promoID, `{"max_count": 3}`, 0, companyActor(company.ID))
+	if s.noError("max_count equal to activations", err) {
+		s.equal("lowered max_count", updated.MaxCount, 3)
+	}
+	return nil
+}
+
+// audienceEstimate сравнивает прирост оценки, а не абсолютные числа: в базе могут быть чужие пользователи
+func (s *suite) audienceEstimate() error {
+	target := models.Target{AgeFrom: intPtr(70), AgeUntil: intPtr(72), Countries: []string{"RU"}}
+
+	before, err := s.repos.B2BPromo.EstimateAudience(s.ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, u := range []struct {
+		age     int
+		country string
+	}{{71, "ru"}, {71, "ru"}, {30, "ru"}, {71, "fr"}} {
+		if _, err := s.user(u.age, u.country); err != nil {
+			return err
+		}
+	}
+
+	after, err := s.repos.B2BPromo.EstimateAudience(s.ctx, target)
+	if s.noError("estimate", err) {
+		s.equal("users delta", after.UsersCount-before.UsersCount, int64(2))
+		s.equal("countries", countryUsers(after)["RU"]-countryUsers(before)["RU"], int64(2))
+		s.equal("no foreign countries", len(after.Countries), 1)
+	}
+	return nil
+}
+
+func countryUsers(estimate *dto.AudienceEstimateResponse) map[string]int64 {
+	users := make(map[string]int64)
+	for _, c := range estimate.Countries {
+		users[c.Country] = c.Users
+	}
+	return users
+}
@@ -0,0 +1,166 @@
+// Package repotest - общий набор проверок контракта репозиториев. Один и тот же набор прогоняется
+// на GORM реализации (Postgres и Redis) и на репозиториях в памяти из memory, чтобы они не расходились.
+//
+// Набор не зависит от пакета testing, как testing/fstest.TestFS: тест вызывает Run и падает на ошибке.
+// Все сценарии создают собственные данные с уникальными email и категориями, поэтому база
+// не обязана быть пустой.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	b2b_repo "solution/internal/repository/b2b"
+	b2c_repo "solution/internal/repository/b2c"
+	"solution/internal/repository/memory"
+	"solution/internal/shared/models"
+	"solution/internal/shared/storage/redis"
+)
+
+// Seeder записывает данные, для которых у репозиториев нет методов
+type Seeder interface {
+	// AddActivation записывает активацию промокода пользователем и увеличивает used_count
+	AddActivation(ctx context.Context, promoID, userID string) error
+}
+
+// Repositories - проверяемая реализация всех репозиториев над одним хранилищем
+type Repositories struct {
+	B2BAuth  b2b_repo.AuthRepository
+	B2BPromo b2b_repo.PromoRepository
+	B2CAuth  b2c_repo.AuthRepository
+	Profile  b2c_repo.ProfileRepository
+	B2CPromo b2c_repo.PromoRepository
+	Seeder   Seeder
+}
+
+// Memory собирает репозитории в памяти над новым хранилищем
+func Memory() Repositories {
+	store := memory.NewStore()
+	return Repositories{
+		B2BAuth:  memory.NewB2BAuthRepository(store),
+		B2BPromo: memory.NewB2BPromoRepository(store),
+		B2CAuth:  memory.NewB2CAuthRepository(store),
+		Profile:  memory.NewProfileRepository(store),
+		B2CPromo: memory.NewB2CPromoRepository(store),
+		Seeder:   store,
+	}
+}
+
+// Gorm собирает GORM репозитории; схема БД должна быть применена через postgres.Migrate
+func Gorm(db *gorm.DB, rdb *redis.RDB) Repositories {
+	return Repositories{
+		B2BAuth:  b2b_repo.NewAuthRepository(db, rdb),
+		B2BPromo: b2b_repo.NewPromoRepository(db, rdb),
+		B2CAuth:  b2c_repo.NewAuthRepository(db, rdb),
+		Profile:  b2c_repo.NewProfileRepository(db, rdb),
+		B2CPromo: b2c_repo.NewPromoRepository(db, rdb),
+		Seeder:   gormSeeder{db: db},
+	}
+}
+
+type gormSeeder struct {
+	db *gorm.DB
+}
+
+func (s gormSeeder) AddActivation(ctx context.Context, promoID, userID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		activation := models.PromoActivation{PromoID: promoID, UserID: userID, ActivatedAt: time.Now().UTC()}
+		if err := tx.Create(&activation).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Promo{}).Where("id = ?", promoID).
+			UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error
+	})
+}
+
+type scenario struct {
+	name string
+	run  func(s *suite) error
+}
+
+var scenarios = []scenario{
+	{name: "b2b auth", run: (*suite).b2bAuth},
+	{name: "b2c auth", run: (*suite).b2cAuth},
+	{name: "profile", run: (*suite).profile},
+	{name: "b2b promos", run: (*suite).b2bPromos},
+	{name: "promo history", run: (*suite).promoHistory},
+	{name: "promo codes", run: (*suite).promoCodes},
+	{name: "promo window", run: (*suite).promoWindow},
+	{name: "promo stats", run: (*suite).promoStats},
+	{name: "audience estimate", run: (*suite).audienceEstimate},
+	{name: "feed", run: (*suite).feed},
+	{name: "likes", run: (*suite).likes},
+	{name: "comments", run: (*suite).comments},
+	{name: "search", run: (*suite).search},
+	{name: "activation", run: (*suite).activation},
+}
+
+// Run прогоняет все сценарии и возвращает все расхождения с контрактом сразу.
+// Ошибка подготовки данных прерывает только свой сценарий.
+func Run(ctx context.Context, repos Repositories) error {
+	var errs []error
+	for _, sc := range scenarios {
+		s := &suite{
+			ctx:    ctx,
+			repos:  repos,
+			name:   sc.name,
+			unique: strings.ReplaceAll(uuid.NewString(), "-", "")[:12],
+		}
+		if err := sc.run(s); err != nil {
+			s.errorf("setup failed: %v", err)
+		}
+		errs = append(errs, s.errs...)
+	}
+	return errors.Join(errs...)
+}
+
+// suite - состояние одного сценария: уникальный суффикс для данных и найденные расхождения
+type suite struct {
+	ctx    context.Context
+	repos  Repositories
+	name   string
+	unique string
+	seq    int
+	errs   []error
+}
+
+func (s *suite) errorf(format string, args ...interface{}) {
+	s.errs = append(s.errs, fmt.Errorf("%s: %s", s.name, fmt.Sprintf(format, args...)))
+}
+
+func (s *suite) equal(what string, got, want interface{}) {
+	if !reflect.DeepEqual(got, want) {
+		s.errorf("%s: got %#v, want %#v", what, got, want)
+	}
+}
+
+func (s *suite) noError(what string, err error) bool {
+	if err != nil {
+		s.errorf("%s: unexpected error: %v", what, err)
+		return false
+	}
+	return true
+}
+
+func (s *suite) errorIs(what string, err, target error) {
+	if !errors.Is(err, target) {
+		s.errorf("%s: got error %v, want %v", what, err, target)
+	}
+}
+
+// email возвращает новый адрес, уникальный для прогона
+func (s *suite) email(kind string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%s-%d@example.com", kind, s.unique, s.seq)
+}
+
+// missingID - идентификатор, которого заведомо нет в хранилище
+func missingID() string {
+	return uuid.NewString()
+}
@@ -0,0 +1,58 @@
+package repotest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	admin_repo "solution/internal/repository/admin"
+	b2b_repo "solution/internal/repository/b2b"
+	b2c_repo "solution/internal/repository/b2c"
+	"solution/internal/repository/repotest"
+	"solution/internal/shared/storage/postgres/pgtest"
+	"solution/internal/shared/storage/redis/redistest"
+)
+
+func TestMemory(t *testing.T) {
+	if err := repotest.Run(context.Background(), repotest.Memory()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestGorm проверяет GORM репозитории на временной базе из pgtest: на TEST_POSTGRES_DSN или на своём кластере
+func TestGorm(t *testing.T) {
+	db := pgtest.Open(t)
+	rdb, _ := redistest.Open(t)
+
+	if err := repotest.Run(context.Background(), repotest.Gorm(db, rdb)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWhitelistTokenTTL проверяет, что токен в Redis живёт столько же, сколько JWT (auth.token_ttl)
+func TestWhitelistTokenTTL(t *testing.T) {
+	rdb, server := redistest.Open(t)
+	ttl := 90 * time.Minute
+
+	tests := []struct {
+		name      string
+		whitelist func(ctx context.Context, token, id string, ttl time.Duration) error
+		id        string
+		key       string
+	}{
+		{name: "b2b", whitelist: b2b_repo.NewAuthRepository(nil, rdb).WhitelistToken, id: "company-id", key: "company-id"},
+		{name: "b2c", whitelist: b2c_repo.NewAuthRepository(nil, rdb).WhitelistToken, id: "user-id", key: "user-id"},
+		{name: "admin", whitelist: admin_repo.NewAuthRepository(nil, rdb).WhitelistToken, id: "admin-id", key: "admin:admin-id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.whitelist(context.Background(), "token", tt.id, ttl); err != nil {
+				t.Fatal(err)
+			}
+			if got := server.TTL(tt.key); got != ttl {
+				t.Errorf("TTL(%s) = %v, want %v", tt.key, got, ttl)
+			}
+		})
+	}
+}
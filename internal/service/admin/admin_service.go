@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"solution/internal/repository/admin"
+	models "solution/internal/shared/models/admin"
+	"solution/internal/shared/models/admin/dto"
+)
+
+type AdminService interface {
+	ListUsers(ctx context.Context, query string, limit, offset int) ([]dto.UserResponse, int64, error)
+	ListCompanies(ctx context.Context, query string, limit, offset int) ([]dto.CompanyResponse, int64, error)
+	SetUserBlocked(ctx context.Context, adminID, userID string, blocked bool, reason string) error
+	SetCompanyBlocked(ctx context.Context, adminID, companyID string, blocked bool, reason string) error
+	ArchivePromo(ctx context.Context, adminID, promoID, reason string) error
+	RemoveComment(ctx context.Context, adminID, commentID, reason string) error
+	GetActivations(ctx context.Context, filter dto.ActivationFilter, limit, offset int) ([]dto.ActivationResponse, int64, error)
+	GetAuditLog(ctx context.Context, limit, offset int) ([]dto.AuditEntryResponse, int64, error)
+}
+
+type adminService struct {
+	repo admin.AdminRepository
+}
+
+func NewAdminService(repo admin.AdminRepository) AdminService {
+	return &adminService{repo: repo}
+}
+
+func (s *adminService) ListUsers(ctx context.Context, query string, limit, offset int) ([]dto.UserResponse, int64, error) {
+	users, totalCount, err := s.repo.ListUsers(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]dto.UserResponse, len(users))
+	for i := range users {
+		response[i] = dto.NewUserResponse(&users[i])
+	}
+	return response, totalCount, nil
+}
+
+func (s *adminService) ListCompanies(ctx context.Context, query string, limit, offset int) ([]dto.CompanyResponse, int64, error) {
+	companies, totalCount, err := s.repo.ListCompanies(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]dto.CompanyResponse, len(companies))
+	for i := range companies {
+		response[i] = dto.NewCompanyResponse(&companies[i])
+	}
+	return response, totalCount, nil
+}
+
+// SetUserBlocked блокирует или разблокирует пользователя. При блокировке отзывается токен,
+// поэтому уже выданный токен перестаёт работать сразу, а не по истечении срока.
+func (s *adminService) SetUserBlocked(ctx context.Context, adminID, userID string, blocked bool, reason string) error {
+	action := models.ActionUserUnblock
+	if blocked {
+		action = models.ActionUserBlock
+	}
+
+	entry, err := newAuditEntry(adminID, action, models.TargetUser, userID, map[string]interface{}{"reason": reason})
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetUserBlocked(ctx, userID, blocked, entry); err != nil {
+		return err
+	}
+
+	if blocked {
+		return s.repo.RevokeToken(ctx, userID)
+	}
+	return nil
+}
+
+// SetCompanyBlocked блокирует или разблокирует компанию. API ключи заблокированной компании
+// отклоняются при авторизации, токен отзывается.
+func (s *adminService) SetCompanyBlocked(ctx context.Context, adminID, companyID string, blocked bool, reason string) error {
+	action := models.ActionCompanyUnblock
+	if blocked {
+		action = models.ActionCompanyBlock
+	}
+
+	entry, err := newAuditEntry(adminID, action, models.TargetCompany, companyID, map[string]interface{}{"reason": reason})
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetCompanyBlocked(ctx, companyID, blocked, entry); err != nil {
+		return err
+	}
+
+	if blocked {
+		return s.repo.RevokeToken(ctx, companyID)
+	}
+	return nil
+}
+
+func (s *adminService) ArchivePromo(ctx context.Context, adminID, promoID, reason string) error {
+	entry, err := newAuditEntry(adminID, models.ActionPromoArchive, models.TargetPromo, promoID, map[string]interface{}{"reason": reason})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.ArchivePromo(ctx, promoID, entry)
+}
+
+// RemoveComment удаляет комментарий; его текст и автор остаются в журнале
+func (s *adminService) RemoveComment(ctx context.Context, adminID, commentID, reason string) error {
+	comment, err := s.repo.GetComment(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	entry, err := newAuditEntry(adminID, models.ActionCommentRemove, models.TargetComment, commentID, map[string]interface{}{
+		"reason":   reason,
+		"promo_id": comment.PromoID,
+		"user_id":  comment.UserID,
+		"text":     comment.Text,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteComment(ctx, commentID, entry)
+}
+
+func (s *adminService) GetActivations(ctx context.Context, filter dto.ActivationFilter, limit, offset int) ([]dto.ActivationResponse, int64, error) {
+	activations, totalCount, err := s.repo.GetActivations(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]dto.ActivationResponse, len(activations))
+	for i := range activations {
+		response[i] = dto.NewActivationResponse(&activations[i])
+	}
+	return response, totalCount, nil
+}
+
+func (s *adminService) GetAuditLog(ctx context.Context, limit, offset int) ([]dto.AuditEntryResponse, int64, error) {
+	entries, totalCount, err := s.repo.GetAuditLog(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]dto.AuditEntryResponse, len(entries))
+	for i := range entries {
+		response[i] = dto.NewAuditEntryResponse(&entries[i])
+	}
+	return response, totalCount, nil
+}
+
+func newAuditEntry(adminID, action, targetType, targetID string, details map[string]interface{}) (*models.AuditEntry, error) {
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditEntry{
+		AdminID:    adminID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    string(raw),
+	}, nil
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"solution/internal/repository/admin"
+	"solution/internal/shared/config"
+	models "solution/internal/shared/models/admin"
+	"solution/internal/shared/models/admin/dto"
+	"solution/internal/shared/utils"
+)
+
+type AuthService interface {
+	AuthenticateAdmin(ctx context.Context, req dto.SignInRequest) (string, error)
+	EnsureAdmin(ctx context.Context, email, password string) error
+}
+
+type authService struct {
+	repo admin.AuthRepository
+}
+
+func NewAuthService(repo admin.AuthRepository) AuthService {
+	return &authService{repo: repo}
+}
+
+func (s *authService) AuthenticateAdmin(ctx context.Context, req dto.SignInRequest) (string, error) {
+	a, err := s.repo.GetAdmin(ctx, req.Email)
+	if err != nil {
+		return "", dto.ErrInvalidCredentials
+	}
+
+	if err := utils.CompareHashPassword(req.Password, a.Password); err != nil {
+		return "", dto.ErrInvalidCredentials
+	}
+
+	token, err := utils.GenerateToken(a.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.WhitelistToken(ctx, token, a.ID, config.GetTokenTTL()); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// EnsureAdmin создаёт администратора из конфигурации, если его ещё нет.
+// Пароль существующего администратора не меняется.
+func (s *authService) EnsureAdmin(ctx context.Context, email, password string) error {
+	if s.repo.IsEmailRegistered(ctx, email) {
+		return nil
+	}
+
+	hash, err := utils.GenerateHashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateAdmin(ctx, &models.Admin{Email: email, Password: hash}); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Admin account created", "email", email)
+	return nil
+}
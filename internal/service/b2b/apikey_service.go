@@ -0,0 +1,113 @@
+package b2b
+
+import (
+	"context"
+	"fmt"
+	"solution/internal/repository/b2b"
+	models "solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/utils"
+)
+
+type ApiKeyService interface {
+	CreateApiKey(ctx context.Context, companyID string, req dto.ApiKeyCreateRequest) (*dto.ApiKeyCreateResponse, error)
+	GetApiKeys(ctx context.Context, companyID string) ([]dto.ApiKeyResponse, error)
+	RevokeApiKey(ctx context.Context, companyID, keyID string) error
+	AuthenticateApiKey(ctx context.Context, key string) (*models.ApiKey, error)
+}
+
+type apiKeyService struct {
+	repo b2b.ApiKeyRepository
+}
+
+func NewApiKeyService(repo b2b.ApiKeyRepository) ApiKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func (s *apiKeyService) CreateApiKey(ctx context.Context, companyID string, req dto.ApiKeyCreateRequest) (*dto.ApiKeyCreateResponse, error) {
+	key, prefix, err := utils.GenerateApiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &models.ApiKey{
+		CompanyID: companyID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   utils.HashApiKey(key),
+		Scopes:    uniqueScopes(req.Scopes),
+	}
+
+	if err := s.repo.CreateApiKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	return &dto.ApiKeyCreateResponse{
+		ApiKeyResponse: dto.NewApiKeyResponse(apiKey),
+		Key:            key,
+	}, nil
+}
+
+func (s *apiKeyService) GetApiKeys(ctx context.Context, companyID string) ([]dto.ApiKeyResponse, error) {
+	keys, err := s.repo.GetApiKeys(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.ApiKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = dto.NewApiKeyResponse(&keys[i])
+	}
+
+	return response, nil
+}
+
+func (s *apiKeyService) RevokeApiKey(ctx context.Context, companyID, keyID string) error {
+	return s.repo.RevokeApiKey(ctx, companyID, keyID)
+}
+
+func (s *apiKeyService) AuthenticateApiKey(ctx context.Context, key string) (*models.ApiKey, error) {
+	return s.repo.GetApiKeyByHash(ctx, utils.HashApiKey(key))
+}
+
+// ApiKeyUsage откладывает обновление last_used_at API ключа до конца запроса, чтобы запись в БД
+// не задерживала ответ интеграции. Регистрируется как scoped сервис и закрывается вместе с областью запроса.
+type ApiKeyUsage struct {
+	ctx   context.Context
+	repo  b2b.ApiKeyRepository
+	keyID string
+}
+
+func NewApiKeyUsage(ctx context.Context, repo b2b.ApiKeyRepository) *ApiKeyUsage {
+	return &ApiKeyUsage{ctx: ctx, repo: repo}
+}
+
+// Record запоминает ключ, которым авторизован запрос
+func (u *ApiKeyUsage) Record(keyID string) {
+	u.keyID = keyID
+}
+
+// Close обновляет last_used_at записанного ключа. Ошибка не ломает запрос интеграции:
+// ответ уже отправлен, ScopeMiddleware только пишет её в лог.
+func (u *ApiKeyUsage) Close() error {
+	if u.keyID == "" {
+		return nil
+	}
+	if err := u.repo.TouchApiKey(u.ctx, u.keyID); err != nil {
+		return fmt.Errorf("update last_used_at of api key %s: %w", u.keyID, err)
+	}
+	return nil
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]struct{}, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		result = append(result, scope)
+	}
+	return result
+}
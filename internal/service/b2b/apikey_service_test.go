@@ -0,0 +1,60 @@
+package b2b
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"solution/internal/repository/b2b"
+)
+
+// touchRecorder - репозиторий ключей, в котором нужен только TouchApiKey
+type touchRecorder struct {
+	b2b.ApiKeyRepository
+	touched []string
+	err     error
+}
+
+func (r *touchRecorder) TouchApiKey(_ context.Context, keyID string) error {
+	r.touched = append(r.touched, keyID)
+	return r.err
+}
+
+func TestApiKeyUsageClose(t *testing.T) {
+	errTouch := errors.New("touch failed")
+
+	tests := []struct {
+		name    string
+		keyID   string
+		repoErr error
+		touched int
+		wantErr error
+	}{
+		{name: "no api key in request", touched: 0},
+		{name: "recorded key", keyID: "key-1", touched: 1},
+		{name: "touch error", keyID: "key-1", repoErr: errTouch, touched: 1, wantErr: errTouch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &touchRecorder{err: tt.repoErr}
+			usage := NewApiKeyUsage(context.Background(), repo)
+			if tt.keyID != "" {
+				usage.Record(tt.keyID)
+			}
+
+			if len(repo.touched) != 0 {
+				t.Fatalf("last_used_at updated before Close")
+			}
+			if err := usage.Close(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Close() = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.touched) != tt.touched {
+				t.Errorf("TouchApiKey called %d times, want %d", len(repo.touched), tt.touched)
+			}
+			if tt.touched > 0 && repo.touched[0] != tt.keyID {
+				t.Errorf("touched key %q, want %q", repo.touched[0], tt.keyID)
+			}
+		})
+	}
+}
@@ -1,20 +1,22 @@
 package b2b
 
 import (
-	"errors"
+	"context"
 	"solution/internal/repository/b2b"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/config"
 	"solution/internal/shared/models/b2b/dto"
 	"solution/internal/shared/utils"
 )
 
 var (
-	ErrEmailAlreadyRegistered = errors.New("email already registered")
-	ErrInvalidCredentials     = errors.New("invalid credentials")
+	ErrEmailAlreadyRegistered = apperr.New(apperr.CodeConflict, "email already registered")
+	ErrInvalidCredentials     = apperr.New(apperr.CodeUnauthorized, "invalid credentials")
 )
 
 type AuthService interface {
-	RegisterCompany(req dto.SignUpRequest) (string, string, error)
-	AuthenticateCompany(req dto.SignInRequest) (string, error)
+	RegisterCompany(ctx context.Context, req dto.SignUpRequest) (string, string, error)
+	AuthenticateCompany(ctx context.Context, req dto.SignInRequest) (string, error)
 }
 
 type authService struct {
@@ -25,8 +27,8 @@ func NewAuthService(repo b2b.AuthRepository) AuthService {
 	return &authService{repo: repo}
 }
 
-func (s *authService) RegisterCompany(req dto.SignUpRequest) (string, string, error) {
-	if s.repo.IsEmailRegistered(req.Email) {
+func (s *authService) RegisterCompany(ctx context.Context, req dto.SignUpRequest) (string, string, error) {
+	if s.repo.IsEmailRegistered(ctx, req.Email) {
 		return "", "", ErrEmailAlreadyRegistered
 	}
 
@@ -36,7 +38,7 @@ func (s *authService) RegisterCompany(req dto.SignUpRequest) (string, string, er
 	}
 	req.Password = hash
 
-	companyID, err := s.repo.CreateCompany(req)
+	companyID, err := s.repo.CreateCompany(ctx, req)
 	if err != nil {
 		return "", "", err
 	}
@@ -46,7 +48,7 @@ func (s *authService) RegisterCompany(req dto.SignUpRequest) (string, string, er
 		return "", "", err
 	}
 
-	err = s.repo.WhitelistToken(token, companyID)
+	err = s.repo.WhitelistToken(ctx, token, companyID, config.GetTokenTTL())
 	if err != nil {
 		return "", "", err
 	}
@@ -54,8 +56,8 @@ func (s *authService) RegisterCompany(req dto.SignUpRequest) (string, string, er
 	return token, companyID, nil
 }
 
-func (s *authService) AuthenticateCompany(req dto.SignInRequest) (string, error) {
-	company, err := s.repo.GetCompany(req.Email)
+func (s *authService) AuthenticateCompany(ctx context.Context, req dto.SignInRequest) (string, error) {
+	company, err := s.repo.GetCompany(ctx, req.Email)
 	if err != nil {
 		return "", ErrInvalidCredentials
 	}
@@ -64,12 +66,16 @@ func (s *authService) AuthenticateCompany(req dto.SignInRequest) (string, error)
 		return "", ErrInvalidCredentials
 	}
 
+	if company.BlockedAt != nil {
+		return "", dto.ErrAccountBlocked
+	}
+
 	token, err := utils.GenerateToken(company.ID)
 	if err != nil {
 		return "", err
 	}
 
-	err = s.repo.WhitelistToken(token, company.ID)
+	err = s.repo.WhitelistToken(ctx, token, company.ID, config.GetTokenTTL())
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,141 @@
+package b2b
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/etag"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b/dto"
+)
+
+// UpdatePromoCodes меняет коды промокода. Проверки зависят от числа активаций: запись проходит, только если
+// промокод не активировали после проверки, иначе изменение проверяется заново для нового числа активаций.
+func (s *promoService) UpdatePromoCodes(ctx context.Context, companyID string, promoID string, req dto.PromoCodesRequest, match etag.Condition, actor models.Actor) (*dto.PromoReadOnlyResponse, error) {
+	for attempt := 1; ; attempt++ {
+		promo, err := s.repo.GetPromoByID(ctx, promoID)
+		if err != nil {
+			return nil, err
+		}
+
+		if promo.CompanyID != companyID {
+			return nil, dto.ErrorNoAccess
+		}
+		if !match.Matches(promo.Version) {
+			return nil, dto.ErrorPromoModified
+		}
+
+		update, err := applyCodesChange(promo, req)
+		if err != nil {
+			return nil, err
+		}
+		update.ExpectedVersion = promo.Version
+
+		updated, err := s.repo.UpdatePromoCodes(ctx, promoID, *update, actor)
+		retry := errors.Is(err, dto.ErrorPromoActivated) || errors.Is(err, dto.ErrorPromoModified) && match.Any()
+		if retry && attempt < maxMergeAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		company, err := s.repo.GetCompanyById(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+
+		return dto.NewPromoReadOnlyResponse(updated, company.Name), nil
+	}
+}
+
+// applyCodesChange проверяет изменение кодов для текущего состояния промокода. Уникальные коды выдаются
+// по порядку, поэтому первые used_count кодов списка уже выданы: их нельзя удалить, а их значения -
+// использовать снова. Общий код после ротации выданным считается, если промокод уже активировали.
+func applyCodesChange(promo *models.Promo, req dto.PromoCodesRequest) (*dto.PromoCodesUpdate, error) {
+	update := &dto.PromoCodesUpdate{
+		Mode:         promo.Mode,
+		PromoCommon:  promo.PromoCommon,
+		PromoUnique:  slices.Clone(promo.PromoUnique),
+		RetiredCodes: slices.Clone(promo.RetiredCodes),
+		MaxCount:     promo.MaxCount,
+		UsedCount:    promo.UsedCount,
+	}
+
+	switchMode := req.Mode != "" && req.Mode != promo.Mode
+	if switchMode {
+		// до первой активации ни один код не выдан, текущие коды просто заменяются кодами нового режима
+		if promo.UsedCount > 0 {
+			return nil, dto.ErrorPromoModeLocked
+		}
+		update.Mode = req.Mode
+		update.PromoCommon = ""
+		update.PromoUnique = nil
+	}
+
+	switch update.Mode {
+	case "COMMON":
+		if len(req.AddUnique) > 0 || len(req.RemoveUnique) > 0 {
+			return nil, apperr.Validation(apperr.Field("add_unique", "unique codes are not allowed in COMMON mode"))
+		}
+		if req.PromoCommon != "" && req.PromoCommon != update.PromoCommon {
+			if slices.Contains(update.RetiredCodes, req.PromoCommon) {
+				return nil, dto.ErrorPromoCodeUsed
+			}
+			if !switchMode && promo.UsedCount > 0 {
+				update.RetiredCodes = append(update.RetiredCodes, promo.PromoCommon)
+			}
+			update.PromoCommon = req.PromoCommon
+		}
+		if update.PromoCommon == "" {
+			return nil, apperr.Validation(apperr.Field("promo_common", "is required for COMMON mode"))
+		}
+
+		if req.MaxCount != nil {
+			if *req.MaxCount < promo.UsedCount {
+				return nil, dto.ErrorMaxCountBelowUsed
+			}
+			update.MaxCount = *req.MaxCount
+		} else if switchMode {
+			return nil, apperr.Validation(apperr.Field("max_count", "is required when switching to COMMON mode"))
+		}
+
+	case "UNIQUE":
+		if req.PromoCommon != "" {
+			return nil, apperr.Validation(apperr.Field("promo_common", "common code is not allowed in UNIQUE mode"))
+		}
+		if req.MaxCount != nil && *req.MaxCount > 1 {
+			return nil, apperr.Validation(apperr.Field("max_count", "must be 1 for UNIQUE mode"))
+		}
+
+		for _, code := range req.RemoveUnique {
+			i := slices.Index(update.PromoUnique, code)
+			if i < 0 {
+				return nil, dto.ErrorPromoCodeNotFound
+			}
+			if i < promo.UsedCount {
+				return nil, dto.ErrorPromoCodeIssued
+			}
+			update.PromoUnique = slices.Delete(update.PromoUnique, i, i+1)
+		}
+		for _, code := range req.AddUnique {
+			if slices.Contains(update.PromoUnique, code) || slices.Contains(update.RetiredCodes, code) {
+				return nil, dto.ErrorPromoCodeUsed
+			}
+			update.PromoUnique = append(update.PromoUnique, code)
+		}
+		if len(update.PromoUnique) == 0 {
+			return nil, apperr.Validation(apperr.Field("promo_unique", "must not be empty"))
+		}
+
+		if req.MaxCount != nil {
+			update.MaxCount = *req.MaxCount
+		} else if switchMode {
+			update.MaxCount = 1
+		}
+	}
+
+	return update, nil
+}
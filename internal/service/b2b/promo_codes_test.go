@@ -0,0 +1,110 @@
+package b2b
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b/dto"
+)
+
+func TestApplyCodesChangeModeSwitch(t *testing.T) {
+	common := func(used int) *models.Promo {
+		return &models.Promo{Mode: "COMMON", PromoCommon: "CODE", MaxCount: 10, UsedCount: used}
+	}
+	unique := func(used int) *models.Promo {
+		return &models.Promo{Mode: "UNIQUE", PromoUnique: pq.StringArray{"A", "B"}, MaxCount: 1, UsedCount: used}
+	}
+
+	tests := []struct {
+		name    string
+		promo   *models.Promo
+		req     dto.PromoCodesRequest
+		want    *dto.PromoCodesUpdate
+		wantErr error
+		field   string
+	}{
+		{
+			name:  "common to unique before activation",
+			promo: common(0),
+			req:   dto.PromoCodesRequest{Mode: "UNIQUE", AddUnique: []string{"X", "Y"}},
+			want:  &dto.PromoCodesUpdate{Mode: "UNIQUE", PromoUnique: []string{"X", "Y"}, MaxCount: 1},
+		},
+		{
+			name:  "unique to common before activation",
+			promo: unique(0),
+			req:   dto.PromoCodesRequest{Mode: "COMMON", PromoCommon: "NEW", MaxCount: intPtr(5)},
+			want:  &dto.PromoCodesUpdate{Mode: "COMMON", PromoCommon: "NEW", MaxCount: 5},
+		},
+		{
+			name:  "unique to common needs max_count",
+			promo: unique(0),
+			req:   dto.PromoCodesRequest{Mode: "COMMON", PromoCommon: "NEW"},
+			field: "max_count",
+		},
+		{
+			name:  "switch needs codes of the new mode",
+			promo: common(0),
+			req:   dto.PromoCodesRequest{Mode: "UNIQUE"},
+			field: "promo_unique",
+		},
+		{
+			name:    "common to unique after first activation",
+			promo:   common(1),
+			req:     dto.PromoCodesRequest{Mode: "UNIQUE", AddUnique: []string{"X"}},
+			wantErr: dto.ErrorPromoModeLocked,
+		},
+		{
+			name:    "unique to common after first activation",
+			promo:   unique(1),
+			req:     dto.PromoCodesRequest{Mode: "COMMON", PromoCommon: "NEW", MaxCount: intPtr(5)},
+			wantErr: dto.ErrorPromoModeLocked,
+		},
+		{
+			name:  "same mode after activation is not a switch",
+			promo: common(1),
+			req:   dto.PromoCodesRequest{Mode: "COMMON", PromoCommon: "NEW"},
+			want:  &dto.PromoCodesUpdate{Mode: "COMMON", PromoCommon: "NEW", RetiredCodes: []string{"CODE"}, MaxCount: 10, UsedCount: 1},
+		},
+		{
+			name:  "same unique mode after activation adds codes",
+			promo: unique(1),
+			req:   dto.PromoCodesRequest{Mode: "UNIQUE", AddUnique: []string{"C"}},
+			want:  &dto.PromoCodesUpdate{Mode: "UNIQUE", PromoUnique: []string{"A", "B", "C"}, MaxCount: 1, UsedCount: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := *tt.promo
+			got, err := applyCodesChange(tt.promo, tt.req)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("applyCodesChange() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.field != "":
+				var appErr *apperr.Error
+				if !errors.As(err, &appErr) || len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.field {
+					t.Fatalf("applyCodesChange() error = %v, want validation error of %s", err, tt.field)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("applyCodesChange() unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("applyCodesChange() = %+v, want %+v", got, tt.want)
+				}
+			}
+
+			// промокод не меняется: изменение пишет репозиторий
+			if !reflect.DeepEqual(*tt.promo, before) {
+				t.Errorf("promo changed to %+v", *tt.promo)
+			}
+		})
+	}
+}
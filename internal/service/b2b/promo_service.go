@@ -1,19 +1,25 @@
 package b2b
 
 import (
+	"context"
 	"errors"
 	"gorm.io/gorm"
 	b2b2 "solution/internal/repository/b2b"
+	"solution/internal/shared/etag"
+	"solution/internal/shared/metrics"
 	"solution/internal/shared/models"
 	"solution/internal/shared/models/b2b/dto"
 )
 
 type PromoService interface {
-	CreatePromo(req dto.PromoCreateRequest) (string, error)
-	GetPromos(companyID string, limit, offset int, sortBy string, country []string) ([]models.Promo, int64, error)
-	GetPromoByID(companyID string, promoID string) (*dto.PromoReadOnlyResponse, error)
-	UpdatePromo(companyID string, promoID string, req dto.PromoPatchRequest) (*models.Promo, error)
-	GetPromoStatByID(companyID string, promoID string) (*dto.PromoStatResponse, error)
+	CreatePromo(ctx context.Context, req dto.PromoCreateRequest, actor models.Actor) (string, error)
+	GetPromos(ctx context.Context, companyID string, limit, offset int, sortBy string, country []string) ([]*dto.PromoReadOnlyResponse, int64, error)
+	GetPromoByID(ctx context.Context, companyID string, promoID string) (*dto.PromoReadOnlyResponse, error)
+	UpdatePromo(ctx context.Context, companyID string, promoID string, patch []byte, match etag.Condition, actor models.Actor) (*dto.PromoReadOnlyResponse, error)
+	UpdatePromoCodes(ctx context.Context, companyID string, promoID string, req dto.PromoCodesRequest, match etag.Condition, actor models.Actor) (*dto.PromoReadOnlyResponse, error)
+	GetPromoHistory(ctx context.Context, companyID string, promoID string, limit, offset int) ([]*dto.PromoChangeResponse, int64, error)
+	GetPromoStatByID(ctx context.Context, companyID string, promoID string) (*dto.PromoStatResponse, error)
+	EstimateAudience(ctx context.Context, target models.Target) (*dto.AudienceEstimateResponse, error)
 }
 
 type promoService struct {
@@ -24,16 +30,36 @@ func NewPromoService(repo b2b2.PromoRepository) PromoService {
 	return &promoService{repo: repo}
 }
 
-func (s *promoService) CreatePromo(req dto.PromoCreateRequest) (string, error) {
-	return s.repo.CreatePromo(req)
+func (s *promoService) CreatePromo(ctx context.Context, req dto.PromoCreateRequest, actor models.Actor) (string, error) {
+	promoID, err := s.repo.CreatePromo(ctx, req, actor)
+	if err != nil {
+		return "", err
+	}
+
+	metrics.PromosCreated.Inc()
+	return promoID, nil
 }
 
-func (s *promoService) GetPromos(companyID string, limit, offset int, sortBy string, country []string) ([]models.Promo, int64, error) {
-	return s.repo.GetPromos(companyID, limit, offset, sortBy, country)
+func (s *promoService) GetPromos(ctx context.Context, companyID string, limit, offset int, sortBy string, country []string) ([]*dto.PromoReadOnlyResponse, int64, error) {
+	promos, totalCount, err := s.repo.GetPromos(ctx, companyID, limit, offset, sortBy, country)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	company, err := s.repo.GetCompanyById(ctx, companyID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]*dto.PromoReadOnlyResponse, 0, len(promos))
+	for i := range promos {
+		response = append(response, dto.NewPromoReadOnlyResponse(&promos[i], company.Name))
+	}
+	return response, totalCount, nil
 }
 
-func (s *promoService) GetPromoByID(companyID string, promoID string) (*dto.PromoReadOnlyResponse, error) {
-	promo, err := s.repo.GetPromoByID(promoID)
+func (s *promoService) GetPromoByID(ctx context.Context, companyID string, promoID string) (*dto.PromoReadOnlyResponse, error) {
+	promo, err := s.repo.GetPromoByID(ctx, promoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, gorm.ErrRecordNotFound
@@ -41,7 +67,7 @@ func (s *promoService) GetPromoByID(companyID string, promoID string) (*dto.Prom
 		return nil, err
 	}
 
-	company, err := s.repo.GetCompanyById(promo.CompanyID)
+	company, err := s.repo.GetCompanyById(ctx, promo.CompanyID)
 	if err != nil {
 		return nil, err
 	}
@@ -49,56 +75,58 @@ func (s *promoService) GetPromoByID(companyID string, promoID string) (*dto.Prom
 		return nil, gorm.ErrRecordNotFound
 	}
 
-	promoResponse := &dto.PromoReadOnlyResponse{
-		Description: promo.Description,
-		ImageURL:    promo.ImageURL,
-		Target:      promo.Target,
-		MaxCount:    promo.MaxCount,
-		ActiveFrom:  promo.ActiveFrom,
-		ActiveUntil: promo.ActiveUntil,
-		Mode:        promo.Mode,
-		PromoCommon: promo.PromoCommon,
-		PromoUnique: promo.PromoUnique,
-		PromoId:     promo.ID,
-		CompanyID:   promo.CompanyID,
-		CompanyName: company.Name,
-		LikeCount:   promo.LikeCount,
-		UsedCount:   promo.UsedCount,
-		Active:      promo.Active,
-	}
-
 	if promo.CompanyID != companyID {
 		return nil, dto.ErrorNoAccess
 	}
 
-	return promoResponse, nil
+	return dto.NewPromoReadOnlyResponse(promo, company.Name), nil
 }
 
-func (s *promoService) UpdatePromo(companyID string, promoID string, req dto.PromoPatchRequest) (*models.Promo, error) {
-	promo, err := s.repo.GetPromoByID(promoID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, gorm.ErrRecordNotFound
+// maxMergeAttempts - сколько раз изменение применяется заново, если промокод изменили или активировали параллельно
+const maxMergeAttempts = 3
+
+// UpdatePromo применяет JSON Merge Patch к текущему состоянию промокода. Запись проходит только для той версии,
+// к которой применялся патч: с If-Match параллельное изменение даёт 412, без него патч применяется заново.
+func (s *promoService) UpdatePromo(ctx context.Context, companyID string, promoID string, patch []byte, match etag.Condition, actor models.Actor) (*dto.PromoReadOnlyResponse, error) {
+	for attempt := 1; ; attempt++ {
+		promo, err := s.repo.GetPromoByID(ctx, promoID)
+		if err != nil {
+			return nil, err
 		}
-		return nil, err
-	}
 
-	if req.MaxCount != nil {
-		if promo.Mode == "UNIQUE" && *req.MaxCount > 1 {
-			return nil, dto.ErrBadRequest
+		if promo.CompanyID != companyID {
+			return nil, dto.ErrorNoAccess
+		}
+		if !match.Matches(promo.Version) {
+			return nil, dto.ErrorPromoModified
 		}
-	}
 
-	if promo.CompanyID != companyID {
-		return nil, dto.ErrorNoAccess
-	}
+		update, err := dto.MergePromoPatch(promo, patch)
+		if err != nil {
+			return nil, err
+		}
+		update.ExpectedVersion = promo.Version
+
+		updated, err := s.repo.UpdatePromo(ctx, promoID, *update, actor)
+		if errors.Is(err, dto.ErrorPromoModified) && match.Any() && attempt < maxMergeAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	return s.repo.UpdatePromo(promoID, req)
+		company, err := s.repo.GetCompanyById(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+
+		return dto.NewPromoReadOnlyResponse(updated, company.Name), nil
+	}
 }
 
-func (s *promoService) GetPromoStatByID(companyID string, promoID string) (*dto.PromoStatResponse, error) {
+func (s *promoService) GetPromoStatByID(ctx context.Context, companyID string, promoID string) (*dto.PromoStatResponse, error) {
 	// First, check if the promo exists and belongs to the company
-	promo, err := s.repo.GetPromoByID(promoID)
+	promo, err := s.repo.GetPromoByID(ctx, promoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrorPromoNotFound
@@ -110,10 +138,36 @@ func (s *promoService) GetPromoStatByID(companyID string, promoID string) (*dto.
 		return nil, dto.ErrorNoAccessToPromo
 	}
 
-	promoStat, err := s.repo.GetPromoStatByID(promoID)
+	promoStat, err := s.repo.GetPromoStatByID(ctx, promoID)
 	if err != nil {
 		return nil, err
 	}
 
 	return promoStat, nil
 }
+
+func (s *promoService) GetPromoHistory(ctx context.Context, companyID string, promoID string, limit, offset int) ([]*dto.PromoChangeResponse, int64, error) {
+	promo, err := s.repo.GetPromoByID(ctx, promoID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if promo.CompanyID != companyID {
+		return nil, 0, dto.ErrorNoAccessToPromo
+	}
+
+	changes, totalCount, err := s.repo.GetPromoHistory(ctx, promoID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]*dto.PromoChangeResponse, 0, len(changes))
+	for i := range changes {
+		response = append(response, dto.NewPromoChangeResponse(&changes[i]))
+	}
+	return response, totalCount, nil
+}
+
+func (s *promoService) EstimateAudience(ctx context.Context, target models.Target) (*dto.AudienceEstimateResponse, error) {
+	return s.repo.EstimateAudience(ctx, target)
+}
@@ -0,0 +1,234 @@
+package b2b
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"solution/internal/repository/memory"
+	"solution/internal/shared/etag"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b/dto"
+)
+
+type promoFixture struct {
+	ctx       context.Context
+	store     *memory.Store
+	service   PromoService
+	companyID string
+	actor     models.Actor
+}
+
+func newPromoFixture(t *testing.T) *promoFixture {
+	t.Helper()
+
+	ctx := context.Background()
+	store := memory.NewStore()
+	companyID, err := memory.NewB2BAuthRepository(store).CreateCompany(ctx, dto.SignUpRequest{Name: "Company", Email: "company@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+
+	return &promoFixture{
+		ctx:       ctx,
+		store:     store,
+		service:   NewPromoService(memory.NewB2BPromoRepository(store)),
+		companyID: companyID,
+		actor:     models.Actor{Type: models.ActorCompany, ID: companyID},
+	}
+}
+
+func (f *promoFixture) promo(t *testing.T, edit func(req *dto.PromoCreateRequest)) string {
+	t.Helper()
+
+	maxCount := 10
+	req := dto.PromoCreateRequest{
+		CompanyID:   f.companyID,
+		Description: "Promo description",
+		Mode:        "COMMON",
+		PromoCommon: "CODE",
+		Target:      &models.Target{},
+		MaxCount:    &maxCount,
+	}
+	if edit != nil {
+		edit(&req)
+	}
+
+	promoID, err := f.service.CreatePromo(f.ctx, req, f.actor)
+	if err != nil {
+		t.Fatalf("create promo: %v", err)
+	}
+	return promoID
+}
+
+func (f *promoFixture) activate(t *testing.T, promoID string, times int) {
+	t.Helper()
+	for i := 0; i < times; i++ {
+		if err := f.store.AddActivation(f.ctx, promoID, "user"); err != nil {
+			t.Fatalf("add activation: %v", err)
+		}
+	}
+}
+
+func ifMatch(t *testing.T, header string) etag.Condition {
+	t.Helper()
+	match, err := etag.ParseIfMatch(header)
+	if err != nil {
+		t.Fatalf("parse If-Match %q: %v", header, err)
+	}
+	return match
+}
+
+func TestUpdatePromo(t *testing.T) {
+	tests := []struct {
+		name        string
+		patch       string
+		ifMatch     string
+		activations int
+		otherOwner  bool
+		wantErr     error
+		wantVersion int
+	}{
+		{name: "without If-Match", patch: `{"description": "New description"}`, wantVersion: 2},
+		{name: "current version", patch: `{"description": "New description"}`, ifMatch: `"1"`, wantVersion: 2},
+		{name: "version in list", patch: `{"description": "New description"}`, ifMatch: `"5", "1"`, wantVersion: 2},
+		{name: "stale version", patch: `{"description": "New description"}`, ifMatch: `"2"`, wantErr: dto.ErrorPromoModified},
+		{name: "weak tag", patch: `{"description": "New description"}`, ifMatch: `W/"1"`, wantErr: dto.ErrorPromoModified},
+		{name: "no changes keep version", patch: `{"description": "Promo description"}`, wantVersion: 1},
+		{name: "other company", patch: `{"description": "New description"}`, otherOwner: true, wantErr: dto.ErrorNoAccess},
+		{name: "max_count below activations", patch: `{"max_count": 2}`, activations: 3, wantErr: dto.ErrorMaxCountBelowUsed},
+		{name: "max_count equal to activations", patch: `{"max_count": 3}`, activations: 3, wantVersion: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPromoFixture(t)
+			promoID := f.promo(t, nil)
+			f.activate(t, promoID, tt.activations)
+
+			companyID := f.companyID
+			if tt.otherOwner {
+				companyID = "other"
+			}
+
+			updated, err := f.service.UpdatePromo(f.ctx, companyID, promoID, []byte(tt.patch), ifMatch(t, tt.ifMatch), f.actor)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdatePromo() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdatePromo() unexpected error: %v", err)
+			}
+			if updated.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", updated.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestUpdatePromoCodes(t *testing.T) {
+	unique := func(req *dto.PromoCreateRequest) {
+		maxCount := 1
+		req.Mode = "UNIQUE"
+		req.PromoCommon = ""
+		req.PromoUnique = []string{"A", "B", "C"}
+		req.MaxCount = &maxCount
+	}
+
+	tests := []struct {
+		name        string
+		edit        func(req *dto.PromoCreateRequest)
+		activations int
+		req         dto.PromoCodesRequest
+		wantErr     error
+		check       func(t *testing.T, promo *dto.PromoReadOnlyResponse)
+	}{
+		{
+			name: "rotate common code before activation",
+			req:  dto.PromoCodesRequest{PromoCommon: "NEW"},
+			check: func(t *testing.T, promo *dto.PromoReadOnlyResponse) {
+				if promo.PromoCommon != "NEW" {
+					t.Errorf("promo_common = %q, want NEW", promo.PromoCommon)
+				}
+			},
+		},
+		{
+			name:        "switch mode after activation",
+			activations: 1,
+			req:         dto.PromoCodesRequest{Mode: "UNIQUE", AddUnique: []string{"A"}},
+			wantErr:     dto.ErrorPromoModeLocked,
+		},
+		{
+			name:        "remove issued unique code",
+			edit:        unique,
+			activations: 1,
+			req:         dto.PromoCodesRequest{RemoveUnique: []string{"A"}},
+			wantErr:     dto.ErrorPromoCodeIssued,
+		},
+		{
+			name:        "remove unissued unique code",
+			edit:        unique,
+			activations: 1,
+			req:         dto.PromoCodesRequest{RemoveUnique: []string{"B"}, AddUnique: []string{"D"}},
+			check: func(t *testing.T, promo *dto.PromoReadOnlyResponse) {
+				if len(promo.PromoUnique) != 3 || promo.PromoUnique[1] != "C" || promo.PromoUnique[2] != "D" {
+					t.Errorf("promo_unique = %v, want [A C D]", promo.PromoUnique)
+				}
+			},
+		},
+		{
+			name:    "add duplicate unique code",
+			edit:    unique,
+			req:     dto.PromoCodesRequest{AddUnique: []string{"C"}},
+			wantErr: dto.ErrorPromoCodeUsed,
+		},
+		{
+			name:        "lower max_count below activations",
+			activations: 4,
+			req:         dto.PromoCodesRequest{MaxCount: intPtr(3)},
+			wantErr:     dto.ErrorMaxCountBelowUsed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPromoFixture(t)
+			promoID := f.promo(t, tt.edit)
+			f.activate(t, promoID, tt.activations)
+
+			updated, err := f.service.UpdatePromoCodes(f.ctx, f.companyID, promoID, tt.req, etag.Condition{}, f.actor)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdatePromoCodes() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdatePromoCodes() unexpected error: %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, updated)
+			}
+		})
+	}
+}
+
+func TestRetiredCommonCodeIsNotReused(t *testing.T) {
+	f := newPromoFixture(t)
+	promoID := f.promo(t, nil)
+	f.activate(t, promoID, 1)
+
+	if _, err := f.service.UpdatePromoCodes(f.ctx, f.companyID, promoID, dto.PromoCodesRequest{PromoCommon: "NEW"}, etag.Condition{}, f.actor); err != nil {
+		t.Fatalf("rotate code: %v", err)
+	}
+	_, err := f.service.UpdatePromoCodes(f.ctx, f.companyID, promoID, dto.PromoCodesRequest{PromoCommon: "CODE"}, etag.Condition{}, f.actor)
+	if !errors.Is(err, dto.ErrorPromoCodeUsed) {
+		t.Fatalf("reuse retired code: error = %v, want %v", err, dto.ErrorPromoCodeUsed)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
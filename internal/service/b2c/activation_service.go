@@ -0,0 +1,96 @@
+package b2c
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	repo "solution/internal/repository/b2c"
+	"solution/internal/shared/antifraud"
+	"solution/internal/shared/metrics"
+	"solution/internal/shared/models/b2c/dto"
+)
+
+type ActivationService interface {
+	ActivatePromo(ctx context.Context, promoID, userID string) (*dto.ActivationResponse, error)
+	GetActivationHistory(ctx context.Context, userID string, limit, offset int) ([]dto.PromoForUser, int64, error)
+}
+
+type activationService struct {
+	repo      repo.PromoRepository
+	limiter   repo.ActivationLimiter
+	antifraud antifraud.Client
+	now       func() time.Time
+}
+
+func NewActivationService(repo repo.PromoRepository, limiter repo.ActivationLimiter, antifraud antifraud.Client) ActivationService {
+	return &activationService{repo: repo, limiter: limiter, antifraud: antifraud, now: time.Now}
+}
+
+// ActivatePromo выдаёт код промокода. Дешёвые проверки идут первыми: доступность промокода, резерв limits
+// в Redis, затем антифрод. Транзакция в Postgres повторяет проверки под блокировкой, и при её отказе
+// резерв в Redis отменяется.
+func (s *activationService) ActivatePromo(ctx context.Context, promoID, userID string) (_ *dto.ActivationResponse, err error) {
+	defer func() { observeActivation(err) }()
+	now := s.now().UTC()
+
+	promo, err := s.repo.GetPromoByID(ctx, promoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrNotFound
+		}
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := dto.CheckActivation(promo, user, now); err != nil {
+		return nil, err
+	}
+
+	release, err := s.limiter.Reserve(ctx, promo, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.antifraud.Allow(ctx, user.Email, promoID)
+	if err != nil {
+		// антифрод не ответил и после повтора: без его решения код не выдаётся, но это не отказ антифрода
+		slog.WarnContext(ctx, "Antifraud is unavailable", "promo_id", promoID, "error", err)
+		release()
+		return nil, dto.ErrAntifraudUnavailable
+	}
+	if !allowed {
+		release()
+		return nil, dto.ErrActivationDenied
+	}
+
+	code, err := s.repo.ActivatePromo(ctx, promoID, userID, now)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &dto.ActivationResponse{Promo: code}, nil
+}
+
+// observeActivation учитывает попытку активации в метрике promo_activations_total.
+// Остальные отказы (limits, таргетинг, неактивный промокод) в метрику не попадают.
+func observeActivation(err error) {
+	switch {
+	case err == nil:
+		metrics.PromoActivations.WithLabelValues(metrics.ActivationOK).Inc()
+	case errors.Is(err, dto.ErrActivationDenied):
+		metrics.PromoActivations.WithLabelValues(metrics.ActivationAntifraudDenied).Inc()
+	case errors.Is(err, dto.ErrAntifraudUnavailable):
+		metrics.PromoActivations.WithLabelValues(metrics.ActivationAntifraudUnavailable).Inc()
+	case errors.Is(err, dto.ErrPromoExhausted):
+		metrics.PromoActivations.WithLabelValues(metrics.ActivationExhausted).Inc()
+	}
+}
+
+func (s *activationService) GetActivationHistory(ctx context.Context, userID string, limit, offset int) ([]dto.PromoForUser, int64, error) {
+	return s.repo.GetActivationHistory(ctx, userID, limit, offset)
+}
@@ -0,0 +1,358 @@
+package b2c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	repo "solution/internal/repository/b2c"
+	"solution/internal/repository/memory"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/metrics"
+	"solution/internal/shared/models"
+	b2b_dto "solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/models/b2c/dto"
+	"solution/internal/shared/storage/redis/redistest"
+)
+
+// antifraudStub запрещает активации пользователям из deny; err - антифрод не ответил
+type antifraudStub struct {
+	deny map[string]bool
+	err  error
+}
+
+func (a *antifraudStub) Allow(_ context.Context, userEmail, _ string) (bool, error) {
+	if a.err != nil {
+		return false, a.err
+	}
+	return !a.deny[userEmail], nil
+}
+
+type activationFixture struct {
+	ctx       context.Context
+	store     *memory.Store
+	redis     *miniredis.Miniredis
+	antifraud *antifraudStub
+	service   *activationService
+	companyID string
+	now       time.Time
+}
+
+func newActivationFixture(t *testing.T) *activationFixture {
+	t.Helper()
+
+	ctx := context.Background()
+	store := memory.NewStore()
+	companyID, err := memory.NewB2BAuthRepository(store).CreateCompany(ctx, b2b_dto.SignUpRequest{Name: "Company", Email: "company@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+
+	rdb, server := redistest.Open(t)
+	f := &activationFixture{
+		ctx:       ctx,
+		store:     store,
+		redis:     server,
+		antifraud: &antifraudStub{deny: map[string]bool{}},
+		companyID: companyID,
+		now:       time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = NewActivationService(memory.NewB2CPromoRepository(store), repo.NewActivationLimiter(rdb), f.antifraud).(*activationService)
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+// wait сдвигает время сервиса и время жизни ключей Redis
+func (f *activationFixture) wait(d time.Duration) {
+	f.now = f.now.Add(d)
+	f.redis.FastForward(d)
+}
+
+func (f *activationFixture) promo(t *testing.T, edit func(req *b2b_dto.PromoCreateRequest)) string {
+	t.Helper()
+
+	maxCount := 10
+	req := b2b_dto.PromoCreateRequest{
+		CompanyID:   f.companyID,
+		Description: "Promo description",
+		Mode:        "COMMON",
+		PromoCommon: "CODE",
+		Target:      &models.Target{},
+		MaxCount:    &maxCount,
+	}
+	if edit != nil {
+		edit(&req)
+	}
+
+	promoID, err := memory.NewB2BPromoRepository(f.store).CreatePromo(f.ctx, req, models.Actor{Type: models.ActorCompany, ID: f.companyID})
+	if err != nil {
+		t.Fatalf("create promo: %v", err)
+	}
+	return promoID
+}
+
+func (f *activationFixture) user(t *testing.T, name, country string) string {
+	t.Helper()
+
+	user := &b2c.User{Name: name, Surname: "Doe", Email: name + "@example.com", Password: "hash", Age: 25, Country: country}
+	userID, err := memory.NewB2CAuthRepository(f.store).CreateUser(f.ctx, user)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return userID
+}
+
+func (f *activationFixture) usedCount(t *testing.T, promoID string) int {
+	t.Helper()
+
+	promo, err := memory.NewB2CPromoRepository(f.store).GetPromoByID(f.ctx, promoID)
+	if err != nil {
+		t.Fatalf("get promo: %v", err)
+	}
+	return promo.UsedCount
+}
+
+// activation - попытка активации пользователем после паузы wait
+type activation struct {
+	user     string
+	wait     time.Duration
+	wantCode string
+	wantErr  error
+}
+
+func TestActivatePromo(t *testing.T) {
+	tests := []struct {
+		name        string
+		edit        func(req *b2b_dto.PromoCreateRequest)
+		activations []activation
+	}{
+		{
+			name: "per user limit",
+			edit: func(req *b2b_dto.PromoCreateRequest) { req.Limits = &models.ActivationLimits{PerUser: 2} },
+			activations: []activation{
+				{user: "alice", wantCode: "CODE"},
+				{user: "alice", wantCode: "CODE"},
+				{user: "alice", wantErr: dto.ErrActivationLimit},
+				{user: "bob", wantCode: "CODE"},
+			},
+		},
+		{
+			name: "cooldown",
+			edit: func(req *b2b_dto.PromoCreateRequest) { req.Limits = &models.ActivationLimits{CooldownSeconds: 60} },
+			activations: []activation{
+				{user: "alice", wantCode: "CODE"},
+				{user: "alice", wait: 30 * time.Second, wantErr: dto.ErrActivationCooldown},
+				{user: "bob", wantCode: "CODE"},
+				{user: "alice", wait: 31 * time.Second, wantCode: "CODE"},
+			},
+		},
+		{
+			name: "daily limit",
+			edit: func(req *b2b_dto.PromoCreateRequest) { req.Limits = &models.ActivationLimits{Daily: 2} },
+			activations: []activation{
+				{user: "alice", wantCode: "CODE"},
+				{user: "bob", wantCode: "CODE"},
+				{user: "carol", wantErr: dto.ErrActivationDailyLimit},
+				{user: "carol", wait: 12 * time.Hour, wantCode: "CODE"},
+			},
+		},
+		{
+			name: "unique codes in order until exhausted",
+			edit: func(req *b2b_dto.PromoCreateRequest) {
+				req.Mode, req.PromoCommon, req.PromoUnique = "UNIQUE", "", []string{"first", "second"}
+				req.MaxCount = intPtr(1)
+			},
+			activations: []activation{
+				{user: "alice", wantCode: "first"},
+				{user: "bob", wantCode: "second"},
+				{user: "carol", wantErr: dto.ErrPromoExhausted},
+			},
+		},
+		{
+			name: "not targeted",
+			edit: func(req *b2b_dto.PromoCreateRequest) { req.Target = &models.Target{Countries: []string{"fr"}} },
+			activations: []activation{
+				{user: "alice", wantErr: dto.ErrPromoNotTargeted},
+			},
+		},
+		{
+			name: "antifraud denial",
+			activations: []activation{
+				{user: "mallory", wantErr: dto.ErrActivationDenied},
+				{user: "alice", wantCode: "CODE"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newActivationFixture(t)
+			f.antifraud.deny["mallory@example.com"] = true
+			promoID := f.promo(t, tt.edit)
+			users := map[string]string{}
+
+			succeeded := 0
+			for i, a := range tt.activations {
+				if users[a.user] == "" {
+					users[a.user] = f.user(t, a.user, "ru")
+				}
+				f.wait(a.wait)
+
+				resp, err := f.service.ActivatePromo(f.ctx, promoID, users[a.user])
+				if !errors.Is(err, a.wantErr) {
+					t.Fatalf("activation %d by %s: error = %v, want %v", i, a.user, err, a.wantErr)
+				}
+				if err != nil {
+					continue
+				}
+				succeeded++
+				if resp.Promo != a.wantCode {
+					t.Fatalf("activation %d by %s: code = %q, want %q", i, a.user, resp.Promo, a.wantCode)
+				}
+			}
+
+			if got := f.usedCount(t, promoID); got != succeeded {
+				t.Fatalf("used_count = %d, want %d: denied activations must not be counted", got, succeeded)
+			}
+		})
+	}
+}
+
+func TestActivatePromoReleasesReservation(t *testing.T) {
+	f := newActivationFixture(t)
+	promoID := f.promo(t, func(req *b2b_dto.PromoCreateRequest) {
+		req.Limits = &models.ActivationLimits{PerUser: 1, CooldownSeconds: 60}
+	})
+	userID := f.user(t, "alice", "ru")
+
+	// резерв limits в Redis делается до антифрода и отменяется, если активация не состоялась
+	f.antifraud.err = errors.New("antifraud is down")
+	if _, err := f.service.ActivatePromo(f.ctx, promoID, userID); !errors.Is(err, dto.ErrAntifraudUnavailable) {
+		t.Fatalf("ActivatePromo() without antifraud error = %v, want %v", err, dto.ErrAntifraudUnavailable)
+	}
+	if status := apperr.From(dto.ErrAntifraudUnavailable).Status(); status != http.StatusServiceUnavailable {
+		t.Fatalf("antifraud unavailable status = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+
+	f.antifraud.err = nil
+	if _, err := f.service.ActivatePromo(f.ctx, promoID, userID); err != nil {
+		t.Fatalf("ActivatePromo() after denial error = %v", err)
+	}
+}
+
+func TestActivatePromoWithoutRedis(t *testing.T) {
+	f := newActivationFixture(t)
+	promoID := f.promo(t, func(req *b2b_dto.PromoCreateRequest) {
+		req.Limits = &models.ActivationLimits{PerUser: 1}
+	})
+	userID := f.user(t, "alice", "ru")
+	f.redis.Close()
+
+	// без Redis limits проверяет транзакция репозитория
+	if _, err := f.service.ActivatePromo(f.ctx, promoID, userID); err != nil {
+		t.Fatalf("ActivatePromo() error = %v", err)
+	}
+	if _, err := f.service.ActivatePromo(f.ctx, promoID, userID); !errors.Is(err, dto.ErrActivationLimit) {
+		t.Fatalf("ActivatePromo() over limit error = %v, want %v", err, dto.ErrActivationLimit)
+	}
+}
+
+func TestActivatePromoNotFound(t *testing.T) {
+	f := newActivationFixture(t)
+	userID := f.user(t, "alice", "ru")
+
+	if _, err := f.service.ActivatePromo(f.ctx, "00000000-0000-0000-0000-000000000000", userID); !errors.Is(err, dto.ErrNotFound) {
+		t.Fatalf("ActivatePromo() error = %v, want %v", err, dto.ErrNotFound)
+	}
+}
+
+func TestGetActivationHistory(t *testing.T) {
+	f := newActivationFixture(t)
+	userID := f.user(t, "alice", "ru")
+	var promoIDs []string
+	for i := 0; i < 3; i++ {
+		promoIDs = append(promoIDs, f.promo(t, func(req *b2b_dto.PromoCreateRequest) {
+			req.Description = fmt.Sprintf("Promo description %d", i)
+		}))
+	}
+
+	// первый промокод активирован дважды и повторяется в истории
+	for _, promoID := range []string{promoIDs[0], promoIDs[1], promoIDs[0], promoIDs[2]} {
+		f.wait(time.Minute)
+		if _, err := f.service.ActivatePromo(f.ctx, promoID, userID); err != nil {
+			t.Fatalf("ActivatePromo() error = %v", err)
+		}
+	}
+
+	history, total, err := f.service.GetActivationHistory(f.ctx, userID, 2, 1)
+	if err != nil {
+		t.Fatalf("GetActivationHistory() error = %v", err)
+	}
+	if total != 4 {
+		t.Fatalf("total = %d, want 4", total)
+	}
+	if len(history) != 2 || history[0].PromoID != promoIDs[0] || history[1].PromoID != promoIDs[1] {
+		t.Fatalf("history page = %+v, want promos %s, %s", history, promoIDs[0], promoIDs[1])
+	}
+	if !history[0].IsActivatedByUser {
+		t.Fatalf("history promo is not marked as activated by user")
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestActivatePromoMetrics(t *testing.T) {
+	f := newActivationFixture(t)
+	f.antifraud.deny["mallory@example.com"] = true
+	promoID := f.promo(t, func(req *b2b_dto.PromoCreateRequest) {
+		req.MaxCount = intPtr(2)
+		req.Limits = &models.ActivationLimits{PerUser: 1}
+	})
+	alice, bob, carol, mallory := f.user(t, "alice", "ru"), f.user(t, "bob", "ru"), f.user(t, "carol", "ru"), f.user(t, "mallory", "ru")
+
+	steps := []struct {
+		userID       string
+		antifraudErr error
+		result       string
+	}{
+		{userID: mallory, result: metrics.ActivationAntifraudDenied},
+		{userID: alice, antifraudErr: errors.New("antifraud is down"), result: metrics.ActivationAntifraudUnavailable},
+		{userID: alice, result: metrics.ActivationOK},
+		// отказ по limits не относится ни к одному результату метрики
+		{userID: alice},
+		{userID: bob, result: metrics.ActivationOK},
+		{userID: carol, result: metrics.ActivationExhausted},
+	}
+	for i, step := range steps {
+		before := activationCounts()
+		f.antifraud.err = step.antifraudErr
+		f.service.ActivatePromo(f.ctx, promoID, step.userID)
+
+		after := activationCounts()
+		for result, count := range after {
+			want := before[result]
+			if result == step.result {
+				want++
+			}
+			if count != want {
+				t.Fatalf("step %d: promo_activations_total{result=%q} = %v, want %v", i, result, count, want)
+			}
+		}
+	}
+}
+
+func activationCounts() map[string]float64 {
+	counts := map[string]float64{}
+	for _, result := range []string{metrics.ActivationOK, metrics.ActivationAntifraudDenied, metrics.ActivationAntifraudUnavailable, metrics.ActivationExhausted} {
+		counts[result] = testutil.ToFloat64(metrics.PromoActivations.WithLabelValues(result))
+	}
+	return counts
+}
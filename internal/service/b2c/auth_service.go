@@ -1,21 +1,24 @@
 package b2c
 
 import (
-	"errors"
+	"context"
 	"solution/internal/repository/b2c"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/config"
 	"solution/internal/shared/models/b2c/dto"
 
 	models "solution/internal/shared/models/b2c"
 	"solution/internal/shared/utils"
+	"strings"
 )
 
 var (
-	ErrEmailAlreadyRegistered = errors.New("email already registered")
+	ErrEmailAlreadyRegistered = apperr.New(apperr.CodeConflict, "email already registered")
 )
 
 type AuthService interface {
-	RegisterUser(req dto.SignUpRequest) (string, string, error)
-	AuthenticateUser(req dto.SignInRequest) (string, error)
+	RegisterUser(ctx context.Context, req dto.SignUpRequest) (string, string, error)
+	AuthenticateUser(ctx context.Context, req dto.SignInRequest) (string, error)
 }
 
 type authService struct {
@@ -26,8 +29,8 @@ func NewAuthService(repo b2c.AuthRepository) AuthService {
 	return &authService{repo: repo}
 }
 
-func (s *authService) RegisterUser(req dto.SignUpRequest) (string, string, error) {
-	if s.repo.IsEmailRegistered(req.Email) {
+func (s *authService) RegisterUser(ctx context.Context, req dto.SignUpRequest) (string, string, error) {
+	if s.repo.IsEmailRegistered(ctx, req.Email) {
 		return "", "", ErrEmailAlreadyRegistered
 	}
 
@@ -49,9 +52,10 @@ func (s *authService) RegisterUser(req dto.SignUpRequest) (string, string, error
 		AvatarURL: avatarURL,
 		Age:       req.Other.Age,
 		Country:   req.Other.Country,
+		Gender:    strings.ToUpper(req.Other.Gender),
 	}
 
-	userID, err := s.repo.CreateUser(newUser)
+	userID, err := s.repo.CreateUser(ctx, newUser)
 	if err != nil {
 		return "", "", err
 	}
@@ -61,7 +65,7 @@ func (s *authService) RegisterUser(req dto.SignUpRequest) (string, string, error
 		return "", "", err
 	}
 
-	err = s.repo.WhitelistToken(token, userID)
+	err = s.repo.WhitelistToken(ctx, token, userID, config.GetTokenTTL())
 	if err != nil {
 		return "", "", err
 	}
@@ -69,9 +73,9 @@ func (s *authService) RegisterUser(req dto.SignUpRequest) (string, string, error
 	return token, userID, nil
 }
 
-func (s *authService) AuthenticateUser(req dto.SignInRequest) (string, error) {
-	user, err := s.repo.GetUserByEmail(req.Email)
-	if err != nil {
+func (s *authService) AuthenticateUser(ctx context.Context, req dto.SignInRequest) (string, error) {
+	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil || user == nil {
 		return "", dto.ErrInvalidCredentials
 	}
 
@@ -79,12 +83,16 @@ func (s *authService) AuthenticateUser(req dto.SignInRequest) (string, error) {
 		return "", dto.ErrInvalidCredentials
 	}
 
+	if user.BlockedAt != nil {
+		return "", dto.ErrAccountBlocked
+	}
+
 	token, err := utils.GenerateToken(user.ID)
 	if err != nil {
 		return "", err
 	}
 
-	err = s.repo.WhitelistToken(token, user.ID)
+	err = s.repo.WhitelistToken(ctx, token, user.ID, config.GetTokenTTL())
 	if err != nil {
 		return "", err
 	}
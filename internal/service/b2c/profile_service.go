@@ -1,15 +1,17 @@
 package b2c
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"golang.org/x/crypto/bcrypt"
 	repo "solution/internal/repository/b2c"
+	"solution/internal/shared/etag"
 	"solution/internal/shared/models/b2c/dto"
 )
 
 type ProfileService interface {
-	GetProfile(userId string) (*dto.ProfileResponse, error)
-	UpdateProfile(userId string, req dto.ProfileUpdateRequest) error
+	GetProfile(ctx context.Context, userId string) (*dto.ProfileResponse, error)
+	UpdateProfile(ctx context.Context, userId string, patch []byte, match etag.Condition) error
 }
 
 type profileService struct {
@@ -20,31 +22,62 @@ func NewProfileService(repo repo.ProfileRepository) ProfileService {
 	return &profileService{repo: repo}
 }
 
-func (s *profileService) GetProfile(userId string) (*dto.ProfileResponse, error) {
-	user, err := s.repo.GetProfile(userId)
+func (s *profileService) GetProfile(ctx context.Context, userId string) (*dto.ProfileResponse, error) {
+	user, err := s.repo.GetProfile(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
-
-	fmt.Println(user)
+	if user == nil {
+		return nil, dto.ErrNotFound
+	}
 
 	return &dto.ProfileResponse{
 		Name:      user.Name,
 		Surname:   user.Surname,
 		Email:     user.Email,
 		AvatarURL: user.AvatarURL,
-		Other:     dto.UserTargetSettings{Age: user.Age, Country: user.Country},
+		Other:     dto.UserTargetSettings{Age: user.Age, Country: user.Country, Gender: user.Gender},
+		Version:   user.Version,
 	}, nil
 }
 
-func (s *profileService) UpdateProfile(userId string, req dto.ProfileUpdateRequest) error {
-	if req.Password != nil {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+// maxMergeAttempts - сколько раз патч без If-Match применяется заново, если профиль изменили параллельно
+const maxMergeAttempts = 3
+
+// UpdateProfile применяет JSON Merge Patch к текущему профилю. Запись проходит только для той версии,
+// к которой применялся патч: с If-Match параллельное изменение даёт 412, без него патч применяется заново.
+func (s *profileService) UpdateProfile(ctx context.Context, userId string, patch []byte, match etag.Condition) error {
+	for attempt := 1; ; attempt++ {
+		user, err := s.repo.GetProfile(ctx, userId)
 		if err != nil {
 			return err
 		}
-		newPassword := string(hashedPassword)
-		req.Password = &newPassword
+		if user == nil {
+			return dto.ErrNotFound
+		}
+		if !match.Matches(user.Version) {
+			return dto.ErrProfileModified
+		}
+
+		req, err := dto.MergeProfilePatch(user, patch)
+		if err != nil {
+			return err
+		}
+		req.ExpectedVersion = user.Version
+
+		if req.Password != nil {
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+			newPassword := string(hashedPassword)
+			req.Password = &newPassword
+		}
+
+		err = s.repo.UpdateProfile(ctx, userId, req)
+		if errors.Is(err, dto.ErrProfileModified) && match.Any() && attempt < maxMergeAttempts {
+			continue
+		}
+		return err
 	}
-	return s.repo.UpdateProfile(userId, &req)
 }
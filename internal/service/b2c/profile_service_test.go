@@ -0,0 +1,112 @@
+package b2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"solution/internal/repository/memory"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/etag"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/models/b2c/dto"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		patch       string
+		ifMatch     string
+		wantErr     error
+		invalid     bool
+		wantName    string
+		wantAvatar  string
+		wantVersion int
+	}{
+		{name: "change name", patch: `{"name": "Peter"}`, wantName: "Peter", wantAvatar: "https://example.com/a.png", wantVersion: 2},
+		{name: "null clears avatar", patch: `{"avatar_url": null}`, wantName: "John", wantVersion: 2},
+		{name: "current version", patch: `{"name": "Peter"}`, ifMatch: `"1"`, wantName: "Peter", wantAvatar: "https://example.com/a.png", wantVersion: 2},
+		{name: "stale version", patch: `{"name": "Peter"}`, ifMatch: `"3"`, wantErr: dto.ErrProfileModified},
+		{name: "no changes", patch: `{"name": "John"}`, wantErr: dto.ErrNoFieldsToUpdate},
+		{name: "invalid merged profile", patch: `{"name": ""}`, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			userID := createUser(t, store)
+			service := NewProfileService(memory.NewProfileRepository(store))
+
+			match, err := etag.ParseIfMatch(tt.ifMatch)
+			if err != nil {
+				t.Fatalf("parse If-Match: %v", err)
+			}
+
+			err = service.UpdateProfile(ctx, userID, []byte(tt.patch), match)
+			if tt.invalid {
+				var appErr *apperr.Error
+				if !errors.As(err, &appErr) || appErr.Code != apperr.CodeValidation {
+					t.Fatalf("UpdateProfile() error = %v, want validation error", err)
+				}
+				return
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateProfile() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateProfile() unexpected error: %v", err)
+			}
+
+			profile, err := service.GetProfile(ctx, userID)
+			if err != nil {
+				t.Fatalf("GetProfile(): %v", err)
+			}
+			if profile.Name != tt.wantName || profile.AvatarURL != tt.wantAvatar || profile.Version != tt.wantVersion {
+				t.Errorf("profile = %+v, want name %q, avatar %q, version %d", profile, tt.wantName, tt.wantAvatar, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileHashesPassword(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	userID := createUser(t, store)
+	service := NewProfileService(memory.NewProfileRepository(store))
+
+	if err := service.UpdateProfile(ctx, userID, []byte(`{"password": "NewPass1!"}`), etag.Condition{}); err != nil {
+		t.Fatalf("UpdateProfile(): %v", err)
+	}
+
+	user, err := memory.NewB2CAuthRepository(store).GetUserByEmail(ctx, "user@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail(): %v", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("NewPass1!")); err != nil {
+		t.Errorf("password is not stored as bcrypt hash: %v", err)
+	}
+}
+
+func createUser(t *testing.T, store *memory.Store) string {
+	t.Helper()
+
+	user := &b2c.User{
+		Name:      "John",
+		Surname:   "Doe",
+		Email:     "user@example.com",
+		Password:  "hash",
+		AvatarURL: "https://example.com/a.png",
+		Age:       25,
+		Country:   "ru",
+	}
+	userID, err := memory.NewB2CAuthRepository(store).CreateUser(context.Background(), user)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return userID
+}
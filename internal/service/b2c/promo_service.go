@@ -1,9 +1,12 @@
 package b2c
 
 import (
+	"context"
 	"errors"
 	"gorm.io/gorm"
 	repo "solution/internal/repository/b2c"
+	"solution/internal/shared/config"
+	"solution/internal/shared/metrics"
 	"solution/internal/shared/models/b2c"
 	"solution/internal/shared/models/b2c/dto"
 	"strconv"
@@ -11,31 +14,58 @@ import (
 )
 
 type PromoService interface {
-	GetPromosForUser(userID string, limit, offset int, category string, active *bool) ([]dto.PromoForUser, int64, error)
-	GetPromo(promoID, userID string) (*dto.PromoForUser, error)
-	LikePromo(promoID, userID string) error
-	UnlikePromo(promoID, userID string) error
-	AddComment(userID, promoID, text string) (*dto.CommentResponse, error)
-	GetComments(promoID, limit, offset string) ([]dto.CommentResponse, int64, error)
-	GetComment(promoID, commentID string) (*dto.CommentResponse, error)
-	EditComment(userID, promoID, commentID, text string) (*dto.CommentResponse, error)
-	DeleteComment(userID, promoID, commentID string) error
+	GetPromosForUser(ctx context.Context, userID string, limit, offset int, category string, active *bool, sortBy string) ([]dto.PromoForUser, int64, error)
+	SearchPromos(ctx context.Context, userID, query string, limit, offset int, active *bool) ([]dto.PromoSearchResult, int64, error)
+	GetPromo(ctx context.Context, promoID, userID string) (*dto.PromoForUser, error)
+	LikePromo(ctx context.Context, promoID, userID string) error
+	UnlikePromo(ctx context.Context, promoID, userID string) error
+	AddComment(ctx context.Context, userID, promoID, text string) (*dto.CommentResponse, error)
+	GetComments(ctx context.Context, promoID, limit, offset string) ([]dto.CommentResponse, int64, error)
+	GetComment(ctx context.Context, promoID, commentID string) (*dto.CommentResponse, error)
+	EditComment(ctx context.Context, userID, promoID, commentID, text string) (*dto.CommentResponse, error)
+	DeleteComment(ctx context.Context, userID, promoID, commentID string) error
 }
 
 type promoService struct {
-	repo repo.PromoRepository
+	repo        repo.PromoRepository
+	rankingRepo repo.RankingRepository
+	ranking     *config.Ranking
 }
 
-func NewPromoService(repo repo.PromoRepository) PromoService {
-	return &promoService{repo: repo}
+func NewPromoService(repo repo.PromoRepository, rankingRepo repo.RankingRepository, ranking *config.Ranking) PromoService {
+	return &promoService{repo: repo, rankingRepo: rankingRepo, ranking: ranking}
 }
 
-func (s *promoService) GetPromosForUser(userID string, limit, offset int, category string, active *bool) ([]dto.PromoForUser, int64, error) {
-	return s.repo.GetPromosForUser(userID, limit, offset, category, active)
+func (s *promoService) GetPromosForUser(ctx context.Context, userID string, limit, offset int, category string, active *bool, sortBy string) ([]dto.PromoForUser, int64, error) {
+	if sortBy != dto.FeedSortRelevance {
+		return s.repo.GetPromosForUser(ctx, userID, limit, offset, category, active, nil)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	affinity, err := s.rankingRepo.GetCategoryAffinity(ctx, userID, s.ranking)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ranking := &dto.FeedRanking{
+		Segment:          b2c.SegmentOf(user.Age, user.Country).Key(),
+		CategoryAffinity: affinity,
+		AffinityWeight:   s.ranking.AffinityWeight,
+	}
+
+	return s.repo.GetPromosForUser(ctx, userID, limit, offset, category, active, ranking)
 }
 
-func (s *promoService) GetPromo(promoID, userID string) (*dto.PromoForUser, error) {
-	promo, err := s.repo.GetPromoForUserByID(promoID, userID)
+func (s *promoService) SearchPromos(ctx context.Context, userID, query string, limit, offset int, active *bool) ([]dto.PromoSearchResult, int64, error) {
+	return s.repo.SearchPromosForUser(ctx, userID, query, limit, offset, active)
+}
+
+func (s *promoService) GetPromo(ctx context.Context, promoID, userID string) (*dto.PromoForUser, error) {
+	promo, err := s.repo.GetPromoForUserByID(ctx, promoID, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrNotFound
@@ -46,16 +76,25 @@ func (s *promoService) GetPromo(promoID, userID string) (*dto.PromoForUser, erro
 	return promo, nil
 }
 
-func (s *promoService) LikePromo(promoID, userID string) error {
-	return s.repo.LikePromo(promoID, userID)
+func (s *promoService) LikePromo(ctx context.Context, promoID, userID string) error {
+	liked, err := s.repo.LikePromo(ctx, promoID, userID)
+	if err != nil {
+		return err
+	}
+
+	// повторный лайк не меняет like_count и не считается в метрике
+	if liked {
+		metrics.PromoLikes.Inc()
+	}
+	return nil
 }
 
-func (s *promoService) UnlikePromo(promoID, userID string) error {
-	return s.repo.UnlikePromo(promoID, userID)
+func (s *promoService) UnlikePromo(ctx context.Context, promoID, userID string) error {
+	return s.repo.UnlikePromo(ctx, promoID, userID)
 }
 
-func (s *promoService) AddComment(userID, promoID, text string) (*dto.CommentResponse, error) {
-	_, err := s.repo.GetPromoByID(promoID)
+func (s *promoService) AddComment(ctx context.Context, userID, promoID, text string) (*dto.CommentResponse, error) {
+	_, err := s.repo.GetPromoByID(ctx, promoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrNotFound
@@ -70,12 +109,13 @@ func (s *promoService) AddComment(userID, promoID, text string) (*dto.CommentRes
 		Text:    text,
 	}
 
-	err = s.repo.AddComment(comment)
+	err = s.repo.AddComment(ctx, comment)
 	if err != nil {
 		return nil, err
 	}
+	metrics.PromoComments.Inc()
 
-	user, err := s.repo.GetUserByID(userID)
+	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -95,8 +135,8 @@ func (s *promoService) AddComment(userID, promoID, text string) (*dto.CommentRes
 }
 
 // GetComments получает список комментариев к промокоду
-func (s *promoService) GetComments(promoID, limitStr, offsetStr string) ([]dto.CommentResponse, int64, error) {
-	_, err := s.repo.GetPromoByID(promoID)
+func (s *promoService) GetComments(ctx context.Context, promoID, limitStr, offsetStr string) ([]dto.CommentResponse, int64, error) {
+	_, err := s.repo.GetPromoByID(ctx, promoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, 0, dto.ErrNotFound
@@ -113,7 +153,7 @@ func (s *promoService) GetComments(promoID, limitStr, offsetStr string) ([]dto.C
 		offset = 0
 	}
 
-	comments, totalCount, err := s.repo.GetComments(promoID, limit, offset)
+	comments, totalCount, err := s.repo.GetComments(ctx, promoID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -121,7 +161,7 @@ func (s *promoService) GetComments(promoID, limitStr, offsetStr string) ([]dto.C
 	// Формируем ответ
 	var response []dto.CommentResponse
 	for _, comment := range comments {
-		user, err := s.repo.GetUserByID(comment.UserID)
+		user, err := s.repo.GetUserByID(ctx, comment.UserID)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -144,8 +184,8 @@ func (s *promoService) GetComments(promoID, limitStr, offsetStr string) ([]dto.C
 }
 
 // GetComment получает конкретный комментарий по его ID
-func (s *promoService) GetComment(promoID, commentID string) (*dto.CommentResponse, error) {
-	_, err := s.repo.GetPromoByID(promoID)
+func (s *promoService) GetComment(ctx context.Context, promoID, commentID string) (*dto.CommentResponse, error) {
+	_, err := s.repo.GetPromoByID(ctx, promoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrNotFound
@@ -153,7 +193,7 @@ func (s *promoService) GetComment(promoID, commentID string) (*dto.CommentRespon
 		return nil, err
 	}
 
-	comment, err := s.repo.GetCommentByID(commentID)
+	comment, err := s.repo.GetCommentByID(ctx, commentID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrNotFound
@@ -167,7 +207,7 @@ func (s *promoService) GetComment(promoID, commentID string) (*dto.CommentRespon
 	}
 
 	// Получаем данные пользователя для ответа
-	user, err := s.repo.GetUserByID(comment.UserID)
+	user, err := s.repo.GetUserByID(ctx, comment.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -187,9 +227,9 @@ func (s *promoService) GetComment(promoID, commentID string) (*dto.CommentRespon
 }
 
 // EditComment редактирует текст комментария
-func (s *promoService) EditComment(userID, promoID, commentID, text string) (*dto.CommentResponse, error) {
+func (s *promoService) EditComment(ctx context.Context, userID, promoID, commentID, text string) (*dto.CommentResponse, error) {
 	// Проверяем, что промокод существует
-	_, err := s.repo.GetPromoByID(promoID)
+	_, err := s.repo.GetPromoByID(ctx, promoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrNotFound
@@ -197,7 +237,7 @@ func (s *promoService) EditComment(userID, promoID, commentID, text string) (*dt
 		return nil, err
 	}
 
-	comment, err := s.repo.GetCommentByID(commentID)
+	comment, err := s.repo.GetCommentByID(ctx, commentID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, dto.ErrNotFound
@@ -218,13 +258,13 @@ func (s *promoService) EditComment(userID, promoID, commentID, text string) (*dt
 	// Обновляем текст комментария
 	comment.Text = text
 
-	err = s.repo.UpdateComment(comment)
+	err = s.repo.UpdateComment(ctx, comment)
 	if err != nil {
 		return nil, err
 	}
 
 	// Получаем данные пользователя для ответа
-	user, err := s.repo.GetUserByID(userID)
+	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -244,9 +284,9 @@ func (s *promoService) EditComment(userID, promoID, commentID, text string) (*dt
 }
 
 // DeleteComment удаляет комментарий
-func (s *promoService) DeleteComment(userID, promoID, commentID string) error {
+func (s *promoService) DeleteComment(ctx context.Context, userID, promoID, commentID string) error {
 	// Проверяем, что промокод существует
-	_, err := s.repo.GetPromoByID(promoID)
+	_, err := s.repo.GetPromoByID(ctx, promoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return dto.ErrNotFound
@@ -254,7 +294,7 @@ func (s *promoService) DeleteComment(userID, promoID, commentID string) error {
 		return err
 	}
 
-	comment, err := s.repo.GetCommentByID(commentID)
+	comment, err := s.repo.GetCommentByID(ctx, commentID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return dto.ErrNotFound
@@ -272,7 +312,7 @@ func (s *promoService) DeleteComment(userID, promoID, commentID string) error {
 		return dto.ErrNoAccess
 	}
 
-	err = s.repo.DeleteComment(commentID)
+	err = s.repo.DeleteComment(ctx, commentID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,55 @@
+package b2c
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"solution/internal/repository/memory"
+	"solution/internal/shared/metrics"
+	"solution/internal/shared/models"
+	b2b_dto "solution/internal/shared/models/b2b/dto"
+)
+
+func TestLikePromoCountsNewLikes(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	userID := createUser(t, store)
+	companyID, err := memory.NewB2BAuthRepository(store).CreateCompany(ctx, b2b_dto.SignUpRequest{Name: "Company", Email: "company@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	promoID, err := memory.NewB2BPromoRepository(store).CreatePromo(ctx, b2b_dto.PromoCreateRequest{
+		CompanyID:   companyID,
+		Description: "Promo description",
+		Mode:        "COMMON",
+		PromoCommon: "CODE",
+		Target:      &models.Target{},
+		MaxCount:    intPtr(10),
+	}, models.Actor{Type: models.ActorCompany, ID: companyID})
+	if err != nil {
+		t.Fatalf("create promo: %v", err)
+	}
+	service := NewPromoService(memory.NewB2CPromoRepository(store), nil, nil)
+
+	before := testutil.ToFloat64(metrics.PromoLikes)
+	for i := 0; i < 3; i++ {
+		if err := service.LikePromo(ctx, promoID, userID); err != nil {
+			t.Fatalf("LikePromo() error = %v", err)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.PromoLikes) - before; got != 1 {
+		t.Fatalf("promo_likes_total grew by %v, want 1: repeated likes must not be counted", got)
+	}
+
+	// лайк после снятия снова добавляет строку
+	if err := service.UnlikePromo(ctx, promoID, userID); err != nil {
+		t.Fatalf("UnlikePromo() error = %v", err)
+	}
+	if err := service.LikePromo(ctx, promoID, userID); err != nil {
+		t.Fatalf("LikePromo() error = %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.PromoLikes) - before; got != 2 {
+		t.Fatalf("promo_likes_total grew by %v, want 2", got)
+	}
+}
@@ -0,0 +1,59 @@
+package b2c
+
+import (
+	"context"
+	"log/slog"
+	repo "solution/internal/repository/b2c"
+	"solution/internal/shared/config"
+	"time"
+)
+
+type RankingService interface {
+	RefreshScores(ctx context.Context) error
+	Run(ctx context.Context)
+}
+
+type rankingService struct {
+	repo    repo.RankingRepository
+	ranking *config.Ranking
+}
+
+func NewRankingService(repo repo.RankingRepository, ranking *config.Ranking) RankingService {
+	return &rankingService{repo: repo, ranking: ranking}
+}
+
+// RefreshScores пересчитывает скоры промокодов для всех сегментов пользователей и удаляет устаревшие
+func (s *rankingService) RefreshScores(ctx context.Context) error {
+	computedAt := time.Now().UTC()
+
+	segments, err := s.repo.GetUserSegments(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if err := s.repo.RefreshSegmentScores(ctx, segment, s.ranking, computedAt); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.DeleteStaleScores(ctx, computedAt)
+}
+
+// Run пересчитывает скоры сразу и затем с интервалом из конфигурации, пока не отменён контекст
+func (s *rankingService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.ranking.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RefreshScores(ctx); err != nil {
+			slog.ErrorContext(ctx, "Error refreshing ranking scores", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type (
+	serviceA struct{ b *serviceB }
+	serviceB struct{ c *serviceC }
+	serviceC struct{ a *serviceA }
+	scopedD  struct{}
+)
+
+var errBoom = errors.New("boom")
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(ct *Container) error
+		want     []string
+	}{
+		{
+			name: "valid graph",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddSingleton(func(b *serviceB) *serviceA { return &serviceA{b: b} }),
+					ct.AddTransient(func(ctx context.Context) *serviceB { return &serviceB{} }),
+					ct.AddScoped(func(a *serviceA) *scopedD { return &scopedD{} }),
+				)
+			},
+		},
+		{
+			name: "cycle",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddSingleton(func(b *serviceB) *serviceA { return &serviceA{b: b} }),
+					ct.AddSingleton(func(c *serviceC) *serviceB { return &serviceB{c: c} }),
+					ct.AddSingleton(func(a *serviceA) *serviceC { return &serviceC{a: a} }),
+				)
+			},
+			want: []string{"dependency cycle: *services.serviceA -> *services.serviceB -> *services.serviceC -> *services.serviceA"},
+		},
+		{
+			name: "missing dependency",
+			register: func(ct *Container) error {
+				return ct.AddSingleton(func(b *serviceB) *serviceA { return &serviceA{b: b} })
+			},
+			want: []string{"service *services.serviceA: missing dependency *services.serviceB"},
+		},
+		{
+			name: "singleton depends on scoped",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddSingleton(func(d *scopedD) *serviceA { return &serviceA{} }),
+					ct.AddScoped(func() *scopedD { return &scopedD{} }),
+				)
+			},
+			want: []string{"singleton *services.serviceA depends on scoped service *services.scopedD"},
+		},
+		{
+			name: "singleton depends on scoped through transients",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddSingleton(func(b *serviceB) *serviceA { return &serviceA{b: b} }),
+					ct.AddTransient(func(c *serviceC) *serviceB { return &serviceB{c: c} }),
+					ct.AddTransient(func(d *scopedD) *serviceC { return &serviceC{} }),
+					ct.AddScoped(func() *scopedD { return &scopedD{} }),
+				)
+			},
+			want: []string{"singleton *services.serviceA depends on scoped service *services.scopedD via *services.serviceA -> *services.serviceB -> *services.serviceC -> *services.scopedD"},
+		},
+		{
+			name: "singleton depends on singleton with scoped dependency",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddSingleton(func(b *serviceB) *serviceA { return &serviceA{b: b} }),
+					ct.AddSingleton(func(d *scopedD) *serviceB { return &serviceB{} }),
+					ct.AddScoped(func() *scopedD { return &scopedD{} }),
+				)
+			},
+			want: []string{"singleton *services.serviceB depends on scoped service *services.scopedD"},
+		},
+		{
+			name: "all problems at once",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddSingleton(func(d *scopedD, b *serviceB) *serviceA { return &serviceA{} }),
+					ct.AddScoped(func() *scopedD { return &scopedD{} }),
+				)
+			},
+			want: []string{
+				"singleton *services.serviceA depends on scoped service *services.scopedD",
+				"service *services.serviceA: missing dependency *services.serviceB",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := NewContainer()
+			if err := tt.register(ct); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			err := ct.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetServiceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(ct *Container) error
+		want     string
+		cause    error
+	}{
+		{
+			name:     "not registered",
+			register: func(ct *Container) error { return nil },
+			want:     "cannot find service *services.serviceA",
+		},
+		{
+			name: "missing dependency",
+			register: func(ct *Container) error {
+				return ct.AddTransient(func(b *serviceB) *serviceA { return &serviceA{b: b} })
+			},
+			want: "cannot find service *services.serviceB required by *services.serviceA",
+		},
+		{
+			name: "cycle",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddTransient(func(b *serviceB) *serviceA { return &serviceA{b: b} }),
+					ct.AddTransient(func(c *serviceC) *serviceB { return &serviceB{c: c} }),
+					ct.AddTransient(func(a *serviceA) *serviceC { return &serviceC{a: a} }),
+				)
+			},
+			want: "dependency cycle: *services.serviceA -> *services.serviceB -> *services.serviceC -> *services.serviceA",
+		},
+		{
+			name: "constructor error",
+			register: func(ct *Container) error {
+				return ct.AddSingleton(func() (*serviceA, error) { return nil, errBoom })
+			},
+			want:  "create service *services.serviceA: boom",
+			cause: errBoom,
+		},
+		{
+			name: "dependency constructor error",
+			register: func(ct *Container) error {
+				return errors.Join(
+					ct.AddTransient(func(b *serviceB) *serviceA { return &serviceA{b: b} }),
+					ct.AddScoped(func() (*serviceB, error) { return nil, errBoom }),
+				)
+			},
+			want:  "create service *services.serviceA -> *services.serviceB: boom",
+			cause: errBoom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := NewContainer()
+			if err := tt.register(ct); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			// вторая попытка должна вернуть ту же ошибку: ошибка синглтона запоминается
+			for attempt := 0; attempt < 2; attempt++ {
+				var a *serviceA
+				err := ct.GetService(&a)
+				if err == nil {
+					t.Fatalf("GetService() = nil, want %q", tt.want)
+				}
+				if err.Error() != tt.want {
+					t.Errorf("GetService() = %q, want %q", err, tt.want)
+				}
+				if tt.cause != nil && !errors.Is(err, tt.cause) {
+					t.Errorf("GetService() = %v, want it to wrap %v", err, tt.cause)
+				}
+			}
+		})
+	}
+}
+
+func TestAddServiceRejectsInvalidFactory(t *testing.T) {
+	tests := []struct {
+		name    string
+		factory interface{}
+	}{
+		{name: "not a function", factory: &serviceA{}},
+		{name: "nil", factory: nil},
+		{name: "no result", factory: func() {}},
+		{name: "second result is not an error", factory: func() (*serviceA, int) { return nil, 0 }},
+		{name: "too many results", factory: func() (*serviceA, *serviceB, error) { return nil, nil, nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewContainer().AddSingleton(tt.factory); err == nil {
+				t.Errorf("AddSingleton(%T) = nil, want error", tt.factory)
+			}
+		})
+	}
+}
+
+func TestSingletonIsCreatedOnce(t *testing.T) {
+	ct := NewContainer()
+	calls := 0
+	if err := ct.AddSingleton(func() (*serviceA, error) { calls++; return &serviceA{}, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second *serviceA
+	if err := ct.GetService(&first); err != nil {
+		t.Fatal(err)
+	}
+	if err := ct.GetService(&second); err != nil {
+		t.Fatal(err)
+	}
+	if first != second || calls != 1 {
+		t.Errorf("singleton created %d times, same instance = %v", calls, first == second)
+	}
+}
@@ -2,19 +2,79 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"reflect"
+	"sync"
 )
 
 type ServiceKeyType string
 
 const ServiceKey ServiceKeyType = "services"
 
-type serviceMap map[reflect.Type]reflect.Value
+// serviceScope хранит scoped сервисы одного контекста
+type serviceScope struct {
+	mu       sync.Mutex
+	services map[reflect.Type]reflect.Value
+	order    []reflect.Type
+}
+
+func (s *serviceScope) get(serviceType reflect.Type) (reflect.Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	service, ok := s.services[serviceType]
+	return service, ok
+}
+
+// put сохраняет сервис, если его ещё нет, и возвращает сохранённый экземпляр
+func (s *serviceScope) put(serviceType reflect.Type, service reflect.Value) reflect.Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.services[serviceType]; ok {
+		return existing
+	}
+	s.services[serviceType] = service
+	s.order = append(s.order, serviceType)
+	return service
+}
+
+// close закрывает созданные в области сервисы, реализующие io.Closer, в обратном порядке создания
+func (s *serviceScope) close() error {
+	s.mu.Lock()
+	services, order := s.services, s.order
+	s.services, s.order = make(map[reflect.Type]reflect.Value), nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(order) - 1; i >= 0; i-- {
+		closer, ok := services[order[i]].Interface().(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %v: %w", order[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
 func NewServiceContext(c context.Context) context.Context {
 	if c.Value(ServiceKey) == nil {
-		return context.WithValue(c, ServiceKey, make(serviceMap))
+		return context.WithValue(c, ServiceKey, &serviceScope{services: make(map[reflect.Type]reflect.Value)})
 	} else {
 		return c
 	}
 }
+
+// CloseServiceContext освобождает scoped сервисы контекста, созданного NewServiceContext.
+// Для контекста без области ничего не делает.
+func CloseServiceContext(c context.Context) error {
+	scope, ok := c.Value(ServiceKey).(*serviceScope)
+	if !ok {
+		return nil
+	}
+	return scope.close()
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// closeLog - журнал закрытия scoped сервисов в порядке вызова Close
+type closeLog []string
+
+type (
+	scopedFirst  struct{ log *closeLog }
+	scopedSecond struct {
+		log   *closeLog
+		first *scopedFirst
+	}
+)
+
+func (s *scopedFirst) Close() error {
+	*s.log = append(*s.log, "first")
+	return nil
+}
+
+func (s *scopedSecond) Close() error {
+	*s.log = append(*s.log, "second")
+	return errBoom
+}
+
+func newScopedContainer(t *testing.T, log *closeLog) *Container {
+	t.Helper()
+
+	ct := NewContainer()
+	err := errors.Join(
+		ct.AddScoped(func() *scopedFirst { return &scopedFirst{log: log} }),
+		ct.AddScoped(func(first *scopedFirst) *scopedSecond { return &scopedSecond{log: log, first: first} }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ct
+}
+
+func TestScopedServicesAreSharedWithinScope(t *testing.T) {
+	var log closeLog
+	ct := newScopedContainer(t, &log)
+
+	ctx := NewServiceContext(context.Background())
+	if NewServiceContext(ctx) != ctx {
+		t.Error("NewServiceContext created a nested scope")
+	}
+
+	var first, again *scopedFirst
+	var second *scopedSecond
+	for _, target := range []interface{}{&first, &second, &again} {
+		if err := ct.GetServiceForContext(ctx, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first != again || second.first != first {
+		t.Error("scoped service resolved twice within one scope")
+	}
+
+	var other *scopedFirst
+	if err := ct.GetServiceForContext(NewServiceContext(context.Background()), &other); err != nil {
+		t.Fatal(err)
+	}
+	if other == first {
+		t.Error("scoped service shared between scopes")
+	}
+}
+
+func TestCloseServiceContext(t *testing.T) {
+	var log closeLog
+	ct := newScopedContainer(t, &log)
+
+	ctx := NewServiceContext(context.Background())
+	var second *scopedSecond
+	if err := ct.GetServiceForContext(ctx, &second); err != nil {
+		t.Fatal(err)
+	}
+
+	err := CloseServiceContext(ctx)
+	if !errors.Is(err, errBoom) || !strings.Contains(err.Error(), "close *services.scopedSecond") {
+		t.Errorf("CloseServiceContext() = %v, want close error of *services.scopedSecond", err)
+	}
+	// закрываются в обратном порядке создания: зависимость создана первой
+	if got := strings.Join(log, ","); got != "second,first" {
+		t.Errorf("close order = %q, want %q", got, "second,first")
+	}
+
+	// повторное закрытие ничего не делает
+	if err := CloseServiceContext(ctx); err != nil {
+		t.Errorf("second CloseServiceContext() = %v, want nil", err)
+	}
+	if len(log) != 2 {
+		t.Errorf("services closed %d times, want 2", len(log))
+	}
+
+	if err := CloseServiceContext(context.Background()); err != nil {
+		t.Errorf("CloseServiceContext() without scope = %v, want nil", err)
+	}
+}
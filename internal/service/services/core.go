@@ -4,79 +4,188 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 )
 
 type BindingMap struct {
 	factoryFunc reflect.Value
 	lifecycle
+
+	// состояние синглтона: создаётся один раз, ошибка создания тоже запоминается
+	mu       sync.Mutex
+	resolved bool
+	instance reflect.Value
+	err      error
+}
+
+// Container - реестр сервисов со своими фабриками и синглтонами
+type Container struct {
+	mu       sync.RWMutex
+	services map[reflect.Type]*BindingMap
 }
 
-var services = make(map[reflect.Type]BindingMap)
+func NewContainer() *Container {
+	return &Container{services: make(map[reflect.Type]*BindingMap)}
+}
 
 // Add new service to service map
-func addService(life lifecycle, factoryFunc interface{}) (err error) {
+func (ct *Container) addService(life lifecycle, factoryFunc interface{}) error {
 	factoryFuncType := reflect.TypeOf(factoryFunc)
 
-	// factory function type checking, output should be 1
-	if factoryFuncType.Kind() == reflect.Func && factoryFuncType.NumOut() == 1 {
-		// okay, it's factory function
-		services[factoryFuncType.Out(0)] = BindingMap{
-			// service => factory function + lifecycle
-			factoryFunc: reflect.ValueOf(factoryFunc),
-			lifecycle:   life,
-		}
-	} else {
-		err = fmt.Errorf("type cannot be used as service: %v", factoryFuncType)
+	// фабрика возвращает сервис и, необязательно, ошибку создания
+	if factoryFuncType == nil || factoryFuncType.Kind() != reflect.Func || !validFactoryOutputs(factoryFuncType) {
+		return fmt.Errorf("type cannot be used as service: %v", factoryFuncType)
+	}
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	// service => factory function + lifecycle
+	ct.services[factoryFuncType.Out(0)] = &BindingMap{
+		factoryFunc: reflect.ValueOf(factoryFunc),
+		lifecycle:   life,
+	}
+	return nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func validFactoryOutputs(factoryFuncType reflect.Type) bool {
+	switch factoryFuncType.NumOut() {
+	case 1:
+		return true
+	case 2:
+		return factoryFuncType.Out(1) == errorType
+	default:
+		return false
 	}
-	return
+}
+
+func (ct *Container) binding(serviceType reflect.Type) (*BindingMap, bool) {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+
+	binding, found := ct.services[serviceType]
+	return binding, found
 }
 
 var contextReference = (*context.Context)(nil)
 var contextReferenceType = reflect.TypeOf(contextReference).Elem()
 
-func resolveServiceFromValue(c context.Context, val reflect.Value) (err error) {
+// resolution - цепочка разрешаемых сервисов, нужна для обнаружения циклов
+type resolution []reflect.Type
+
+func (r resolution) contains(t reflect.Type) bool {
+	for _, resolved := range r {
+		if resolved == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (r resolution) String() string {
+	names := make([]string, len(r))
+	for i, t := range r {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+func (ct *Container) resolveServiceFromValue(c context.Context, val reflect.Value, path resolution) error {
 	serviceType := val.Elem().Type()
 
 	if serviceType == contextReferenceType {
 		val.Elem().Set(reflect.ValueOf(c))
-	} else if binding, found := services[serviceType]; found {
-		// find service by service type from services map
-		if binding.lifecycle == Scoped {
-			resolveScopedService(c, val, binding)
-		} else {
-			val.Elem().Set(invokeFunction(c, binding.factoryFunc)[0])
+		return nil
+	}
+
+	binding, found := ct.binding(serviceType)
+	if !found {
+		if len(path) > 0 {
+			return fmt.Errorf("cannot find service %v required by %v", serviceType, path)
 		}
-	} else {
-		err = fmt.Errorf("cannot find service %v", serviceType)
+		return fmt.Errorf("cannot find service %v", serviceType)
+	}
+
+	if path.contains(serviceType) {
+		return fmt.Errorf("dependency cycle: %v", append(path, serviceType))
+	}
+	path = append(path, serviceType)
+
+	var (
+		service reflect.Value
+		err     error
+	)
+	switch binding.lifecycle {
+	case Singleton:
+		service, err = ct.resolveSingletonService(binding, path)
+	case Scoped:
+		service, err = ct.resolveScopedService(c, serviceType, binding, path)
+	default:
+		service, err = ct.invokeFactory(c, binding, path)
+	}
+	if err != nil {
+		return err
 	}
-	return
+
+	val.Elem().Set(service)
+	return nil
 }
 
-func resolveScopedService(c context.Context, val reflect.Value, binding BindingMap) (err error) {
-	sMap, ok := c.Value(ServiceKey).(serviceMap)
-	if ok {
-		serviceVal, ok := sMap[val.Type()]
-		if !ok {
-			serviceVal = invokeFunction(c, binding.factoryFunc)[0]
-			sMap[val.Type()] = serviceVal
-		}
-		val.Elem().Set(serviceVal)
-	} else {
-		val.Elem().Set(invokeFunction(c, binding.factoryFunc)[0])
+func (ct *Container) resolveSingletonService(binding *BindingMap, path resolution) (reflect.Value, error) {
+	binding.mu.Lock()
+	defer binding.mu.Unlock()
+
+	if !binding.resolved {
+		// синглтоны не зависят от контекста запроса
+		binding.instance, binding.err = ct.invokeFactory(context.Background(), binding, path)
+		binding.resolved = true
+	}
+	return binding.instance, binding.err
+}
+
+func (ct *Container) resolveScopedService(c context.Context, serviceType reflect.Type, binding *BindingMap, path resolution) (reflect.Value, error) {
+	scope, ok := c.Value(ServiceKey).(*serviceScope)
+	if !ok {
+		return ct.invokeFactory(c, binding, path)
 	}
-	return
+
+	if service, ok := scope.get(serviceType); ok {
+		return service, nil
+	}
+
+	service, err := ct.invokeFactory(c, binding, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return scope.put(serviceType, service), nil
+}
+
+func (ct *Container) invokeFactory(c context.Context, binding *BindingMap, path resolution) (reflect.Value, error) {
+	results, err := ct.invokeFunction(c, binding.factoryFunc, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if len(results) == 2 && !results[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("create service %v: %w", path, results[1].Interface().(error))
+	}
+	return results[0], nil
 }
 
-func resolveFunctionArguments(c context.Context, f reflect.Value, othersArgs ...interface{}) []reflect.Value {
+func (ct *Container) resolveFunctionArguments(c context.Context, f reflect.Value, path resolution, othersArgs ...interface{}) ([]reflect.Value, error) {
 	// make parameter slice from function type
 	params := make([]reflect.Value, f.Type().NumIn())
 
+	if len(othersArgs) > len(params) {
+		return nil, fmt.Errorf("too many arguments for %v: got %d", f.Type(), len(othersArgs))
+	}
+
 	i := 0
-	if othersArgs != nil {
-		// map otherArgs array into params (reflect value slice)
-		for ; i < len(othersArgs); i++ {
-			params[i] = reflect.ValueOf(othersArgs[i])
-		}
+	// map otherArgs array into params (reflect value slice)
+	for ; i < len(othersArgs); i++ {
+		params[i] = reflect.ValueOf(othersArgs[i])
 	}
 
 	// got params => reflect value slice
@@ -85,16 +194,19 @@ func resolveFunctionArguments(c context.Context, f reflect.Value, othersArgs ...
 		pVal := reflect.New(pType)
 
 		// get arguments from services
-		err := resolveServiceFromValue(c, pVal)
-		if err != nil {
-			panic(err)
+		if err := ct.resolveServiceFromValue(c, pVal, path); err != nil {
+			return nil, err
 		}
 		params[i] = pVal.Elem()
 	}
-	return params
+	return params, nil
 }
 
-func invokeFunction(c context.Context, f reflect.Value, otherArgs ...interface{}) []reflect.Value {
+func (ct *Container) invokeFunction(c context.Context, f reflect.Value, path resolution, otherArgs ...interface{}) ([]reflect.Value, error) {
+	params, err := ct.resolveFunctionArguments(c, f, path, otherArgs...)
+	if err != nil {
+		return nil, err
+	}
 	// call function with arguments
-	return f.Call(resolveFunctionArguments(c, f, otherArgs...))
+	return f.Call(params), nil
 }
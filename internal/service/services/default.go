@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"reflect"
+)
+
+// defaultContainer используется функциями уровня пакета
+var defaultContainer = NewContainer()
+
+// Default возвращает контейнер, с которым работают функции уровня пакета
+func Default() *Container {
+	return defaultContainer
+}
+
+func AddTransient(factoryFunc interface{}) error {
+	return defaultContainer.AddTransient(factoryFunc)
+}
+
+func AddScoped(factoryFunc interface{}) error {
+	return defaultContainer.AddScoped(factoryFunc)
+}
+
+func AddSingleton(factoryFunc interface{}) error {
+	return defaultContainer.AddSingleton(factoryFunc)
+}
+
+func GetService(target interface{}) error {
+	return defaultContainer.GetService(target)
+}
+
+func GetServiceForContext(c context.Context, target interface{}) error {
+	return defaultContainer.GetServiceForContext(c, target)
+}
+
+func Populate(target interface{}) error {
+	return defaultContainer.Populate(target)
+}
+
+func PopulateForContext(c context.Context, target interface{}) error {
+	return defaultContainer.PopulateForContext(c, target)
+}
+
+func PopulateForContextWithExtras(c context.Context, target interface{},
+	extras map[reflect.Type]reflect.Value) error {
+	return defaultContainer.PopulateForContextWithExtras(c, target, extras)
+}
+
+func Call(target interface{}, otherArgs ...interface{}) ([]interface{}, error) {
+	return defaultContainer.Call(target, otherArgs...)
+}
+
+func CallForContext(c context.Context, target interface{}, otherArgs ...interface{}) ([]interface{}, error) {
+	return defaultContainer.CallForContext(c, target, otherArgs...)
+}
+
+func Validate() error {
+	return defaultContainer.Validate()
+}
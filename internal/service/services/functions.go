@@ -6,20 +6,24 @@ import (
 	"reflect"
 )
 
-func Call(target interface{}, otherArgs ...interface{}) ([]interface{}, error) {
-	return CallForContext(context.Background(), target, otherArgs...)
+func (ct *Container) Call(target interface{}, otherArgs ...interface{}) ([]interface{}, error) {
+	return ct.CallForContext(context.Background(), target, otherArgs...)
 }
 
-func CallForContext(c context.Context, target interface{}, otherArgs ...interface{}) (results []interface{}, err error) {
+func (ct *Container) CallForContext(c context.Context, target interface{}, otherArgs ...interface{}) ([]interface{}, error) {
 	targetValue := reflect.ValueOf(target)
-	if targetValue.Kind() == reflect.Func {
-		resultVals := invokeFunction(c, targetValue, otherArgs...)
-		results = make([]interface{}, len(resultVals))
-		for i := 0; i < len(resultVals); i++ {
-			results[i] = resultVals[i].Interface()
-		}
-	} else {
-		err = errors.New("only functions can be invoked")
+	if targetValue.Kind() != reflect.Func {
+		return nil, errors.New("only functions can be invoked")
 	}
-	return
+
+	resultVals, err := ct.invokeFunction(c, targetValue, nil, otherArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, len(resultVals))
+	for i := 0; i < len(resultVals); i++ {
+		results[i] = resultVals[i].Interface()
+	}
+	return results, nil
 }
@@ -1,32 +1,13 @@
 package services
 
-import (
-	"context"
-	"reflect"
-	"sync"
-)
-
-func AddTransient(factoryFunc interface{}) (err error) {
-	return addService(Transient, factoryFunc)
+func (ct *Container) AddTransient(factoryFunc interface{}) error {
+	return ct.addService(Transient, factoryFunc)
 }
 
-func AddScoped(factoryFunc interface{}) (err error) {
-	return addService(Scoped, factoryFunc)
+func (ct *Container) AddScoped(factoryFunc interface{}) error {
+	return ct.addService(Scoped, factoryFunc)
 }
 
-func AddSingleton(factoryFunc interface{}) (err error) {
-	factoryFuncVal := reflect.ValueOf(factoryFunc)
-	if factoryFuncVal.Kind() == reflect.Func && factoryFuncVal.Type().NumOut() == 1 {
-		var results []reflect.Value
-		once := sync.Once{}
-		wrapper := reflect.MakeFunc(factoryFuncVal.Type(),
-			func([]reflect.Value) []reflect.Value {
-				once.Do(func() {
-					results = invokeFunction(context.TODO(), factoryFuncVal)
-				})
-				return results
-			})
-		err = addService(Singleton, wrapper.Interface())
-	}
-	return
+func (ct *Container) AddSingleton(factoryFunc interface{}) error {
+	return ct.addService(Singleton, factoryFunc)
 }
@@ -6,16 +6,14 @@ import (
 	"reflect"
 )
 
-func GetService(target interface{}) error {
-	return GetServiceForContext(context.Background(), target)
+func (ct *Container) GetService(target interface{}) error {
+	return ct.GetServiceForContext(context.Background(), target)
 }
 
-func GetServiceForContext(c context.Context, target interface{}) (err error) {
+func (ct *Container) GetServiceForContext(c context.Context, target interface{}) error {
 	targetValue := reflect.ValueOf(target)
-	if targetValue.Kind() == reflect.Ptr && targetValue.Elem().CanSet() {
-		err = resolveServiceFromValue(c, targetValue)
-	} else {
-		err = errors.New("type cannot be used as target")
+	if targetValue.Kind() != reflect.Ptr || !targetValue.Elem().CanSet() {
+		return errors.New("type cannot be used as target")
 	}
-	return
+	return ct.resolveServiceFromValue(c, targetValue, nil)
 }
@@ -3,36 +3,38 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 )
 
-func Populate(target interface{}) error {
-	return PopulateForContext(context.Background(), target)
+func (ct *Container) Populate(target interface{}) error {
+	return ct.PopulateForContext(context.Background(), target)
 }
 
-func PopulateForContext(c context.Context, target interface{}) (err error) {
-	return PopulateForContextWithExtras(c, target,
+func (ct *Container) PopulateForContext(c context.Context, target interface{}) error {
+	return ct.PopulateForContextWithExtras(c, target,
 		make(map[reflect.Type]reflect.Value))
 }
 
-func PopulateForContextWithExtras(c context.Context, target interface{},
-	extras map[reflect.Type]reflect.Value) (err error) {
+func (ct *Container) PopulateForContextWithExtras(c context.Context, target interface{},
+	extras map[reflect.Type]reflect.Value) error {
 	targetValue := reflect.ValueOf(target)
-	if targetValue.Kind() == reflect.Ptr &&
-		targetValue.Elem().Kind() == reflect.Struct {
-		targetValue = targetValue.Elem()
-		for i := 0; i < targetValue.Type().NumField(); i++ {
-			fieldVal := targetValue.Field(i)
-			if fieldVal.CanSet() {
-				if extra, ok := extras[fieldVal.Type()]; ok {
-					fieldVal.Set(extra)
-				} else {
-					resolveServiceFromValue(c, fieldVal.Addr())
-				}
-			}
+	if targetValue.Kind() != reflect.Ptr ||
+		targetValue.Elem().Kind() != reflect.Struct {
+		return errors.New("type cannot be used as target")
+	}
+
+	targetValue = targetValue.Elem()
+	for i := 0; i < targetValue.Type().NumField(); i++ {
+		fieldVal := targetValue.Field(i)
+		if !fieldVal.CanSet() {
+			continue
+		}
+		if extra, ok := extras[fieldVal.Type()]; ok {
+			fieldVal.Set(extra)
+		} else if err := ct.resolveServiceFromValue(c, fieldVal.Addr(), nil); err != nil {
+			return fmt.Errorf("field %s: %w", targetValue.Type().Field(i).Name, err)
 		}
-	} else {
-		err = errors.New("type cannot be used as target")
 	}
-	return
+	return nil
 }
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Validate проверяет весь граф зависимостей без создания сервисов:
+// все аргументы фабрик зарегистрированы, нет циклов, синглтоны не зависят от scoped сервисов
+// ни напрямую, ни через transient сервисы.
+// Возвращает все найденные проблемы сразу.
+func (ct *Container) Validate() error {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+
+	// порядок обхода фиксирован, чтобы сообщения об ошибках были стабильными
+	types := make([]reflect.Type, 0, len(ct.services))
+	for serviceType := range ct.services {
+		types = append(types, serviceType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].String() < types[j].String() })
+
+	var errs []error
+	for _, serviceType := range types {
+		binding := ct.services[serviceType]
+		for _, dep := range dependencies(binding) {
+			if dep == contextReferenceType {
+				continue
+			}
+			depBinding, found := ct.services[dep]
+			if !found {
+				errs = append(errs, fmt.Errorf("service %v: missing dependency %v", serviceType, dep))
+				continue
+			}
+			if binding.lifecycle == Singleton && depBinding.lifecycle == Scoped {
+				errs = append(errs, fmt.Errorf("singleton %v depends on scoped service %v", serviceType, dep))
+			}
+			if binding.lifecycle == Singleton && depBinding.lifecycle == Transient {
+				if capture := ct.findScopedCapture(resolution{serviceType, dep}); capture != nil {
+					errs = append(errs, fmt.Errorf("singleton %v depends on scoped service %v via %v", serviceType, capture[len(capture)-1], capture))
+				}
+			}
+		}
+	}
+
+	// каждый цикл сообщается один раз, начиная с первого по порядку типа
+	visited := make(map[reflect.Type]bool)
+	for _, serviceType := range types {
+		if cycle := ct.findCycle(serviceType, nil, visited); cycle != nil {
+			errs = append(errs, fmt.Errorf("dependency cycle: %v", cycle))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// findScopedCapture ищет scoped сервис, который создаётся вместе с последним сервисом path через цепочку transient
+// сервисов. Transient создаётся заново при каждом разрешении, поэтому синглтон удержал бы такой scoped сервис навсегда.
+func (ct *Container) findScopedCapture(path resolution) resolution {
+	for _, dep := range dependencies(ct.services[path[len(path)-1]]) {
+		// циклы сообщаются отдельно
+		if dep == contextReferenceType || path.contains(dep) {
+			continue
+		}
+		depBinding, found := ct.services[dep]
+		if !found {
+			continue
+		}
+		switch depBinding.lifecycle {
+		case Scoped:
+			return append(path, dep)
+		case Transient:
+			if capture := ct.findScopedCapture(append(path, dep)); capture != nil {
+				return capture
+			}
+		}
+	}
+	return nil
+}
+
+func (ct *Container) findCycle(serviceType reflect.Type, path resolution, visited map[reflect.Type]bool) resolution {
+	if path.contains(serviceType) {
+		return append(path, serviceType)
+	}
+	if visited[serviceType] {
+		return nil
+	}
+	binding, found := ct.services[serviceType]
+	if !found {
+		return nil
+	}
+
+	path = append(path, serviceType)
+	for _, dep := range dependencies(binding) {
+		if cycle := ct.findCycle(dep, path, visited); cycle != nil {
+			visited[serviceType] = true
+			return cycle
+		}
+	}
+	visited[serviceType] = true
+	return nil
+}
+
+func dependencies(binding *BindingMap) []reflect.Type {
+	funcType := binding.factoryFunc.Type()
+	deps := make([]reflect.Type, funcType.NumIn())
+	for i := range deps {
+		deps[i] = funcType.In(i)
+	}
+	return deps
+}
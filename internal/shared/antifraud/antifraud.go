@@ -0,0 +1,131 @@
+// Package antifraud - клиент внешнего сервиса, который решает, может ли пользователь получить код промокода
+package antifraud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"solution/internal/shared/config"
+	"solution/internal/shared/storage/redis"
+)
+
+// attempts - запрос к антифроду повторяется один раз, если сервис не ответил
+const attempts = 2
+
+// Client проверяет активацию промокода пользователем
+type Client interface {
+	// Allow сообщает, разрешена ли активация; ошибка - антифрод не ответил и решение неизвестно
+	Allow(ctx context.Context, userEmail, promoID string) (bool, error)
+}
+
+// New возвращает клиент антифрода. Без antifraud.address проверка выключена и активации разрешены.
+func New(cfg *config.Antifraud, rdb *redis.RDB) Client {
+	if cfg.Address == "" {
+		return allowAll{}
+	}
+	return &client{
+		url:  "http://" + cfg.Address + "/api/validate",
+		http: &http.Client{Timeout: cfg.Timeout},
+		rdb:  rdb,
+	}
+}
+
+type allowAll struct{}
+
+func (allowAll) Allow(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+
+type client struct {
+	url  string
+	http *http.Client
+	rdb  *redis.RDB
+}
+
+type validateRequest struct {
+	UserEmail string `json:"user_email"`
+	PromoID   string `json:"promo_id"`
+}
+
+type validateResponse struct {
+	OK bool `json:"ok"`
+	// CacheUntil - до какого момента ответ можно не запрашивать заново
+	CacheUntil string `json:"cache_until"`
+}
+
+// Allow отвечает из кэша Redis, пока не наступил cache_until последнего ответа антифрода
+func (c *client) Allow(ctx context.Context, userEmail, promoID string) (bool, error) {
+	key := fmt.Sprintf("antifraud:%s:%s", userEmail, promoID)
+	cached, err := c.rdb.Client.Get(ctx, key).Bool()
+	if err == nil {
+		return cached, nil
+	}
+	if !errors.Is(err, goredis.Nil) {
+		slog.WarnContext(ctx, "Failed to read antifraud verdict from cache", "error", err)
+	}
+
+	var resp *validateResponse
+	for attempt := 1; attempt <= attempts; attempt++ {
+		resp, err = c.validate(ctx, validateRequest{UserEmail: userEmail, PromoID: promoID})
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		slog.WarnContext(ctx, "Antifraud request failed", "attempt", attempt, "error", err)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if until, ok := parseCacheUntil(resp.CacheUntil); ok {
+		if ttl := time.Until(until); ttl > 0 {
+			if err := c.rdb.Client.Set(ctx, key, resp.OK, ttl).Err(); err != nil {
+				slog.WarnContext(ctx, "Failed to cache antifraud verdict", "error", err)
+			}
+		}
+	}
+	return resp.OK, nil
+}
+
+func (c *client) validate(ctx context.Context, body validateRequest) (*validateResponse, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("antifraud responded with status %d", res.StatusCode)
+	}
+	var resp validateResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid antifraud response: %w", err)
+	}
+	return &resp, nil
+}
+
+// parseCacheUntil разбирает cache_until: антифрод присылает время без часового пояса, оно считается UTC
+func parseCacheUntil(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
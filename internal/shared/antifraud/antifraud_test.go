@@ -0,0 +1,118 @@
+package antifraud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"solution/internal/shared/config"
+	"solution/internal/shared/storage/redis/redistest"
+)
+
+// antifraudServer отвечает handler и считает запросы
+func antifraudServer(t *testing.T, handler func(w http.ResponseWriter, req validateRequest)) (*config.Antifraud, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method != http.MethodPost || r.URL.Path != "/api/validate" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req validateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		handler(w, req)
+	}))
+	t.Cleanup(server.Close)
+
+	return &config.Antifraud{Address: strings.TrimPrefix(server.URL, "http://"), Timeout: time.Second}, &calls
+}
+
+func TestAllowCachesVerdict(t *testing.T) {
+	ctx := context.Background()
+	rdb, _ := redistest.Open(t)
+	cacheUntil := time.Now().UTC().Add(time.Hour).Format("2006-01-02T15:04:05.000")
+	cfg, calls := antifraudServer(t, func(w http.ResponseWriter, req validateRequest) {
+		ok := req.UserEmail == "good@example.com"
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": ok, "cache_until": cacheUntil})
+	})
+	client := New(cfg, rdb)
+
+	for i := 0; i < 2; i++ {
+		if ok, err := client.Allow(ctx, "good@example.com", "promo"); err != nil || !ok {
+			t.Fatalf("Allow(good) = %v, %v, want true", ok, err)
+		}
+		if ok, err := client.Allow(ctx, "bad@example.com", "promo"); err != nil || ok {
+			t.Fatalf("Allow(bad) = %v, %v, want false", ok, err)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("antifraud requests = %d, want 2: repeated checks must use the cache", got)
+	}
+}
+
+func TestAllowWithoutCacheUntil(t *testing.T) {
+	rdb, _ := redistest.Open(t)
+	cfg, calls := antifraudServer(t, func(w http.ResponseWriter, req validateRequest) {
+		w.Write([]byte(`{"ok": true}`))
+	})
+	client := New(cfg, rdb)
+
+	for i := 0; i < 2; i++ {
+		if ok, err := client.Allow(context.Background(), "user@example.com", "promo"); err != nil || !ok {
+			t.Fatalf("Allow() = %v, %v, want true", ok, err)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("antifraud requests = %d, want 2", got)
+	}
+}
+
+func TestAllowRetriesOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		wantOK    bool
+		wantErr   bool
+		wantCalls int32
+	}{
+		{name: "first attempt", failures: 0, wantOK: true, wantCalls: 1},
+		{name: "retry succeeds", failures: 1, wantOK: true, wantCalls: 2},
+		{name: "retry fails", failures: 2, wantErr: true, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdb, _ := redistest.Open(t)
+			var served atomic.Int32
+			cfg, calls := antifraudServer(t, func(w http.ResponseWriter, req validateRequest) {
+				if served.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Write([]byte(`{"ok": true}`))
+			})
+
+			ok, err := New(cfg, rdb).Allow(context.Background(), "user@example.com", "promo")
+			if (err != nil) != tt.wantErr || ok != tt.wantOK {
+				t.Fatalf("Allow() = %v, %v, want %v, error %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("antifraud requests = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAllowWithoutAddress(t *testing.T) {
+	ok, err := New(&config.Antifraud{Timeout: time.Second}, nil).Allow(context.Background(), "user@example.com", "promo")
+	if err != nil || !ok {
+		t.Fatalf("Allow() = %v, %v, want true without antifraud address", ok, err)
+	}
+}
@@ -0,0 +1,128 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Code - машиночитаемый код ошибки, однозначно задаёт HTTP статус
+type Code string
+
+const (
+	CodeBadRequest         Code = "bad_request"
+	CodeValidation         Code = "validation_failed"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeConflict           Code = "conflict"
+	CodePreconditionFailed Code = "precondition_failed"
+	CodeUnavailable        Code = "unavailable"
+	CodeInternal           Code = "internal"
+)
+
+// Коды конфликтов при изменении кодов промокода: клиенту важно отличать их друг от друга
+const (
+	CodePromoCodeUsed     Code = "promo_code_used"
+	CodePromoCodeIssued   Code = "promo_code_issued"
+	CodePromoModeLocked   Code = "promo_mode_locked"
+	CodePromoCodeNotFound Code = "promo_code_not_found"
+)
+
+// Коды отказа в активации промокода: все дают 403, код объясняет причину
+const (
+	CodePromoInactive        Code = "promo_inactive"
+	CodePromoExhausted       Code = "promo_exhausted"
+	CodePromoNotTargeted     Code = "promo_not_targeted"
+	CodeActivationDenied     Code = "activation_denied"
+	CodeActivationLimit      Code = "activation_limit_reached"
+	CodeActivationCooldown   Code = "activation_cooldown"
+	CodeActivationDailyLimit Code = "activation_daily_limit"
+)
+
+var statuses = map[Code]int{
+	CodeBadRequest:         http.StatusBadRequest,
+	CodeValidation:         http.StatusBadRequest,
+	CodeUnauthorized:       http.StatusUnauthorized,
+	CodeForbidden:          http.StatusForbidden,
+	CodeNotFound:           http.StatusNotFound,
+	CodeConflict:           http.StatusConflict,
+	CodePreconditionFailed: http.StatusPreconditionFailed,
+	CodeUnavailable:        http.StatusServiceUnavailable,
+	CodeInternal:           http.StatusInternalServerError,
+	CodePromoCodeUsed:      http.StatusConflict,
+	CodePromoCodeIssued:    http.StatusConflict,
+	CodePromoModeLocked:    http.StatusConflict,
+	CodePromoCodeNotFound:  http.StatusConflict,
+
+	CodePromoInactive:        http.StatusForbidden,
+	CodePromoExhausted:       http.StatusForbidden,
+	CodePromoNotTargeted:     http.StatusForbidden,
+	CodeActivationDenied:     http.StatusForbidden,
+	CodeActivationLimit:      http.StatusForbidden,
+	CodeActivationCooldown:   http.StatusForbidden,
+	CodeActivationDailyLimit: http.StatusForbidden,
+}
+
+// Status возвращает HTTP статус кода, неизвестные коды считаются внутренней ошибкой
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error - ошибка приложения. Message отдаётся клиенту, Err - только в логи.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []*FieldError
+	Err     error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap сохраняет причину: errors.Is по-прежнему находит доменные ошибки внутри
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+var (
+	ErrBadRequest         = New(CodeBadRequest, "Ошибка в данных запроса.")
+	ErrUnauthorized       = New(CodeUnauthorized, "Пользователь не авторизован.")
+	ErrForbidden          = New(CodeForbidden, "Недостаточно прав для выполнения запроса.")
+	ErrNotFound           = New(CodeNotFound, "Ресурс не найден.")
+	ErrPreconditionFailed = New(CodePreconditionFailed, "Ресурс был изменён, получите актуальную версию.")
+	ErrInternal           = New(CodeInternal, "Ошибка сервера.")
+	ErrUnavailable        = New(CodeUnavailable, "Сервис временно недоступен.")
+)
+
+// From приводит произвольную ошибку к *Error. Ошибки без кода считаются внутренними,
+// их текст клиенту не показывается.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Wrap(ErrUnavailable.Code, ErrUnavailable.Message, err)
+	}
+	return Wrap(ErrInternal.Code, ErrInternal.Message, err)
+}
@@ -0,0 +1,104 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError - ошибка значения конкретного поля запроса. Field - путь в JSON через точку.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+
+	cause error
+}
+
+func Field(field, message string) *FieldError {
+	return &FieldError{Field: field, Message: message}
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.cause
+}
+
+// Nest добавляет префикс к путям полей, например ошибки Target внутри промокода получают "target."
+func Nest(prefix string, err error) error {
+	if err == nil {
+		return nil
+	}
+	fields := collectFields(err)
+	nested := make([]error, 0, len(fields))
+	for _, field := range fields {
+		path := prefix
+		if field.Field != "" {
+			path = prefix + "." + field.Field
+		}
+		nested = append(nested, &FieldError{Field: path, Message: field.Message, cause: field})
+	}
+	if len(nested) == 1 {
+		return nested[0]
+	}
+	return errors.Join(nested...)
+}
+
+// Validation оборачивает ошибку Validate() DTO, сохраняя ошибки по полям
+func Validation(err error) *Error {
+	return &Error{
+		Code:    CodeValidation,
+		Message: ErrBadRequest.Message,
+		Fields:  collectFields(err),
+		Err:     err,
+	}
+}
+
+// Binding оборачивает ошибку разбора тела запроса; для несовпадения типов указывается поле
+func Binding(err error) *Error {
+	appErr := Wrap(CodeBadRequest, ErrBadRequest.Message, err)
+
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			appErr.Fields = append(appErr.Fields, Field(fieldPath(fe.Namespace()), fmt.Sprintf("failed on the '%s' rule", fe.Tag())))
+		}
+	case errors.As(err, &typeErr):
+		appErr.Fields = []*FieldError{Field(typeErr.Field, fmt.Sprintf("must be %s", typeErr.Type))}
+	case errors.As(err, &syntaxErr):
+		appErr.Fields = []*FieldError{Field("", "malformed JSON")}
+	}
+	return appErr
+}
+
+// fieldPath убирает имя корневой структуры из пути валидатора: "SignUpRequest.other.age" -> "other.age"
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// collectFields разворачивает errors.Join и оборачивания до отдельных ошибок полей
+func collectFields(err error) []*FieldError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var fields []*FieldError
+		for _, e := range joined.Unwrap() {
+			fields = append(fields, collectFields(e)...)
+		}
+		return fields
+	}
+	var field *FieldError
+	if errors.As(err, &field) {
+		return []*FieldError{field}
+	}
+	return []*FieldError{{Message: err.Error(), cause: err}}
+}
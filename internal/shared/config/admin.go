@@ -0,0 +1,18 @@
+package config
+
+import "fmt"
+
+// Admin - учётная запись первого администратора. Регистрации администраторов нет:
+// если администратора с таким email ещё нет, он создаётся при старте.
+type Admin struct {
+	Email    string `yaml:"email" env:"ADMIN_EMAIL"`
+	Password string `yaml:"password" env:"ADMIN_PASSWORD" secret:"true"`
+}
+
+func (a *Admin) validate() []error {
+	var errs []error
+	if (a.Email == "") != (a.Password == "") {
+		errs = append(errs, fmt.Errorf("admin.email and admin.password must be set together"))
+	}
+	return errs
+}
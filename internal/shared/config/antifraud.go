@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Antifraud - внешний сервис проверки активаций промокодов
+type Antifraud struct {
+	Address string        `yaml:"address" env:"ANTIFRAUD_ADDRESS"`
+	Timeout time.Duration `yaml:"timeout" env:"ANTIFRAUD_TIMEOUT" default:"2s"`
+}
+
+func (a *Antifraud) validate() []error {
+	var errs []error
+	if a.Address != "" {
+		if err := validateAddress(a.Address); err != nil {
+			errs = append(errs, fmt.Errorf("antifraud.address: %w", err))
+		}
+	}
+	if a.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("antifraud.timeout must be positive"))
+	}
+	return errs
+}
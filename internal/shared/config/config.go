@@ -1,30 +1,46 @@
 package config
 
+import (
+	"errors"
+	"os"
+)
+
+// Config - итоговая конфигурация приложения.
+// Источники в порядке приоритета: флаги командной строки, переменные окружения, YAML файл, значения по умолчанию.
 type Config struct {
-	Postgres *Postgres
-	Redis    *Redis
-	Server   *Server
+	Server     *Server     `yaml:"server"`
+	Postgres   *Postgres   `yaml:"postgres"`
+	Redis      *Redis      `yaml:"redis"`
+	Auth       *Auth       `yaml:"auth"`
+	Antifraud  *Antifraud  `yaml:"antifraud"`
+	Ranking    *Ranking    `yaml:"ranking"`
+	Logging    *Logging    `yaml:"logging"`
+	Tracing    *Tracing    `yaml:"tracing"`
+	Health     *Health     `yaml:"health"`
+	Validation *Validation `yaml:"validation"`
+	OpenAPI    *OpenAPI    `yaml:"openapi"`
+	Admin      *Admin      `yaml:"admin"`
 }
 
+// Init загружает конфигурацию из аргументов процесса и окружения
 func Init() (*Config, error) {
-	postgresConfig, err := getPostgres()
-	if err != nil {
-		return nil, err
-	}
-
-	redisCfg, err := getRedis()
-	if err != nil {
-		return nil, err
-	}
-
-	serverCfg, err := getServer()
-	if err != nil {
-		return nil, err
-	}
+	return Load(os.Args[1:])
+}
 
-	return &Config{
-		Postgres: postgresConfig,
-		Redis:    redisCfg,
-		Server:   serverCfg,
-	}, nil
+// Validate проверяет все секции и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, c.Server.validate()...)
+	errs = append(errs, c.Postgres.validate()...)
+	errs = append(errs, c.Redis.validate()...)
+	errs = append(errs, c.Auth.validate()...)
+	errs = append(errs, c.Antifraud.validate()...)
+	errs = append(errs, c.Ranking.validate()...)
+	errs = append(errs, c.Logging.validate()...)
+	errs = append(errs, c.Tracing.validate()...)
+	errs = append(errs, c.Health.validate()...)
+	errs = append(errs, c.Validation.validate()...)
+	errs = append(errs, c.OpenAPI.validate()...)
+	errs = append(errs, c.Admin.validate()...)
+	return errors.Join(errs...)
 }
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Health - проверки для /healthz и /readyz
+type Health struct {
+	// CheckTimeout ограничивает каждую проверку готовности
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	// AntifraudOptional - недоступность антифрода отражается в ответе, но не снимает готовность
+	AntifraudOptional bool `yaml:"antifraud_optional" env:"HEALTH_ANTIFRAUD_OPTIONAL" default:"false"`
+	// DrainDelay - сколько /readyz отвечает 503 перед остановкой сервера, чтобы балансировщик успел убрать под
+	DrainDelay time.Duration `yaml:"drain_delay" env:"HEALTH_DRAIN_DELAY" default:"0s"`
+}
+
+func (h *Health) validate() []error {
+	var errs []error
+	if h.CheckTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("health.check_timeout must be positive"))
+	}
+	errs = appendIfNegative(errs, "health.drain_delay", h.DrainDelay)
+	return errs
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Параметры описываются тегами полей:
+//
+//	yaml    - имя в файле; путь параметра (server.read_timeout) служит и именем флага
+//	env     - переменная окружения
+//	default - значение по умолчанию
+//	secret  - значение скрывается при печати конфигурации
+type param struct {
+	path   string
+	env    string
+	def    string
+	secret bool
+	value  reflect.Value
+}
+
+const configFileEnv = "CONFIG_FILE"
+
+// Load собирает конфигурацию из значений по умолчанию, YAML файла (-config или CONFIG_FILE),
+// переменных окружения и флагов args, после чего валидирует её
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	params := collectParams(reflect.ValueOf(cfg).Elem(), "")
+
+	for _, p := range params {
+		if p.def == "" {
+			continue
+		}
+		if err := setParam(p.value, p.def); err != nil {
+			return nil, fmt.Errorf("invalid default for %s: %w", p.path, err)
+		}
+	}
+
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(configFileEnv), "path to YAML config file (env "+configFileEnv+")")
+	for _, p := range params {
+		flags.String(p.path, "", p.usage())
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	byPath := make(map[string]param, len(params))
+	for _, p := range params {
+		byPath[p.path] = p
+		if p.env == "" {
+			continue
+		}
+		if raw := os.Getenv(p.env); raw != "" {
+			if err := setParam(p.value, raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", p.env, err))
+			}
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		p, ok := byPath[f.Name]
+		if !ok {
+			return
+		}
+		if err := setParam(p.value, f.Value.String()); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// LogValue выводит действующую конфигурацию в лог плоским набором атрибутов, секреты скрыты
+func (c *Config) LogValue() slog.Value {
+	params := collectParams(reflect.ValueOf(c).Elem(), "")
+	attrs := make([]slog.Attr, 0, len(params))
+	for _, p := range params {
+		value := formatParam(p.value)
+		if p.secret && value != "" {
+			value = "***"
+		}
+		attrs = append(attrs, slog.String(p.path, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (p param) usage() string {
+	if p.env == "" {
+		return p.path
+	}
+	return p.path + " (env " + p.env + ")"
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func collectParams(v reflect.Value, prefix string) []param {
+	var params []param
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Ptr && value.Type().Elem().Kind() == reflect.Struct {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct {
+			params = append(params, collectParams(value, name)...)
+			continue
+		}
+
+		params = append(params, param{
+			path:   name,
+			env:    field.Tag.Get("env"),
+			def:    field.Tag.Get("default"),
+			secret: field.Tag.Get("secret") == "true",
+			value:  value,
+		})
+	}
+	return params
+}
+
+func setParam(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+func formatParam(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogValueHidesSecrets(t *testing.T) {
+	cfg := &Config{}
+	var secrets []string
+	for _, p := range collectParams(reflect.ValueOf(cfg).Elem(), "") {
+		if !p.secret {
+			continue
+		}
+		value := "leaked-" + p.path
+		if err := setParam(p.value, value); err != nil {
+			t.Fatalf("set %s: %v", p.path, err)
+		}
+		secrets = append(secrets, p.path)
+	}
+	if len(secrets) == 0 {
+		t.Fatal("config has no secret parameters")
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("config", "config", cfg)
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("config", "config", cfg)
+	out := buf.String()
+
+	if strings.Contains(out, "leaked-") {
+		t.Fatalf("secret value in log output:\n%s", out)
+	}
+	for _, path := range secrets {
+		if !strings.Contains(out, `"`+path+`":"***"`) || !strings.Contains(out, "config."+path+"=***") {
+			t.Errorf("%s is not masked:\n%s", path, out)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type Logging struct {
+	// Level: debug, info, warn, error
+	Level  string `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json"`
+	// SlowQueryThreshold - запросы к БД дольше порога пишутся с уровнем warn
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env:"LOG_SLOW_QUERY_THRESHOLD" default:"200ms"`
+}
+
+func (l *Logging) validate() []error {
+	var errs []error
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: unknown level %q", l.Level))
+	}
+	if l.Format != "json" && l.Format != "text" {
+		errs = append(errs, fmt.Errorf("logging.format must be json or text"))
+	}
+	errs = appendIfNegative(errs, "logging.slow_query_threshold", l.SlowQueryThreshold)
+	return errs
+}
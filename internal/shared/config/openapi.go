@@ -0,0 +1,22 @@
+package config
+
+import "fmt"
+
+// OpenAPI - контракт api.yml: отдача спецификации и проверка запросов и ответов по ней
+type OpenAPI struct {
+	// SpecFile - путь к api.yml, файл читается при старте
+	SpecFile string `yaml:"spec_file" env:"OPENAPI_SPEC_FILE" default:"api.yml"`
+	// ValidateRequests отклоняет запросы, не соответствующие спецификации, до обработчиков
+	ValidateRequests bool `yaml:"validate_requests" env:"OPENAPI_VALIDATE_REQUESTS" default:"false"`
+	// ValidateResponses заменяет ответы, нарушающие спецификацию, ошибкой 500.
+	// Ответы буферизуются целиком, режим предназначен для разработки и тестов.
+	ValidateResponses bool `yaml:"validate_responses" env:"OPENAPI_VALIDATE_RESPONSES" default:"false"`
+}
+
+func (o *OpenAPI) validate() []error {
+	var errs []error
+	if o.SpecFile == "" {
+		errs = append(errs, fmt.Errorf("openapi.spec_file is required"))
+	}
+	return errs
+}
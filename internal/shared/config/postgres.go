@@ -1,37 +1,80 @@
 package config
 
 import (
-	"errors"
-	"os"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
 )
 
 type Postgres struct {
-	ConnStr  string
-	Host     string
-	Port     string
-	Database string
-	Username string
-	Password string
+	// ConnStr целиком задаёт подключение; если пуст, строка собирается из отдельных полей
+	ConnStr  string `yaml:"conn" env:"POSTGRES_CONN" secret:"true"`
+	Host     string `yaml:"host" env:"POSTGRES_HOST"`
+	Port     string `yaml:"port" env:"POSTGRES_PORT" default:"5432"`
+	Database string `yaml:"database" env:"POSTGRES_DATABASE"`
+	Username string `yaml:"username" env:"POSTGRES_USERNAME"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD" secret:"true"`
+	SSLMode  string `yaml:"sslmode" env:"POSTGRES_SSLMODE" default:"disable"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"POSTGRES_MAX_OPEN_CONNS" default:"20"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"POSTGRES_MAX_IDLE_CONNS" default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"POSTGRES_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"POSTGRES_CONN_MAX_IDLE_TIME" default:"5m"`
+
+	// StatementTimeout ограничивает время одного запроса к БД в рамках запроса клиента, 0 - без ограничения
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"POSTGRES_STATEMENT_TIMEOUT" default:"5s"`
 }
 
-func getPostgres() (*Postgres, error) {
-	connStr := os.Getenv("POSTGRES_CONN")
-	host := os.Getenv("POSTGRES_HOST")
-	port := os.Getenv("POSTGRES_PORT")
-	database := os.Getenv("POSTGRES_DATABASE")
-	username := os.Getenv("POSTGRES_USERNAME")
-	password := os.Getenv("POSTGRES_PASSWORD")
+// DSN возвращает строку подключения
+func (p *Postgres) DSN() string {
+	if p.ConnStr != "" {
+		return p.ConnStr
+	}
 
-	if connStr == "" || host == "" || port == "" || database == "" || username == "" || password == "" {
-		return nil, errors.New("one or more environment variables are missing")
+	dsn := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(p.Username, p.Password),
+		Host:     net.JoinHostPort(p.Host, p.Port),
+		Path:     "/" + p.Database,
+		RawQuery: url.Values{"sslmode": {p.SSLMode}}.Encode(),
 	}
+	return dsn.String()
+}
 
-	return &Postgres{
-		ConnStr:  connStr,
-		Host:     host,
-		Port:     port,
-		Database: database,
-		Username: username,
-		Password: password,
-	}, nil
+func (p *Postgres) validate() []error {
+	var errs []error
+	if p.ConnStr != "" {
+		if _, err := url.Parse(p.ConnStr); err != nil {
+			errs = append(errs, fmt.Errorf("postgres.conn is not a valid URL"))
+		}
+	} else {
+		required := map[string]string{
+			"postgres.host":     p.Host,
+			"postgres.database": p.Database,
+			"postgres.username": p.Username,
+		}
+		for _, name := range []string{"postgres.host", "postgres.database", "postgres.username"} {
+			if required[name] == "" {
+				errs = append(errs, fmt.Errorf("%s is required when postgres.conn is not set", name))
+			}
+		}
+		if err := validatePort(p.Port); err != nil {
+			errs = append(errs, fmt.Errorf("postgres.port: %w", err))
+		}
+	}
+
+	if p.MaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("postgres.max_open_conns must not be negative"))
+	}
+	if p.MaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("postgres.max_idle_conns must not be negative"))
+	}
+	if p.MaxOpenConns > 0 && p.MaxIdleConns > p.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("postgres.max_idle_conns must not exceed postgres.max_open_conns"))
+	}
+	errs = appendIfNegative(errs, "postgres.conn_max_lifetime", p.ConnMaxLifetime)
+	errs = appendIfNegative(errs, "postgres.conn_max_idle_time", p.ConnMaxIdleTime)
+	errs = appendIfNegative(errs, "postgres.statement_timeout", p.StatementTimeout)
+	return errs
 }
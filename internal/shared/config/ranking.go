@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ranking - веса персонализированной ленты (sort=relevance)
+type Ranking struct {
+	// Вклад категорий, с которыми пользователь уже взаимодействовал
+	AffinityWeight   float64 `yaml:"weight_affinity" env:"RANKING_WEIGHT_AFFINITY" default:"1"`
+	LikeWeight       float64 `yaml:"weight_like" env:"RANKING_WEIGHT_LIKE" default:"1"`
+	ActivationWeight float64 `yaml:"weight_activation" env:"RANKING_WEIGHT_ACTIVATION" default:"2"`
+	CommentWeight    float64 `yaml:"weight_comment" env:"RANKING_WEIGHT_COMMENT" default:"0.5"`
+
+	// Предрасчитываемая часть скора по сегменту пользователя
+	PopularityWeight        float64 `yaml:"weight_popularity" env:"RANKING_WEIGHT_POPULARITY" default:"0.3"`
+	SegmentPopularityWeight float64 `yaml:"weight_segment_popularity" env:"RANKING_WEIGHT_SEGMENT_POPULARITY" default:"0.5"`
+	FreshnessWeight         float64 `yaml:"weight_freshness" env:"RANKING_WEIGHT_FRESHNESS" default:"1"`
+	FreshnessHalfLifeDays   float64 `yaml:"freshness_half_life_days" env:"RANKING_FRESHNESS_HALF_LIFE_DAYS" default:"7"`
+	InventoryWeight         float64 `yaml:"weight_inventory" env:"RANKING_WEIGHT_INVENTORY" default:"0.5"`
+
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"RANKING_REFRESH_INTERVAL" default:"5m"`
+}
+
+func (r *Ranking) validate() []error {
+	var errs []error
+	if r.FreshnessHalfLifeDays <= 0 {
+		errs = append(errs, fmt.Errorf("ranking.freshness_half_life_days must be positive"))
+	}
+	if r.RefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("ranking.refresh_interval must be positive"))
+	}
+	return errs
+}
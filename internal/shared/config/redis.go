@@ -1,18 +1,44 @@
 package config
 
 import (
-	"errors"
-	"os"
+	"fmt"
+	"net"
+	"time"
 )
 
 type Redis struct {
-	ConnStr string
+	Host     string `yaml:"host" env:"REDIS_HOST"`
+	Port     string `yaml:"port" env:"REDIS_PORT" default:"6379"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" env:"REDIS_DB" default:"0"`
+
+	// PoolSize 0 - размер пула по умолчанию go-redis
+	PoolSize     int           `yaml:"pool_size" env:"REDIS_POOL_SIZE" default:"0"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" env:"REDIS_DIAL_TIMEOUT" default:"5s"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"REDIS_READ_TIMEOUT" default:"3s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"REDIS_WRITE_TIMEOUT" default:"3s"`
+}
+
+func (r *Redis) Addr() string {
+	return net.JoinHostPort(r.Host, r.Port)
 }
 
-func getRedis() (*Redis, error) {
-	redisConn := os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT")
-	if redisConn == ":" { // оба значения пустые
-		return nil, errors.New("not found REDIS_HOST or REDIS_PORT")
+func (r *Redis) validate() []error {
+	var errs []error
+	if r.Host == "" {
+		errs = append(errs, fmt.Errorf("redis.host is required"))
+	}
+	if err := validatePort(r.Port); err != nil {
+		errs = append(errs, fmt.Errorf("redis.port: %w", err))
+	}
+	if r.DB < 0 {
+		errs = append(errs, fmt.Errorf("redis.db must not be negative"))
+	}
+	if r.PoolSize < 0 {
+		errs = append(errs, fmt.Errorf("redis.pool_size must not be negative"))
 	}
-	return &Redis{ConnStr: redisConn}, nil
+	errs = appendIfNegative(errs, "redis.dial_timeout", r.DialTimeout)
+	errs = appendIfNegative(errs, "redis.read_timeout", r.ReadTimeout)
+	errs = appendIfNegative(errs, "redis.write_timeout", r.WriteTimeout)
+	return errs
 }
@@ -1,25 +1,87 @@
 package config
 
 import (
-	"errors"
+	"fmt"
+	"net"
 	"os"
-	"strings"
+	"strconv"
+	"time"
 )
 
 type Server struct {
-	Addr string
-	Port string
+	Addr string `yaml:"address" env:"SERVER_ADDRESS" default:"0.0.0.0:8080"`
+
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"10s"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"10s"`
+
+	TLS TLS `yaml:"tls"`
+}
+
+// TLS включается, если заданы и сертификат, и ключ
+type TLS struct {
+	CertFile string `yaml:"cert_file" env:"SERVER_TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" env:"SERVER_TLS_KEY_FILE"`
+}
+
+func (s *Server) Port() string {
+	_, port, _ := net.SplitHostPort(s.Addr)
+	return port
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+func (s *Server) validate() []error {
+	var errs []error
+	if err := validateAddress(s.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.address: %w", err))
+	}
+
+	errs = appendIfNegative(errs, "server.read_timeout", s.ReadTimeout)
+	errs = appendIfNegative(errs, "server.write_timeout", s.WriteTimeout)
+	errs = appendIfNegative(errs, "server.idle_timeout", s.IdleTimeout)
+	errs = appendIfNegative(errs, "server.shutdown_timeout", s.ShutdownTimeout)
+
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("server.tls: cert_file and key_file must be set together"))
+	}
+	for name, path := range map[string]string{"server.tls.cert_file": s.TLS.CertFile, "server.tls.key_file": s.TLS.KeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errs
 }
 
-func getServer() (*Server, error) {
-	serverConf := os.Getenv("SERVER_ADDRESS")
+// validateAddress проверяет адрес вида host:port
+func validateAddress(addr string) error {
+	if addr == "" {
+		return fmt.Errorf("is required")
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("expected host:port, got %q", addr)
+	}
+	return validatePort(port)
+}
 
-	if serverConf == "" {
-		return nil, errors.New("not found REDIS_HOST or REDIS_PORT")
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
 	}
-	port := strings.Split(serverConf, ":")[1]
+	return nil
+}
 
-	return &Server{Addr: serverConf,
-		Port: port,
-	}, nil
+func appendIfNegative(errs []error, name string, d time.Duration) []error {
+	if d < 0 {
+		return append(errs, fmt.Errorf("%s must not be negative", name))
+	}
+	return errs
 }
@@ -1,14 +1,58 @@
 package config
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 )
 
-func GetJwtKey() []byte {
-	jwtKey := os.Getenv("RANDOM_SECRET")
-	if jwtKey == "" {
-		panic("RANDOM_SECRET is not set in the environment")
+type Auth struct {
+	Secret   string        `yaml:"secret" env:"RANDOM_SECRET" secret:"true"`
+	TokenTTL time.Duration `yaml:"token_ttl" env:"AUTH_TOKEN_TTL" default:"24h"`
+}
+
+var ErrJwtKeyNotConfigured = errors.New("jwt secret is not configured")
+
+var (
+	authMu  sync.RWMutex
+	current *Auth
+)
+
+// SetAuth делает настройки авторизации доступными для GetJwtKey и GetTokenTTL
+func SetAuth(auth *Auth) {
+	authMu.Lock()
+	defer authMu.Unlock()
+	current = auth
+}
+
+func GetJwtKey() ([]byte, error) {
+	authMu.RLock()
+	defer authMu.RUnlock()
+
+	if current == nil || current.Secret == "" {
+		return nil, ErrJwtKeyNotConfigured
 	}
+	return []byte(current.Secret), nil
+}
+
+func GetTokenTTL() time.Duration {
+	authMu.RLock()
+	defer authMu.RUnlock()
 
-	return []byte(jwtKey)
+	if current == nil {
+		return 24 * time.Hour
+	}
+	return current.TokenTTL
+}
+
+func (a *Auth) validate() []error {
+	var errs []error
+	if a.Secret == "" {
+		errs = append(errs, fmt.Errorf("auth.secret is required"))
+	}
+	if a.TokenTTL <= 0 {
+		errs = append(errs, fmt.Errorf("auth.token_ttl must be positive"))
+	}
+	return errs
 }
@@ -0,0 +1,48 @@
+package config
+
+import "fmt"
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
+)
+
+type Tracing struct {
+	// Exporter: none - трассировка выключена, stdout - для локального запуска, otlp - коллектор
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" default:"promo-backend"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+
+	OTLPProtocol string `yaml:"otlp_protocol" env:"TRACING_OTLP_PROTOCOL" default:"grpc"`
+	// OTLPEndpoint - host:port коллектора; если пуст, экспортер берёт стандартные OTEL_EXPORTER_OTLP_* переменные
+	OTLPEndpoint string `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool   `yaml:"otlp_insecure" env:"TRACING_OTLP_INSECURE" default:"false"`
+}
+
+func (t *Tracing) validate() []error {
+	var errs []error
+	switch t.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be none, stdout or otlp"))
+	}
+	if t.ServiceName == "" {
+		errs = append(errs, fmt.Errorf("tracing.service_name is required"))
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1"))
+	}
+	if t.OTLPProtocol != OTLPProtocolGRPC && t.OTLPProtocol != OTLPProtocolHTTP {
+		errs = append(errs, fmt.Errorf("tracing.otlp_protocol must be grpc or http"))
+	}
+	if t.OTLPEndpoint != "" {
+		if err := validateAddress(t.OTLPEndpoint); err != nil {
+			errs = append(errs, fmt.Errorf("tracing.otlp_endpoint: %w", err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultEmailPattern - формат email по умолчанию, совпадает с default тега EmailPolicy.Pattern
+const DefaultEmailPattern = `^[a-zA-Z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`
+
+// Validation - политики проверки паролей и email при регистрации и смене пароля
+type Validation struct {
+	Password PasswordPolicy `yaml:"password"`
+	Email    EmailPolicy    `yaml:"email"`
+}
+
+type PasswordPolicy struct {
+	MinLength    int  `yaml:"min_length" env:"PASSWORD_MIN_LENGTH" default:"8"`
+	MaxLength    int  `yaml:"max_length" env:"PASSWORD_MAX_LENGTH" default:"60"`
+	RequireUpper bool `yaml:"require_upper" env:"PASSWORD_REQUIRE_UPPER" default:"true"`
+	RequireLower bool `yaml:"require_lower" env:"PASSWORD_REQUIRE_LOWER" default:"true"`
+	RequireDigit bool `yaml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT" default:"true"`
+	// SpecialChars - хотя бы один из символов обязателен; пусто - спецсимвол не требуется
+	SpecialChars string `yaml:"special_chars" env:"PASSWORD_SPECIAL_CHARS" default:"@$!%*?&"`
+}
+
+type EmailPolicy struct {
+	MinLength int    `yaml:"min_length" env:"EMAIL_MIN_LENGTH" default:"8"`
+	MaxLength int    `yaml:"max_length" env:"EMAIL_MAX_LENGTH" default:"120"`
+	Pattern   string `yaml:"pattern" env:"EMAIL_PATTERN" default:"^[a-zA-Z0-9._%+\\-]+@[a-z0-9.\\-]+\\.[a-z]{2,}$"`
+}
+
+func (v *Validation) validate() []error {
+	var errs []error
+	if v.Password.MinLength < 1 || v.Password.MaxLength < v.Password.MinLength {
+		errs = append(errs, fmt.Errorf("validation.password: min_length must be positive and not exceed max_length"))
+	}
+	if v.Email.MinLength < 1 || v.Email.MaxLength < v.Email.MinLength {
+		errs = append(errs, fmt.Errorf("validation.email: min_length must be positive and not exceed max_length"))
+	}
+	if _, err := regexp.Compile(v.Email.Pattern); err != nil {
+		errs = append(errs, fmt.Errorf("validation.email.pattern: %w", err))
+	}
+	return errs
+}
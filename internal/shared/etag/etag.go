@@ -0,0 +1,110 @@
+// Package etag - ETag по версии ресурса и разбор условных заголовков If-Match и If-None-Match
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"solution/internal/shared/apperr"
+)
+
+// Format возвращает сильный ETag версии ресурса
+func Format(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ForBody возвращает ETag вида "<версия>-<хеш тела>". Хеш меняется вместе с любым полем ответа, в том числе
+// со счётчиками, которые версию не увеличивают, поэтому If-None-Match не отдаёт 304 на устаревшее тело.
+// If-Match сравнивает только версию из префикса тега.
+func ForBody(version int, body any) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return strconv.Quote(strconv.Itoa(version) + "-" + hex.EncodeToString(sum[:8])), nil
+}
+
+// Condition - разобранный If-Match. Нулевое значение - условия нет, подходит любая версия.
+type Condition struct {
+	versions []int
+	set      bool
+}
+
+// Any сообщает, что If-Match не ограничивает версию: заголовка нет или он равен "*"
+func (c Condition) Any() bool {
+	return !c.set
+}
+
+// Matches проверяет текущую версию ресурса по списку тегов If-Match
+func (c Condition) Matches(version int) bool {
+	return !c.set || slices.Contains(c.versions, version)
+}
+
+// ParseIfMatch разбирает If-Match как список тегов через запятую (RFC 9110). Условие выполнено, если с текущей
+// версией совпадает хотя бы один тег. If-Match требует сильного сравнения, поэтому слабые теги не совпадают никогда.
+func ParseIfMatch(header string) (Condition, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return Condition{}, nil
+	}
+
+	condition := Condition{set: true}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		weak := strings.HasPrefix(tag, "W/")
+		if !isQuoted(strings.TrimPrefix(tag, "W/")) {
+			return Condition{}, apperr.ErrPreconditionFailed
+		}
+		if version, ok := parse(tag); ok && !weak {
+			condition.versions = append(condition.versions, version)
+		}
+	}
+	return condition, nil
+}
+
+// NoneMatch проверяет If-None-Match по текущему ETag: true - у клиента актуальное тело и можно ответить 304.
+// Сравнение слабое, как требует RFC 9110 для If-None-Match.
+func NoneMatch(header string, current string) bool {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+func isQuoted(tag string) bool {
+	return len(tag) >= 2 && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`)
+}
+
+func parse(tag string) (int, bool) {
+	if !isQuoted(tag) {
+		return 0, false
+	}
+	value, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, false
+	}
+	// у тегов ForBody версия - префикс до хеша тела
+	value, _, _ = strings.Cut(value, "-")
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
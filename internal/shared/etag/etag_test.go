@@ -0,0 +1,105 @@
+package etag
+
+import (
+	"errors"
+	"testing"
+
+	"solution/internal/shared/apperr"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int
+		matches bool
+		any     bool
+		invalid bool
+	}{
+		{name: "no header", header: "", version: 3, matches: true, any: true},
+		{name: "wildcard", header: " * ", version: 3, matches: true, any: true},
+		{name: "same version", header: `"3"`, version: 3, matches: true},
+		{name: "other version", header: `"2"`, version: 3},
+		{name: "list with current version", header: `"1", "3" ,"5"`, version: 3, matches: true},
+		{name: "list without current version", header: `"1", "2"`, version: 3},
+		{name: "weak tag never matches", header: `W/"3"`, version: 3},
+		{name: "weak and strong tags", header: `W/"3", "3"`, version: 3, matches: true},
+		{name: "foreign opaque tag", header: `"abc", "3"`, version: 3, matches: true},
+		{name: "tag with body hash", header: `"3-0123456789abcdef"`, version: 3, matches: true},
+		{name: "tag with body hash of other version", header: `"2-0123456789abcdef"`, version: 3},
+		{name: "empty list elements", header: `, "3",`, version: 3, matches: true},
+		{name: "unquoted tag", header: `3`, invalid: true},
+		{name: "unquoted tag in list", header: `"3", 4`, invalid: true},
+		{name: "wildcard in list", header: `"3", *`, invalid: true},
+		{name: "broken weak tag", header: `W/3`, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, err := ParseIfMatch(tt.header)
+			if tt.invalid {
+				if !errors.Is(err, apperr.ErrPreconditionFailed) {
+					t.Fatalf("ParseIfMatch(%q) error = %v, want precondition failed", tt.header, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIfMatch(%q) unexpected error: %v", tt.header, err)
+			}
+			if got := condition.Matches(tt.version); got != tt.matches {
+				t.Errorf("Matches(%d) = %v, want %v", tt.version, got, tt.matches)
+			}
+			if got := condition.Any(); got != tt.any {
+				t.Errorf("Any() = %v, want %v", got, tt.any)
+			}
+		})
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	current := `"3-0123456789abcdef"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "*", want: true},
+		{header: current, want: true},
+		{header: "W/" + current, want: true},
+		{header: `"1", "2"`, want: false},
+		{header: `"1", W/` + current, want: true},
+		{header: `"3"`, want: false},
+		{header: `"3-fedcba9876543210"`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := NoneMatch(tt.header, current); got != tt.want {
+			t.Errorf("NoneMatch(%q, %s) = %v, want %v", tt.header, current, got, tt.want)
+		}
+	}
+}
+
+func TestForBody(t *testing.T) {
+	type body struct {
+		UsedCount int `json:"used_count"`
+	}
+
+	tag, err := ForBody(3, body{UsedCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := ForBody(3, body{UsedCount: 1}); again != tag {
+		t.Errorf("ForBody is not stable: %s != %s", again, tag)
+	}
+	if changed, _ := ForBody(3, body{UsedCount: 2}); changed == tag {
+		t.Errorf("ForBody(%d) = %s, want a new tag when the body changes without a new version", 3, changed)
+	}
+
+	match, err := ParseIfMatch(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match.Matches(3) || match.Matches(4) {
+		t.Errorf("If-Match %s must match version 3 only", tag)
+	}
+}
@@ -0,0 +1,88 @@
+// Package gormhook - регистрация колбэков плагинов GORM вокруг каждой операции
+package gormhook
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+const contextKey = "gormhook:context"
+
+// Hooks возвращает колбэки плагина до и после операции operation: create, query, update, delete, row или raw
+type Hooks func(operation string) (before, after func(*gorm.DB))
+
+// statementContext - контекст Statement до первого before и число before без парного after
+type statementContext struct {
+	parent context.Context
+	open   int
+}
+
+// Register вешает колбэки плагина prefix на все операции под именами prefix:before_<operation>
+// и prefix:after_<operation>. Колбэк before может подменить db.Statement.Context: исходный контекст
+// возвращается после последнего after операции, в каком бы порядке ни были подключены плагины.
+func Register(db *gorm.DB, prefix string, hooks Hooks) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, p := range processors {
+		before, after := hooks(p.operation)
+		if err := p.before(prefix+":before_"+p.operation, saveContext(before)); err != nil {
+			return err
+		}
+		if err := p.after(prefix+":after_"+p.operation, restoreContext(after)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveContext(before func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		saved, ok := savedContext(db)
+		if !ok {
+			saved = &statementContext{}
+			db.InstanceSet(contextKey, saved)
+		}
+		if saved.open == 0 {
+			saved.parent = db.Statement.Context
+		}
+		saved.open++
+		before(db)
+	}
+}
+
+// restoreContext возвращает исходный контекст после последнего after: цепочка вида query.Count(...).Find(...)
+// переиспользует Statement, и следующий запрос не должен получить контекст предыдущего
+func restoreContext(after func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		after(db)
+		saved, ok := savedContext(db)
+		if !ok || saved.open == 0 {
+			return
+		}
+		saved.open--
+		if saved.open == 0 {
+			db.Statement.Context = saved.parent
+		}
+	}
+}
+
+func savedContext(db *gorm.DB) (*statementContext, bool) {
+	value, ok := db.InstanceGet(contextKey)
+	if !ok {
+		return nil, false
+	}
+	return value.(*statementContext), true
+}
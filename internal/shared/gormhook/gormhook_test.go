@@ -0,0 +1,91 @@
+package gormhook
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type depthKey struct{}
+
+func depth(ctx context.Context) int {
+	d, _ := ctx.Value(depthKey{}).(int)
+	return d
+}
+
+// wrapContext - плагин, который на время операции подменяет контекст дочерним, как tracing и timeout
+func wrapContext(string) (before, after func(*gorm.DB)) {
+	before = func(db *gorm.DB) {
+		db.Statement.Context = context.WithValue(db.Statement.Context, depthKey{}, depth(db.Statement.Context)+1)
+	}
+	return before, func(*gorm.DB) {}
+}
+
+// TestRegisterRestoresContext проверяет, что контекст возвращается к исходному после операции при нескольких
+// плагинах, и следующий запрос цепочки получает исходный контекст, а не контекст предыдущего запроса.
+// Запросы выполняются в режиме DryRun, поэтому база не нужна.
+func TestRegisterRestoresContext(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{"outer", "inner"} {
+		if err := Register(db, prefix, wrapContext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var seen []int
+	capture := func(db *gorm.DB) { seen = append(seen, depth(db.Statement.Context)) }
+	if err := db.Callback().Query().Before("gorm:query").After("inner:before_query").Register("test:query", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Row().Before("gorm:row").After("inner:before_row").Register("test:row", capture); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		run  func(tx *gorm.DB) *gorm.DB
+		want []int
+	}{
+		{name: "query", run: func(tx *gorm.DB) *gorm.DB { return find(tx) }, want: []int{2}},
+		{name: "chained queries", run: func(tx *gorm.DB) *gorm.DB { return find(find(tx)) }, want: []int{2, 2}},
+		{name: "row after query", run: func(tx *gorm.DB) *gorm.DB {
+			tx = find(tx)
+			tx.Row()
+			return tx
+		}, want: []int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			ctx := context.Background()
+			tx := tt.run(db.WithContext(ctx).Table("promos"))
+			if len(seen) != len(tt.want) {
+				t.Fatalf("captured %d statements, want %d", len(seen), len(tt.want))
+			}
+			for i := range seen {
+				if seen[i] != tt.want[i] {
+					t.Errorf("statement %d context depth = %d, want %d", i, seen[i], tt.want[i])
+				}
+			}
+			if tx.Statement.Context != ctx {
+				t.Errorf("context after statements is not restored, depth %d", depth(tx.Statement.Context))
+			}
+		})
+	}
+}
+
+func find(tx *gorm.DB) *gorm.DB {
+	var rows []struct{ ID string }
+	return tx.Find(&rows)
+}
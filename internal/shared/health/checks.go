@@ -0,0 +1,18 @@
+package health
+
+import (
+	"context"
+	"net"
+)
+
+// DialCheck проверяет, что адрес host:port принимает TCP соединения
+func DialCheck(address string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
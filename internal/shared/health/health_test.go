@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func okCheck(context.Context) error { return nil }
+
+func failCheck(context.Context) error { return errors.New("connection refused") }
+
+// slowCheck ждёт отмены контекста: проверка дольше таймаута Checker
+func slowCheck(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		drain  bool
+		status int
+		want   Report
+	}{
+		{
+			name:   "all checks pass",
+			checks: []Check{{Name: "postgres", Run: okCheck}, {Name: "redis", Run: okCheck}},
+			status: http.StatusOK,
+			want: Report{Status: StatusOK, Checks: map[string]CheckResult{
+				"postgres": {Status: StatusOK},
+				"redis":    {Status: StatusOK},
+			}},
+		},
+		{
+			name:   "required check fails",
+			checks: []Check{{Name: "postgres", Run: failCheck}, {Name: "redis", Run: okCheck}},
+			status: http.StatusServiceUnavailable,
+			want: Report{Status: StatusFail, Checks: map[string]CheckResult{
+				"postgres": {Status: StatusFail, Error: "connection refused"},
+				"redis":    {Status: StatusOK},
+			}},
+		},
+		{
+			name:   "required check times out",
+			checks: []Check{{Name: "postgres", Run: slowCheck}},
+			status: http.StatusServiceUnavailable,
+			want: Report{Status: StatusFail, Checks: map[string]CheckResult{
+				"postgres": {Status: StatusFail, Error: context.DeadlineExceeded.Error()},
+			}},
+		},
+		{
+			name:   "optional check fails",
+			checks: []Check{{Name: "postgres", Run: okCheck}, {Name: "antifraud", Optional: true, Run: failCheck}},
+			status: http.StatusOK,
+			want: Report{Status: StatusOK, Checks: map[string]CheckResult{
+				"postgres":  {Status: StatusOK},
+				"antifraud": {Status: StatusFail, Optional: true, Error: "connection refused"},
+			}},
+		},
+		{
+			name:   "draining",
+			checks: []Check{{Name: "postgres", Run: okCheck}},
+			drain:  true,
+			status: http.StatusServiceUnavailable,
+			want: Report{Status: StatusFail, Reason: "shutting down", Checks: map[string]CheckResult{
+				"postgres": {Status: StatusOK},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(20*time.Millisecond, tt.checks...)
+			if tt.drain {
+				checker.Drain()
+			}
+
+			rec := httptest.NewRecorder()
+			checker.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+
+			var report Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("decode report: %v (%s)", err, rec.Body)
+			}
+			if report.Status != tt.want.Status || report.Reason != tt.want.Reason {
+				t.Errorf("report = %s (%q), want %s (%q)", report.Status, report.Reason, tt.want.Status, tt.want.Reason)
+			}
+			if len(report.Checks) != len(tt.want.Checks) {
+				t.Fatalf("checks = %v, want %v", report.Checks, tt.want.Checks)
+			}
+			for name, want := range tt.want.Checks {
+				got := report.Checks[name]
+				got.DurationMs = 0
+				if got != want {
+					t.Errorf("check %s = %+v, want %+v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDrainFlipsReadiness(t *testing.T) {
+	checker := NewChecker(time.Second, Check{Name: "postgres", Run: okCheck})
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		checker.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+	live := func() int {
+		rec := httptest.NewRecorder()
+		checker.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec.Code
+	}
+
+	if got := ready(); got != http.StatusOK {
+		t.Fatalf("readyz before Drain = %d, want %d", got, http.StatusOK)
+	}
+	checker.Drain()
+	if !checker.Draining() {
+		t.Error("Draining() = false after Drain")
+	}
+	if got := ready(); got != http.StatusServiceUnavailable {
+		t.Errorf("readyz after Drain = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+	// живость не зависит от остановки: под не перезапускается, пока дообслуживает запросы
+	if got := live(); got != http.StatusOK {
+		t.Errorf("healthz after Drain = %d, want %d", got, http.StatusOK)
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestSetLevel(t *testing.T) {
+	defer level.Set(level.Level())
+
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level})
+
+	tests := []struct {
+		name  string
+		want  slog.Level
+		debug bool
+		err   bool
+	}{
+		{name: "info", want: slog.LevelInfo},
+		{name: "debug", want: slog.LevelDebug, debug: true},
+		{name: "WARN", want: slog.LevelWarn},
+		{name: "verbose", want: slog.LevelWarn, err: true},
+		{name: "", want: slog.LevelWarn, err: true},
+	}
+
+	for _, tt := range tests {
+		err := SetLevel(tt.name)
+		if (err != nil) != tt.err {
+			t.Errorf("SetLevel(%q) error = %v, want error %v", tt.name, err, tt.err)
+		}
+		if got := Level(); got != tt.want {
+			t.Errorf("after SetLevel(%q) Level() = %v, want %v", tt.name, got, tt.want)
+		}
+		// уже созданные обработчики видят новый уровень без перенастройки
+		if got := handler.Enabled(context.Background(), slog.LevelDebug); got != tt.debug {
+			t.Errorf("after SetLevel(%q) debug enabled = %v, want %v", tt.name, got, tt.debug)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+)
+
+const redacted = "***"
+
+// sensitiveKeys - атрибуты, значения которых никогда не пишутся в лог. Только явные имена секретов:
+// общие имена вроде "key" встречаются и у безобидных атрибутов.
+// Сравнение по последнему сегменту ключа без учёта регистра.
+var sensitiveKeys = map[string]struct{}{
+	"password":      {},
+	"token":         {},
+	"access_token":  {},
+	"authorization": {},
+	"secret":        {},
+	"api_key":       {},
+	"conn":          {},
+	"promo_common":  {},
+	"promo_unique":  {},
+	"promo_code":    {},
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		key = key[i+1:]
+	}
+	if _, ok := sensitiveKeys[key]; ok {
+		return true
+	}
+	return strings.Contains(key, "password") || strings.Contains(key, "secret") || strings.HasSuffix(key, "token")
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindGroup && isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactAttr(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactAttr}))
+
+	log.Info("test",
+		"code", "promo_not_found",
+		"promo_code", "SUMMER",
+		"promo_common", "SALE",
+		"promo_unique", []string{"A", "B"},
+		"password", "pass",
+		"db_password", "pass",
+		"refresh_token", "jwt",
+		"api_key", "pk_secret",
+		"key", "promo:feed",
+		slog.Group("request", "authorization", "Bearer jwt", "code", "bad_request"),
+	)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid log record %q: %v", buf.String(), err)
+	}
+
+	want := map[string]any{
+		"code":          "promo_not_found",
+		"promo_code":    redacted,
+		"promo_common":  redacted,
+		"promo_unique":  redacted,
+		"password":      redacted,
+		"db_password":   redacted,
+		"refresh_token": redacted,
+		"api_key":       redacted,
+		"key":           "promo:feed",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+
+	request, _ := record["request"].(map[string]any)
+	if request["authorization"] != redacted {
+		t.Errorf("request.authorization = %v, want %v", request["authorization"], redacted)
+	}
+	if request["code"] != "bad_request" {
+		t.Errorf("request.code = %v, want bad_request", request["code"])
+	}
+}
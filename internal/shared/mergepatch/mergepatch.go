@@ -0,0 +1,79 @@
+// Package mergepatch - JSON Merge Patch (RFC 7396): поля патча заменяют поля документа,
+// вложенные объекты сливаются рекурсивно, null удаляет поле
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrNotObject - патч не объект. RFC 7396 разрешает заменить документ целиком,
+// но ресурсы API - объекты, и такой патч всегда ошибка клиента.
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply применяет patch к JSON документу doc
+func Apply(doc, patch []byte) ([]byte, error) {
+	p, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.(map[string]interface{}); !ok {
+		return nil, ErrNotObject
+	}
+
+	d, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(d, p))
+}
+
+// Merge применяет patch к JSON представлению current и разбирает результат в merged
+func Merge(current interface{}, patch []byte, merged interface{}) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	result, err := Apply(doc, patch)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(result, merged)
+}
+
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// decode сохраняет числа как json.Number, чтобы большие целые не теряли точность при слиянии
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return value, nil
+}
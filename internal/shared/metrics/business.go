@@ -0,0 +1,42 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Результаты активации промокода
+const (
+	ActivationOK                   = "ok"
+	ActivationAntifraudDenied      = "antifraud_denied"
+	ActivationAntifraudUnavailable = "antifraud_unavailable"
+	ActivationExhausted            = "exhausted"
+)
+
+var (
+	PromosCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "promos_created_total",
+		Help: "Promos created by companies.",
+	})
+
+	PromoLikes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "promo_likes_total",
+		Help: "Promo likes by users.",
+	})
+
+	PromoComments = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "promo_comments_total",
+		Help: "Comments added to promos.",
+	})
+
+	PromoActivations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "promo_activations_total",
+		Help: "Promo activation attempts by result: ok, antifraud_denied, antifraud_unavailable, exhausted.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(PromosCreated, PromoLikes, PromoComments, PromoActivations)
+
+	// серии с нулём видны в Prometheus ещё до первой активации
+	for _, result := range []string{ActivationOK, ActivationAntifraudDenied, ActivationAntifraudUnavailable, ActivationExhausted} {
+		PromoActivations.WithLabelValues(result)
+	}
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+	"solution/internal/shared/gormhook"
+)
+
+var (
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency by operation.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	dbErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Failed database queries by operation. Record not found is not an error.",
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(dbDuration, dbErrors)
+}
+
+const startedAtKey = "metrics:started_at"
+
+// GormPlugin измеряет длительность и ошибки запросов GORM
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	return gormhook.Register(db, "metrics", func(operation string) (before, after func(*gorm.DB)) {
+		return startTimer, observe(operation)
+	})
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startedAtKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if value, ok := db.InstanceGet(startedAtKey); ok {
+			dbDuration.WithLabelValues(operation).Observe(time.Since(value.(time.Time)).Seconds())
+		}
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			dbErrors.WithLabelValues(operation).Inc()
+		}
+	}
+}
+
+// RegisterDB подключает плагин метрик и статистику пула соединений
+func RegisterDB(db *gorm.DB) error {
+	if err := db.Use(GormPlugin{}); err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return register(collectors.NewDBStatsCollector(sqlDB, "postgres"))
+}
@@ -0,0 +1,11 @@
+package admin
+
+import "time"
+
+// Admin - оператор платформы. Регистрации нет: первый администратор создаётся из конфигурации
+type Admin struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Email     string    `gorm:"size:100;not null;unique"`
+	Password  string    `gorm:"size:255;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
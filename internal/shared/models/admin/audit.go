@@ -0,0 +1,36 @@
+package admin
+
+import "time"
+
+const (
+	ActionUserBlock      = "user.block"
+	ActionUserUnblock    = "user.unblock"
+	ActionCompanyBlock   = "company.block"
+	ActionCompanyUnblock = "company.unblock"
+	ActionPromoArchive   = "promo.archive"
+	ActionCommentRemove  = "comment.remove"
+)
+
+const (
+	TargetUser    = "user"
+	TargetCompany = "company"
+	TargetPromo   = "promo"
+	TargetComment = "comment"
+)
+
+// AuditEntry - запись журнала действий администраторов. Журнал только дополняется:
+// UPDATE и DELETE запрещены триггером в БД.
+type AuditEntry struct {
+	ID         string `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	AdminID    string `gorm:"type:uuid;not null;index"`
+	Action     string `gorm:"size:50;not null"`
+	TargetType string `gorm:"size:20;not null"`
+	TargetID   string `gorm:"type:uuid;not null;index"`
+	// Details - JSON с причиной и данными, которые действие удалило или изменило
+	Details   string    `gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (AuditEntry) TableName() string {
+	return "admin_audit_log"
+}
@@ -0,0 +1,122 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/admin"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/validation"
+)
+
+// ActionRequest - тело запросов блокировки, архивации и удаления; причина попадает в журнал
+type ActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (req *ActionRequest) Validate() error {
+	v := validation.New()
+	validation.Check(v, "reason", req.Reason, validation.Length(0, 500))
+	return v.Err()
+}
+
+// LogLevelRequest - новый уровень логирования процесса
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+func (req *LogLevelRequest) Validate() error {
+	v := validation.New()
+	validation.Check(v, "level", req.Level, validation.OneOf("debug", "info", "warn", "error"))
+	return v.Err()
+}
+
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// ActivationFilter - фильтр журнала активаций, пустые поля не ограничивают выборку
+type ActivationFilter struct {
+	PromoID string
+	UserID  string
+}
+
+type UserResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Surname   string     `json:"surname"`
+	Email     string     `json:"email"`
+	Age       int        `json:"age"`
+	Country   string     `json:"country"`
+	CreatedAt time.Time  `json:"created_at"`
+	BlockedAt *time.Time `json:"blocked_at"`
+}
+
+func NewUserResponse(user *b2c.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Surname:   user.Surname,
+		Email:     user.Email,
+		Age:       user.Age,
+		Country:   user.Country,
+		CreatedAt: user.CreatedAt,
+		BlockedAt: user.BlockedAt,
+	}
+}
+
+type CompanyResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	BlockedAt *time.Time `json:"blocked_at"`
+}
+
+func NewCompanyResponse(company *b2b.Company) CompanyResponse {
+	return CompanyResponse{
+		ID:        company.ID,
+		Name:      company.Name,
+		Email:     company.Email,
+		BlockedAt: company.BlockedAt,
+	}
+}
+
+type ActivationResponse struct {
+	ID          string    `json:"id"`
+	PromoID     string    `json:"promo_id"`
+	UserID      string    `json:"user_id"`
+	ActivatedAt time.Time `json:"activated_at"`
+}
+
+func NewActivationResponse(activation *models.PromoActivation) ActivationResponse {
+	return ActivationResponse{
+		ID:          activation.ID,
+		PromoID:     activation.PromoID,
+		UserID:      activation.UserID,
+		ActivatedAt: activation.ActivatedAt,
+	}
+}
+
+type AuditEntryResponse struct {
+	ID         string          `json:"id"`
+	AdminID    string          `json:"admin_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func NewAuditEntryResponse(entry *admin.AuditEntry) AuditEntryResponse {
+	return AuditEntryResponse{
+		ID:         entry.ID,
+		AdminID:    entry.AdminID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Details:    json.RawMessage(entry.Details),
+		CreatedAt:  entry.CreatedAt,
+	}
+}
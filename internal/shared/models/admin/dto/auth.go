@@ -0,0 +1,16 @@
+package dto
+
+import "solution/internal/shared/validation"
+
+type SignInRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Validate не применяет политику паролей: пароль администратора задаётся конфигурацией
+func (req *SignInRequest) Validate() error {
+	v := validation.New()
+	validation.Check(v, "email", req.Email, validation.Email())
+	validation.Check(v, "password", req.Password, validation.Length(1, 255))
+	return v.Err()
+}
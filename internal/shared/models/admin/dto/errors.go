@@ -0,0 +1,12 @@
+package dto
+
+import "solution/internal/shared/apperr"
+
+var (
+	ErrInvalidCredentials = apperr.New(apperr.CodeUnauthorized, "invalid credentials")
+	ErrUserNotFound       = apperr.New(apperr.CodeNotFound, "user not found")
+	ErrCompanyNotFound    = apperr.New(apperr.CodeNotFound, "company not found")
+	ErrPromoNotFound      = apperr.New(apperr.CodeNotFound, "promo not found")
+	ErrCommentNotFound    = apperr.New(apperr.CodeNotFound, "comment not found")
+	ErrPromoArchived      = apperr.New(apperr.CodeConflict, "promo is already archived")
+)
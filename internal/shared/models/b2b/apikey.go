@@ -0,0 +1,40 @@
+package b2b
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ScopePromoRead  = "promo:read"
+	ScopePromoWrite = "promo:write"
+	ScopeStatsRead  = "stats:read"
+)
+
+var ValidApiKeyScopes = map[string]struct{}{
+	ScopePromoRead:  {},
+	ScopePromoWrite: {},
+	ScopeStatsRead:  {},
+}
+
+type ApiKey struct {
+	ID         string         `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	CompanyID  string         `gorm:"type:uuid;not null;index"`
+	Name       string         `gorm:"size:100;not null"`
+	Prefix     string         `gorm:"size:16;not null"`
+	KeyHash    string         `gorm:"size:64;not null;uniqueIndex"`
+	Scopes     pq.StringArray `gorm:"type:text[]"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime"`
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
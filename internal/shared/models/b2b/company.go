@@ -1,8 +1,12 @@
 package b2b
 
+import "time"
+
 type Company struct {
 	ID       string `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
 	Name     string `gorm:"size:100;not null"`
 	Email    string `gorm:"size:100;not null;unique"`
 	Password string `gorm:"size:100;not null"`
+	// BlockedAt выставляет администратор: вход и API ключи заблокированной компании не работают
+	BlockedAt *time.Time
 }
@@ -11,6 +11,11 @@ type Date struct {
 	time.Time
 }
 
+// StartIn - начало дня даты в часовом поясе loc
+func (d Date) StartIn(loc *time.Location) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+}
+
 func (d *Date) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), "\"")
 	if s == "" || s == "null" {
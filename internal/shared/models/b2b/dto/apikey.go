@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/validation"
+	"time"
+)
+
+var (
+	ErrApiKeyNotFound = apperr.New(apperr.CodeNotFound, "api key not found")
+	ErrInvalidApiKey  = apperr.New(apperr.CodeUnauthorized, "invalid api key")
+)
+
+type ApiKeyCreateRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+type ApiKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ApiKeyCreateResponse содержит ключ в открытом виде - он возвращается только один раз
+type ApiKeyCreateResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
+
+func NewApiKeyResponse(key *b2b.ApiKey) ApiKeyResponse {
+	return ApiKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func (req *ApiKeyCreateRequest) Validate() error {
+	v := validation.New()
+	validation.Check(v, "name", req.Name, validation.Length(1, 100))
+	if validation.Check(v, "scopes", req.Scopes, validation.NotEmpty[string]()) {
+		validation.CheckEach(v, "scopes", req.Scopes, validation.OneOf(b2b.ScopePromoRead, b2b.ScopePromoWrite, b2b.ScopeStatsRead))
+	}
+	return v.Err()
+}
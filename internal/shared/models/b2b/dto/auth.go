@@ -1,16 +1,11 @@
 package dto
 
 import (
-	"errors"
-	"regexp"
-	"unicode/utf8"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/validation"
 )
 
-var (
-	ErrInvalidEmailFormat = errors.New("invalid email format")
-	ErrInvalidPassword    = errors.New("password must be at least 8 characters long and contain at least one uppercase letter, one lowercase letter, one number, and one special character")
-	ErrInvalidCompanyName = errors.New("company name must be between 5 and 50 characters long")
-)
+var ErrAccountBlocked = apperr.New(apperr.CodeForbidden, "account is blocked")
 
 type SignUpRequest struct {
 	Name     string `json:"name" binding:"required"`
@@ -29,68 +24,16 @@ type AuthResponse struct {
 }
 
 func (req *SignUpRequest) Validate() error {
-	if utf8.RuneCountInString(req.Name) < 5 || utf8.RuneCountInString(req.Name) > 50 {
-		return ErrInvalidCompanyName
-	}
-
-	if err := validateEmail(req.Email); err != nil {
-		return err
-	}
-
-	if err := validatePassword(req.Password); err != nil {
-		return err
-	}
-
-	return nil
+	v := validation.New()
+	validation.Check(v, "name", req.Name, validation.Length(5, 50))
+	validation.Check(v, "email", req.Email, validation.Email())
+	validation.Check(v, "password", req.Password, validation.Password())
+	return v.Err()
 }
 
 func (req *SignInRequest) Validate() error {
-	if err := validateEmail(req.Email); err != nil {
-		return err
-	}
-
-	if err := validatePassword(req.Password); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateEmail(email string) error {
-	if utf8.RuneCountInString(email) < 8 || utf8.RuneCountInString(email) > 120 {
-		return ErrInvalidEmail
-	}
-	re := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
-	if !re.MatchString(email) {
-		return ErrInvalidEmailFormat
-	}
-	return nil
-}
-
-func validatePassword(password string) error {
-	if utf8.RuneCountInString(password) < 8 || utf8.RuneCountInString(password) > 60 {
-		return ErrInvalidPassword
-	}
-
-	upper := regexp.MustCompile(`[A-Z]`)
-	if !upper.MatchString(password) {
-		return ErrInvalidPassword
-	}
-
-	lower := regexp.MustCompile(`[a-z]`)
-	if !lower.MatchString(password) {
-		return ErrInvalidPassword
-	}
-
-	number := regexp.MustCompile(`\d`)
-	if !number.MatchString(password) {
-		return ErrInvalidPassword
-	}
-
-	special := regexp.MustCompile(`[@$!%*?&]`)
-	if !special.MatchString(password) {
-		return ErrInvalidPassword
-	}
-
-	return nil
+	v := validation.New()
+	validation.Check(v, "email", req.Email, validation.Email())
+	validation.Check(v, "password", req.Password, validation.SignInPassword())
+	return v.Err()
 }
@@ -1,34 +1,11 @@
 package dto
 
-import "errors"
-
-type ErrorResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
-}
-
-var (
-	ErrInvalidEmail = errors.New("invalid email format")
-	ErrBadRequest   = errors.New("bad request")
-)
+import "solution/internal/shared/apperr"
 
 var (
-	ErrorUnauthorized = ErrorResponse{
-		Status:  "error",
-		Message: "Пользователь не авторизован.",
-	}
-
-	ErrorBadRequest = ErrorResponse{
-		Status:  "error",
-		Message: "Ошибка в данных запроса.",
-	}
-
-	ErrorInternalServer = ErrorResponse{
-		Status:  "error",
-		Message: "Ошибка сервера.",
-	}
+	ErrBadRequest = apperr.New(apperr.CodeBadRequest, "bad request")
 )
 
 var (
-	ErrNotFound = errors.New("no record found")
+	ErrNotFound = apperr.New(apperr.CodeNotFound, "no record found")
 )
@@ -1,24 +1,32 @@
 package dto
 
 import (
+	"encoding/json"
 	"errors"
-	"net/url"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/mergepatch"
 	models2 "solution/internal/shared/models"
 	models "solution/internal/shared/models/b2b"
-	"unicode/utf8"
+	"solution/internal/shared/validation"
+	"time"
 )
 
 var (
-	ErrInvalidPromoMode     = errors.New("promo mode must be either 'COMMON' or 'UNIQUE'")
-	ErrInvalidPromoCode     = errors.New("promo code must be between 5 and 30 characters long")
-	ErrInvalidPromoMaxCount = errors.New("max count must be greater than 0")
-	ErrPromoUniqueTooShort  = errors.New("each promo_unique must be between 3 and 30 characters long")
-	ErrPromoUniqueRequired  = errors.New("at least one promo_unique is required for UNIQUE mode")
-	ErrPromoCommonRequired  = errors.New("promo_common is required for COMMON mode")
-	ErrPromoUniqueNotUnique = errors.New("promo_unique values must be unique")
-	ErrorPromoNotFound      = errors.New("promo not found")
-	ErrorNoAccess           = errors.New("no access to this resource")
-	ErrorNoAccessToPromo    = errors.New("no access to promo")
+	ErrorPromoNotFound   = apperr.New(apperr.CodeNotFound, "promo not found")
+	ErrorNoAccess        = apperr.New(apperr.CodeForbidden, "no access to this resource")
+	ErrorNoAccessToPromo = apperr.New(apperr.CodeForbidden, "no access to promo")
+	ErrorPromoModified   = apperr.New(apperr.CodePreconditionFailed, "promo was modified, reload it and retry")
+	// ErrorMaxCountBelowUsed - лимит активаций общего кода нельзя опустить ниже уже сделанных активаций
+	ErrorMaxCountBelowUsed = apperr.Validation(apperr.Field("max_count", "must not be less than the number of activations"))
+)
+
+var (
+	ErrorPromoCodeUsed     = apperr.New(apperr.CodePromoCodeUsed, "promo code is already in this promo or was issued before")
+	ErrorPromoCodeIssued   = apperr.New(apperr.CodePromoCodeIssued, "promo code was already issued and cannot be removed")
+	ErrorPromoCodeNotFound = apperr.New(apperr.CodePromoCodeNotFound, "promo code not found in promo")
+	ErrorPromoModeLocked   = apperr.New(apperr.CodePromoModeLocked, "mode can be changed only before the first activation")
+	// ErrorPromoActivated - промокод активировали между проверкой и записью изменения кодов
+	ErrorPromoActivated = apperr.New(apperr.CodeConflict, "promo was activated during the change, retry")
 )
 
 type Country struct {
@@ -26,8 +34,8 @@ type Country struct {
 }
 
 type CountryActivation struct {
-	Country     Country `json:"country"`
-	Activations int     `json:"activations_count"`
+	Country     Country `json:"country" gorm:"embedded;embeddedPrefix:country_"`
+	Activations int     `json:"activations_count" gorm:"column:activations_count"`
 }
 
 type PromoStatResponse struct {
@@ -36,165 +44,248 @@ type PromoStatResponse struct {
 }
 
 type PromoCreateRequest struct {
-	CompanyID   string          `json:"company_id"`
-	Description string          `json:"description" binding:"required"`
-	ImageURL    string          `json:"image_url"`
-	Mode        string          `json:"mode" binding:"required"`
-	PromoCommon string          `json:"promo_common,omitempty"`
-	PromoUnique []string        `json:"promo_unique,omitempty"`
-	Target      *models2.Target `json:"target" binding:"required"`
-	MaxCount    *int            `json:"max_count" binding:"required"`
-	ActiveFrom  *models.Date    `json:"active_from,omitempty"`
-	ActiveUntil *models.Date    `json:"active_until,omitempty"`
+	CompanyID   string                    `json:"company_id"`
+	Description string                    `json:"description" binding:"required"`
+	ImageURL    string                    `json:"image_url"`
+	Mode        string                    `json:"mode" binding:"required"`
+	PromoCommon string                    `json:"promo_common,omitempty"`
+	PromoUnique []string                  `json:"promo_unique,omitempty"`
+	Target      *models2.Target           `json:"target" binding:"required"`
+	MaxCount    *int                      `json:"max_count" binding:"required"`
+	Limits      *models2.ActivationLimits `json:"limits,omitempty"`
+	ActiveFrom  *models.Date              `json:"active_from,omitempty"`
+	ActiveUntil *models.Date              `json:"active_until,omitempty"`
+	Timezone    string                    `json:"timezone,omitempty"`
 }
 
 type PromoReadOnlyResponse struct {
-	Description string         `json:"description" binding:"required"`
-	ImageURL    string         `json:"image_url"`
-	Target      models2.Target `json:"target" binding:"required"` // Use models.Target
-	MaxCount    int            `json:"max_count" binding:"required"`
-	ActiveFrom  *models.Date   `json:"active_from"`
-	ActiveUntil *models.Date   `json:"active_until"`
-	Mode        string         `json:"mode" binding:"required"`
-	PromoCommon string         `json:"promo_common,omitempty"`
-	PromoUnique []string       `json:"promo_unique,omitempty"`
-	PromoId     string         `json:"promo_id" binding:"required"`
-	CompanyID   string         `json:"company_id" binding:"required"`
-	CompanyName string         `json:"company_name" binding:"required"`
-	LikeCount   int            `json:"like_count" binding:"required"`
-	UsedCount   int            `json:"used_count" binding:"required"`
-	Active      bool           `json:"active" binding:"required"`
-}
-
-type PromoPatchRequest struct {
-	Description string          `json:"description,omitempty" `
-	ImageURL    string          `json:"image_url,omitempty"`
-	Target      *models2.Target `json:"target,omitempty"`
-	MaxCount    *int            `json:"max_count,omitempty"`
-	ActiveFrom  *models.Date    `json:"active_from,omitempty"`
-	ActiveUntil *models.Date    `json:"active_until,omitempty"`
-}
-
-func (req *PromoPatchRequest) Validate() error {
-	if req.Description != "" {
-		length := utf8.RuneCountInString(req.Description)
-		if length < 10 || length > 300 {
-			return errors.New("description must be between 10 and 300 characters long")
-		}
-	}
+	Description string                   `json:"description" binding:"required"`
+	ImageURL    string                   `json:"image_url"`
+	Target      models2.Target           `json:"target" binding:"required"` // Use models.Target
+	MaxCount    int                      `json:"max_count" binding:"required"`
+	Limits      models2.ActivationLimits `json:"limits"`
+	ActiveFrom  *models.Date             `json:"active_from"`
+	ActiveUntil *models.Date             `json:"active_until"`
+	Timezone    string                   `json:"timezone"`
+	Mode        string                   `json:"mode" binding:"required"`
+	PromoCommon string                   `json:"promo_common,omitempty"`
+	PromoUnique []string                 `json:"promo_unique,omitempty"`
+	PromoId     string                   `json:"promo_id" binding:"required"`
+	CompanyID   string                   `json:"company_id" binding:"required"`
+	CompanyName string                   `json:"company_name" binding:"required"`
+	LikeCount   int                      `json:"like_count" binding:"required"`
+	UsedCount   int                      `json:"used_count" binding:"required"`
+	Active      bool                     `json:"active" binding:"required"`
+	Version     int                      `json:"-"`
+}
 
-	if req.ImageURL != "" {
-		if len(req.ImageURL) > 350 {
-			return errors.New("image_url must be at most 350 characters long")
-		}
-		if _, err := url.ParseRequestURI(req.ImageURL); err != nil {
-			return errors.New("image_url must be a valid URL")
-		}
+// NewPromoReadOnlyResponse собирает промокод в формате PromoReadOnly из api.yml
+func NewPromoReadOnlyResponse(promo *models2.Promo, companyName string) *PromoReadOnlyResponse {
+	promo.SetActiveStatus()
+	return &PromoReadOnlyResponse{
+		Description: promo.Description,
+		ImageURL:    promo.ImageURL,
+		Target:      promo.Target,
+		MaxCount:    promo.MaxCount,
+		Limits:      promo.Limits,
+		ActiveFrom:  promo.ActiveFrom,
+		ActiveUntil: promo.ActiveUntil,
+		Timezone:    promo.Timezone,
+		Mode:        promo.Mode,
+		PromoCommon: promo.PromoCommon,
+		PromoUnique: promo.PromoUnique,
+		PromoId:     promo.ID,
+		CompanyID:   promo.CompanyID,
+		CompanyName: companyName,
+		LikeCount:   promo.LikeCount,
+		UsedCount:   promo.UsedCount,
+		Active:      promo.Active,
+		Version:     promo.Version,
 	}
+}
 
-	if req.Target != nil {
-		if err := req.Target.Validate(); err != nil {
-			return err
-		}
+// PromoChangeResponse - запись истории промокода
+type PromoChangeResponse struct {
+	ID        string          `json:"id"`
+	Action    string          `json:"action"`
+	Actor     models2.Actor   `json:"actor"`
+	Changes   json.RawMessage `json:"changes"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func NewPromoChangeResponse(change *models2.PromoChange) *PromoChangeResponse {
+	return &PromoChangeResponse{
+		ID:        change.ID,
+		Action:    change.Action,
+		Actor:     models2.Actor{Type: change.ActorType, ID: change.ActorID},
+		Changes:   json.RawMessage(change.Changes),
+		CreatedAt: change.CreatedAt,
 	}
+}
 
-	if req.MaxCount != nil {
-		if *req.MaxCount < 0 || *req.MaxCount > 100000000 {
-			return errors.New("max_count must be between 0 and 100,000,000")
-		}
+// PromoUpdate - редактируемые поля промокода после применения JSON Merge Patch (RFC 7396).
+// Записывается целиком: пустые значения очищают поля.
+type PromoUpdate struct {
+	Description string                   `json:"description"`
+	ImageURL    string                   `json:"image_url"`
+	Target      models2.Target           `json:"target"`
+	MaxCount    *int                     `json:"max_count"`
+	Limits      models2.ActivationLimits `json:"limits"`
+	ActiveFrom  *models.Date             `json:"active_from"`
+	ActiveUntil *models.Date             `json:"active_until"`
+	Timezone    string                   `json:"timezone"`
+	// Mode не редактируется, но ограничивает max_count
+	Mode string `json:"-"`
+	// ExpectedVersion - версия, к которой применялся патч; 0 - без проверки
+	ExpectedVersion int `json:"-"`
+}
+
+const maxPromoCount = 100000000
+
+// MergePromoPatch применяет patch к текущему промокоду и проверяет результат слияния, а не сам патч:
+// null в патче очищает поле, а обязательные поля нельзя очистить
+func MergePromoPatch(promo *models2.Promo, patch []byte) (*PromoUpdate, error) {
+	current := PromoUpdate{
+		Description: promo.Description,
+		ImageURL:    promo.ImageURL,
+		Target:      promo.Target,
+		MaxCount:    &promo.MaxCount,
+		Limits:      promo.Limits,
+		ActiveFrom:  promo.ActiveFrom,
+		ActiveUntil: promo.ActiveUntil,
+		Timezone:    promo.Timezone,
 	}
 
-	if req.ActiveFrom != nil && req.ActiveUntil != nil {
-		if req.ActiveFrom.Time.After(req.ActiveUntil.Time) {
-			return errors.New("active_from cannot be after active_until")
+	var update PromoUpdate
+	if err := mergepatch.Merge(current, patch, &update); err != nil {
+		if errors.Is(err, mergepatch.ErrNotObject) {
+			return nil, apperr.Validation(apperr.Field("", err.Error()))
 		}
+		return nil, apperr.Binding(err)
 	}
+	update.Mode = promo.Mode
 
-	return nil
+	if err := update.Validate(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+	return &update, nil
 }
 
-func (req *PromoCreateRequest) Validate() error {
-	if err := validatePromoMode(req.Mode); err != nil {
-		return err
+func (u *PromoUpdate) Validate() error {
+	v := validation.New()
+	validation.Check(v, "description", u.Description, validation.Required(), validation.Length(10, 300))
+	if u.ImageURL != "" {
+		validation.Check(v, "image_url", u.ImageURL, validation.URL(350))
 	}
-
-	if req.Description != "" {
-		length := utf8.RuneCountInString(req.Description)
-		if length < 10 || length > 300 {
-			return errors.New("description must be between 10 and 300 characters long")
-		}
+	v.Nest("target", u.Target.Validate())
+	if u.MaxCount == nil {
+		v.Add("max_count", "is required")
+	} else if validation.Check(v, "max_count", *u.MaxCount, validation.Range(0, maxPromoCount)) && u.Mode == "UNIQUE" && *u.MaxCount > 1 {
+		v.Add("max_count", "must be 1 for UNIQUE mode")
 	}
-	if req.ImageURL != "" {
-		if len(req.ImageURL) > 350 {
-			return errors.New("image_url must be at most 350 characters long")
-		}
-		if _, err := url.ParseRequestURI(req.ImageURL); err != nil {
-			return errors.New("image_url must be a valid URL")
-		}
+	v.Nest("limits", u.Limits.Validate())
+	validateActivePeriod(v, u.ActiveFrom, u.ActiveUntil)
+	if u.Timezone != "" {
+		validation.Check(v, "timezone", u.Timezone, validation.Timezone())
 	}
+	return v.Err()
+}
 
-	switch req.Mode {
-	case "COMMON":
-		if req.PromoCommon == "" {
-			return ErrPromoCommonRequired
-		}
-
-		if utf8.RuneCountInString(req.PromoCommon) < 5 || utf8.RuneCountInString(req.PromoCommon) > 30 {
-			return ErrInvalidPromoCode
-		}
-
-	case "UNIQUE":
-		if len(req.PromoUnique) == 0 {
-			return ErrPromoUniqueRequired
-		}
+func (req *PromoCreateRequest) Validate() error {
+	v := validation.New()
+	validation.Check(v, "description", req.Description, validation.Length(10, 300))
+	if req.ImageURL != "" {
+		validation.Check(v, "image_url", req.ImageURL, validation.URL(350))
+	}
 
-		for _, code := range req.PromoUnique {
-			if utf8.RuneCountInString(code) < 3 || utf8.RuneCountInString(code) > 30 {
-				return ErrPromoUniqueTooShort
+	if validation.Check(v, "mode", req.Mode, validation.OneOf("COMMON", "UNIQUE")) {
+		switch req.Mode {
+		case "COMMON":
+			validation.Check(v, "promo_common", req.PromoCommon, validation.Required(), validation.Length(5, 30))
+		case "UNIQUE":
+			if validation.Check(v, "promo_unique", req.PromoUnique, validation.NotEmpty[string]()) &&
+				validation.CheckEach(v, "promo_unique", req.PromoUnique, validation.Length(3, 30)) {
+				validation.Check(v, "promo_unique", req.PromoUnique, validation.Unique())
 			}
-		}
-
-		uniqueCodes := make(map[string]struct{})
-		for _, code := range req.PromoUnique {
-			if _, exists := uniqueCodes[code]; exists {
-				return ErrPromoUniqueNotUnique
+			if req.MaxCount != nil && *req.MaxCount > 1 {
+				v.Add("max_count", "must be 1 for UNIQUE mode")
 			}
-			uniqueCodes[code] = struct{}{}
 		}
+	}
 
-	default:
-		return ErrInvalidPromoMode
+	validation.CheckPtr(v, "max_count", req.MaxCount, validation.Range(0, maxPromoCount))
+	validateActivePeriod(v, req.ActiveFrom, req.ActiveUntil)
+	if req.Timezone != "" {
+		validation.Check(v, "timezone", req.Timezone, validation.Timezone())
 	}
-	if req.MaxCount != nil {
-		if req.Mode == "UNIQUE" && *req.MaxCount > 1 {
-			return ErrBadRequest
-		}
+	if req.Target != nil {
+		v.Nest("target", req.Target.Validate())
 	}
-
-	if req.MaxCount != nil {
-		if *req.MaxCount < 0 || *req.MaxCount > 100000000 {
-			return errors.New("max_count must be between 0 and 100,000,000")
-		}
+	if req.Limits != nil {
+		v.Nest("limits", req.Limits.Validate())
 	}
+	return v.Err()
+}
 
-	if req.ActiveFrom != nil && req.ActiveUntil != nil {
-		if req.ActiveFrom.Time.After(req.ActiveUntil.Time) {
-			return errors.New("active_from cannot be after active_until")
-		}
-	}
+// PromoCodesRequest - изменение кодов промокода: ротация общего кода, добавление и удаление
+// уникальных кодов, смена режима до первой активации
+type PromoCodesRequest struct {
+	Mode         string   `json:"mode,omitempty"`
+	PromoCommon  string   `json:"promo_common,omitempty"`
+	AddUnique    []string `json:"add_unique,omitempty"`
+	RemoveUnique []string `json:"remove_unique,omitempty"`
+	MaxCount     *int     `json:"max_count,omitempty"`
+}
 
-	if req.Target != nil {
-		if err := req.Target.Validate(); err != nil {
-			return err
-		}
+// Validate проверяет формат запроса; допустимость изменения для текущего промокода проверяет сервис
+func (req *PromoCodesRequest) Validate() error {
+	v := validation.New()
+	if req.Mode != "" {
+		validation.Check(v, "mode", req.Mode, validation.OneOf("COMMON", "UNIQUE"))
+	}
+	if req.PromoCommon != "" {
+		validation.Check(v, "promo_common", req.PromoCommon, validation.Length(5, 30))
+	}
+	if validation.CheckEach(v, "add_unique", req.AddUnique, validation.Length(3, 30)) {
+		validation.Check(v, "add_unique", req.AddUnique, validation.Unique())
 	}
+	validation.Check(v, "remove_unique", req.RemoveUnique, validation.Unique())
+	validation.CheckPtr(v, "max_count", req.MaxCount, validation.Range(0, maxPromoCount))
+	return v.Err()
+}
 
-	return nil
+// PromoCodesUpdate - новое состояние кодов промокода. Сервис проверял его для UsedCount активаций,
+// поэтому запись проходит, только если число активаций с тех пор не изменилось.
+type PromoCodesUpdate struct {
+	Mode         string
+	PromoCommon  string
+	PromoUnique  []string
+	RetiredCodes []string
+	MaxCount     int
+	UsedCount    int
+	// ExpectedVersion - версия, к которой применялось изменение, 0 - без проверки
+	ExpectedVersion int
 }
 
-func validatePromoMode(mode string) error {
-	if mode != "COMMON" && mode != "UNIQUE" {
-		return ErrInvalidPromoMode
+func validateActivePeriod(v *validation.Validator, from, until *models.Date) {
+	if from != nil && until != nil && from.Time.After(until.Time) {
+		v.Add("active_from", "must not be after active_until")
 	}
-	return nil
+}
+
+type AudienceAgeGroup struct {
+	AgeFrom  int   `json:"age_from"`
+	AgeUntil int   `json:"age_until"`
+	Users    int64 `json:"users_count"`
+}
+
+type AudienceCountry struct {
+	Country string `json:"country"`
+	Users   int64  `json:"users_count"`
+}
+
+type AudienceEstimateResponse struct {
+	UsersCount int64              `json:"users_count"`
+	Countries  []AudienceCountry  `json:"countries"`
+	AgeGroups  []AudienceAgeGroup `json:"age_groups"`
 }
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/targeting"
+)
+
+var (
+	ErrPromoInactive        = apperr.New(apperr.CodePromoInactive, "promo is not active")
+	ErrPromoExhausted       = apperr.New(apperr.CodePromoExhausted, "all promo activations are used")
+	ErrPromoNotTargeted     = apperr.New(apperr.CodePromoNotTargeted, "promo is not available for this user")
+	ErrActivationDenied     = apperr.New(apperr.CodeActivationDenied, "antifraud denied the activation")
+	ErrAntifraudUnavailable = apperr.New(apperr.CodeUnavailable, "antifraud is unavailable, retry the activation later")
+	ErrActivationLimit      = apperr.New(apperr.CodeActivationLimit, "user has reached the activation limit of this promo")
+	ErrActivationCooldown   = apperr.New(apperr.CodeActivationCooldown, "activation cooldown has not passed yet")
+	ErrActivationDailyLimit = apperr.New(apperr.CodeActivationDailyLimit, "daily activation limit of this promo is reached")
+)
+
+type ActivationResponse struct {
+	Promo string `json:"promo"`
+}
+
+// CheckActivation проверяет, что пользователь может получить код промокода в момент now без учёта limits:
+// промокод не исчерпан, действует и подходит пользователю по таргетингу
+func CheckActivation(promo *models.Promo, user *b2c.User, now time.Time) error {
+	if promo.Exhausted() {
+		return ErrPromoExhausted
+	}
+	if !promo.IsActiveAt(now) {
+		return ErrPromoInactive
+	}
+	if !targeting.Matches(promo.Target, targeting.AudienceOf(user)) {
+		return ErrPromoNotTargeted
+	}
+	return nil
+}
+
+// LimitError - ошибка для нарушенного ограничения активаций, nil - ограничения соблюдены
+func LimitError(limit models.Limit) error {
+	switch limit {
+	case models.LimitPerUser:
+		return ErrActivationLimit
+	case models.LimitCooldown:
+		return ErrActivationCooldown
+	case models.LimitDaily:
+		return ErrActivationDailyLimit
+	}
+	return nil
+}
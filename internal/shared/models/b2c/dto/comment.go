@@ -1,14 +1,15 @@
 package dto
 
+import "solution/internal/shared/validation"
+
 type CommentRequest struct {
 	Text string `json:"text" binding:"required,min=10,max=1000"`
 }
 
 func (req *CommentRequest) Validate() error {
-	if len(req.Text) < 10 || len(req.Text) > 1000 {
-		return ErrBadRequest
-	}
-	return nil
+	v := validation.New()
+	validation.Check(v, "text", req.Text, validation.Length(10, 1000))
+	return v.Err()
 }
 
 type CommentResponse struct {
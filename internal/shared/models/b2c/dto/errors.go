@@ -1,10 +1,14 @@
 package dto
 
-import "errors"
+import (
+	"errors"
+	"solution/internal/shared/apperr"
+)
 
 var (
 	ErrNoFieldsToUpdate = errors.New("no fields to update")
-	ErrNotFound         = errors.New("no record found")
-	ErrNoAccess         = errors.New("no access to this resource")
-	ErrBadRequest       = errors.New("bad request")
+	ErrNotFound         = apperr.New(apperr.CodeNotFound, "no record found")
+	ErrNoAccess         = apperr.New(apperr.CodeForbidden, "no access to this resource")
+	ErrBadRequest       = apperr.New(apperr.CodeBadRequest, "bad request")
+	ErrProfileModified  = apperr.New(apperr.CodePreconditionFailed, "profile was modified, reload it and retry")
 )
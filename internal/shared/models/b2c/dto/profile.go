@@ -1,7 +1,12 @@
 package dto
 
 import (
-	"unicode/utf8"
+	"errors"
+
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/mergepatch"
+	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/validation"
 )
 
 type ProfileResponse struct {
@@ -10,6 +15,7 @@ type ProfileResponse struct {
 	Email     string             `json:"email" binding:"required"`
 	AvatarURL string             `json:"avatar_url"`
 	Other     UserTargetSettings `json:"other" binding:"required"`
+	Version   int                `json:"-"`
 }
 
 type ProfileUpdateRequest struct {
@@ -17,32 +23,61 @@ type ProfileUpdateRequest struct {
 	Surname   *string `json:"surname,omitempty"`
 	AvatarURL *string `json:"avatar_url,omitempty"`
 	Password  *string `json:"password,omitempty"`
+	// ExpectedVersion - версия из If-Match, 0 - без проверки
+	ExpectedVersion int `json:"-"`
 }
 
-func (req *ProfileUpdateRequest) Validate() error {
-	if req.Name != nil {
-		if utf8.RuneCountInString(*req.Name) < 1 || utf8.RuneCountInString(*req.Name) > 100 {
-			return ErrInvalidName
-		}
+// profileDocument - профиль в том виде, к которому применяется JSON Merge Patch.
+// Пароль в профиле не возвращается, но патч может его задать.
+type profileDocument struct {
+	Name      string  `json:"name"`
+	Surname   string  `json:"surname"`
+	AvatarURL string  `json:"avatar_url"`
+	Password  *string `json:"password,omitempty"`
+}
+
+// MergeProfilePatch применяет JSON Merge Patch к профилю и проверяет результат целиком.
+// В запрос попадают только изменившиеся поля: патч без изменений даёт ErrNoFieldsToUpdate.
+func MergeProfilePatch(user *b2c.User, patch []byte) (*ProfileUpdateRequest, error) {
+	current := profileDocument{
+		Name:      user.Name,
+		Surname:   user.Surname,
+		AvatarURL: user.AvatarURL,
 	}
 
-	if req.Surname != nil {
-		if utf8.RuneCountInString(*req.Surname) < 1 || utf8.RuneCountInString(*req.Surname) > 120 {
-			return ErrInvalidSurname
+	var merged profileDocument
+	if err := mergepatch.Merge(current, patch, &merged); err != nil {
+		if errors.Is(err, mergepatch.ErrNotObject) {
+			return nil, apperr.Validation(apperr.Field("", err.Error()))
 		}
+		return nil, apperr.Binding(err)
 	}
 
-	if req.Password != nil {
-		if err := validatePassword(*req.Password); err != nil {
-			return err
-		}
+	if err := merged.validate(); err != nil {
+		return nil, apperr.Validation(err)
 	}
 
-	if req.AvatarURL != nil {
-		if err := validateAvatarURL(*req.AvatarURL); err != nil {
-			return err
-		}
+	var req ProfileUpdateRequest
+	if merged.Name != user.Name {
+		req.Name = &merged.Name
+	}
+	if merged.Surname != user.Surname {
+		req.Surname = &merged.Surname
+	}
+	if merged.AvatarURL != user.AvatarURL {
+		req.AvatarURL = &merged.AvatarURL
 	}
+	req.Password = merged.Password
+	return &req, nil
+}
 
-	return nil
+func (d *profileDocument) validate() error {
+	v := validation.New()
+	validation.Check(v, "name", d.Name, validation.Required(), validation.Length(1, 100))
+	validation.Check(v, "surname", d.Surname, validation.Required(), validation.Length(1, 120))
+	if d.AvatarURL != "" {
+		validation.Check(v, "avatar_url", d.AvatarURL, validation.URL(350))
+	}
+	validation.CheckPtr(v, "password", d.Password, validation.Password())
+	return v.Err()
 }
@@ -12,3 +12,21 @@ type PromoForUser struct {
 	IsLikedByUser     bool   `json:"is_liked_by_user" binding:"required"`
 	CommentCount      int64  `json:"comment_count" binding:"required"`
 }
+
+type PromoSearchResult struct {
+	PromoForUser
+	Rank      float64 `json:"rank"`
+	Highlight string  `json:"highlight"`
+}
+
+const (
+	FeedSortRecent    = "recent"
+	FeedSortRelevance = "relevance"
+)
+
+// FeedRanking - входные данные персонализированной сортировки ленты
+type FeedRanking struct {
+	Segment          string
+	CategoryAffinity map[string]float64
+	AffinityWeight   float64
+}
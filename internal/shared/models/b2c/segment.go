@@ -0,0 +1,45 @@
+package b2c
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Возрастные границы сегментов (нижняя граница включительно)
+var segmentAgeBounds = []int{0, 18, 25, 35, 45, 55}
+
+// UserSegment - группа пользователей одной страны и возрастного диапазона,
+// для которой периодически предрасчитывается скор промокодов
+type UserSegment struct {
+	Country  string
+	AgeFrom  int
+	AgeUntil int
+}
+
+func SegmentOf(age int, country string) UserSegment {
+	segment := UserSegment{Country: strings.ToLower(country), AgeUntil: 100}
+	for i, bound := range segmentAgeBounds {
+		if age < bound {
+			break
+		}
+		segment.AgeFrom = bound
+		if i+1 < len(segmentAgeBounds) {
+			segment.AgeUntil = segmentAgeBounds[i+1] - 1
+		} else {
+			segment.AgeUntil = 100
+		}
+	}
+	return segment
+}
+
+func (s UserSegment) Key() string {
+	return fmt.Sprintf("%s:%d-%d", s.Country, s.AgeFrom, s.AgeUntil)
+}
+
+type PromoSegmentScore struct {
+	Segment    string    `gorm:"size:32;primaryKey"`
+	PromoID    string    `gorm:"type:uuid;primaryKey"`
+	Score      float64   `gorm:"not null"`
+	ComputedAt time.Time `gorm:"not null;index"`
+}
@@ -1,12 +1,20 @@
 package b2c
 
+import "time"
+
 type User struct {
-	ID        string `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	Name      string `gorm:"size:100;not null"`
-	Surname   string `gorm:"size:120;not null"`
-	Email     string `gorm:"size:100;not null;unique"`
-	Password  string `gorm:"size:255;not null"`
-	AvatarURL string `gorm:"size:350"`
-	Age       int    `gorm:"not null"`
-	Country   string `gorm:"size:100;not null"`
+	ID        string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Name      string    `gorm:"size:100;not null"`
+	Surname   string    `gorm:"size:120;not null"`
+	Email     string    `gorm:"size:100;not null;unique"`
+	Password  string    `gorm:"size:255;not null"`
+	AvatarURL string    `gorm:"size:350"`
+	Age       int       `gorm:"not null"`
+	Country   string    `gorm:"size:100;not null"`
+	Gender    string    `gorm:"size:10"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	// Version растёт с каждым изменением профиля, по ней считается ETag
+	Version int `gorm:"not null;default:1"`
+	// BlockedAt выставляет администратор: заблокированный пользователь не может войти
+	BlockedAt *time.Time
 }
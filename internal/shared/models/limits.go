@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"solution/internal/shared/validation"
+)
+
+const (
+	maxActivations = 100000000
+	// maxCooldownSeconds - интервал между активациями одного пользователя не больше года
+	maxCooldownSeconds = 365 * 24 * 60 * 60
+)
+
+// ActivationLimits - ограничения активаций промокода сверх max_count, 0 - без ограничения.
+// Хранятся в колонках promos с префиксом limit_.
+type ActivationLimits struct {
+	// PerUser - сколько раз один пользователь может активировать промокод
+	PerUser int `gorm:"not null;default:0" json:"per_user,omitempty"`
+	// CooldownSeconds - сколько секунд пользователь ждёт между своими активациями
+	CooldownSeconds int `gorm:"not null;default:0" json:"cooldown_seconds,omitempty"`
+	// Daily - сколько активаций всеми пользователями допускается за календарные сутки в часовом поясе промокода
+	Daily int `gorm:"not null;default:0" json:"daily,omitempty"`
+}
+
+func (l *ActivationLimits) Validate() error {
+	v := validation.New()
+	validation.Check(v, "per_user", l.PerUser, validation.Range(0, maxActivations))
+	validation.Check(v, "cooldown_seconds", l.CooldownSeconds, validation.Range(0, maxCooldownSeconds))
+	validation.Check(v, "daily", l.Daily, validation.Range(0, maxActivations))
+	return v.Err()
+}
+
+// Limit - ограничение активаций, которое не позволяет активировать промокод
+type Limit string
+
+const (
+	LimitPerUser  Limit = "per_user"
+	LimitCooldown Limit = "cooldown"
+	LimitDaily    Limit = "daily"
+)
+
+// ActivationUsage - уже сделанные активации промокода, от которых зависят ограничения
+type ActivationUsage struct {
+	// UserCount и LastByUser - активации промокода этим пользователем
+	UserCount  int
+	LastByUser *time.Time
+	// Today - активации всеми пользователями с начала текущих суток в часовом поясе промокода
+	Today int
+}
+
+// Check возвращает нарушенное ограничение для ещё одной активации в момент now или пустую строку.
+// То же правило проверяет Redis до транзакции, см. b2c.ActivationLimiter.
+func (l ActivationLimits) Check(usage ActivationUsage, now time.Time) Limit {
+	if l.PerUser > 0 && usage.UserCount >= l.PerUser {
+		return LimitPerUser
+	}
+	if l.CooldownSeconds > 0 && usage.LastByUser != nil && now.Before(usage.LastByUser.Add(l.Cooldown())) {
+		return LimitCooldown
+	}
+	if l.Daily > 0 && usage.Today >= l.Daily {
+		return LimitDaily
+	}
+	return ""
+}
+
+func (l ActivationLimits) Cooldown() time.Duration {
+	return time.Duration(l.CooldownSeconds) * time.Second
+}
+
+// DayStart - начало суток, в которые попадает now, в часовом поясе промокода. От него считается limits.daily.
+func (p *Promo) DayStart(now time.Time) time.Time {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil || p.Timezone == "" {
+		loc = time.UTC
+	}
+	year, month, day := now.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc).UTC()
+}
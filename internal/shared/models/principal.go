@@ -0,0 +1,68 @@
+package models
+
+import (
+	"context"
+	"solution/internal/service/services"
+)
+
+// Principal - субъект текущего запроса. Регистрируется как scoped сервис
+// и заполняется middleware авторизации.
+type Principal struct {
+	CompanyID string
+	UserID    string
+	AdminID   string
+	// ApiKeyID и Scopes заполняются только при авторизации по API ключу
+	ApiKeyID string
+	Scopes   []string
+}
+
+// CurrentPrincipal возвращает субъекта из области scoped сервисов запроса
+func CurrentPrincipal(ctx context.Context) (*Principal, error) {
+	var principal *Principal
+	if err := services.GetServiceForContext(ctx, &principal); err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+func (p *Principal) IsCompany() bool {
+	return p.CompanyID != ""
+}
+
+func (p *Principal) IsUser() bool {
+	return p.UserID != ""
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.AdminID != ""
+}
+
+// IsApiKey - запрос компании авторизован API ключом, а не токеном
+func (p *Principal) IsApiKey() bool {
+	return p.ApiKeyID != ""
+}
+
+// HasScope проверяет скоуп API ключа; у токена компании ограничений нет
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsApiKey() {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Actor - автор изменения для истории: API ключ, администратор или компания
+func (p *Principal) Actor() Actor {
+	switch {
+	case p.IsApiKey():
+		return Actor{Type: ActorApiKey, ID: p.ApiKeyID}
+	case p.IsAdmin():
+		return Actor{Type: ActorAdmin, ID: p.AdminID}
+	default:
+		return Actor{Type: ActorCompany, ID: p.CompanyID}
+	}
+}
@@ -1,15 +1,9 @@
 package models
 
 import (
-	"database/sql/driver"
-	"encoding/json"
-	"errors"
-	"fmt"
 	"github.com/lib/pq"
 	"solution/internal/shared/models/b2b"
-	"strings"
 	"time"
-	"unicode/utf8"
 )
 
 type Promo struct {
@@ -26,94 +20,96 @@ type Promo struct {
 	ActiveUntil *b2b.Date      `json:"active_until,omitempty"`
 	LikeCount   int            `json:"like_count,required"`
 	UsedCount   int            `json:"used_count,required"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"-"`
-	Active      bool           `gorm:"-" json:"active"`
+	// Timezone - часовой пояс IANA, в котором заданы active_from и active_until
+	Timezone string `gorm:"size:64;not null;default:'UTC'" json:"timezone"`
+	// StartsAt и EndsAt - окно действия в абсолютном времени, EndsAt в окно не входит. Считаются UpdateWindow.
+	StartsAt *time.Time `json:"-"`
+	EndsAt   *time.Time `json:"-"`
+	// Limits - ограничения активаций на пользователя и на сутки сверх max_count
+	Limits ActivationLimits `json:"limits" gorm:"embedded;embeddedPrefix:limit_"`
+	// Version растёт с каждым изменением промокода компанией или администратором, по ней считается ETag
+	Version   int       `gorm:"not null;default:1" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+	// RetiredCodes - общие коды, которые выдавались пользователям до ротации. Повторно их использовать нельзя.
+	RetiredCodes pq.StringArray `gorm:"type:text[]" json:"-"`
+	// ArchivedAt выставляет администратор: архивный промокод неактивен и скрыт от пользователей
+	ArchivedAt *time.Time `json:"-"`
+	Active     bool       `gorm:"-" json:"active"`
 }
 
-func (p *Promo) SetActiveStatus() {
-	currentTime := time.Now().UTC()
-	isActive := true
-
-	if p.ActiveFrom != nil && currentTime.Before(p.ActiveFrom.Time) {
-		isActive = false
+// DefaultTimezone - часовой пояс промокода, если компания его не указала
+const DefaultTimezone = "UTC"
+
+// ActiveCondition - IsActiveAt в виде SQL условия над таблицей promos, момент времени передаётся
+// именованным параметром @now. Оба правила опираются на окно starts_at/ends_at из UpdateWindow.
+const ActiveCondition = `promos.archived_at IS NULL AND
+    (promos.starts_at IS NULL OR promos.starts_at <= @now) AND
+    (promos.ends_at IS NULL OR promos.ends_at > @now) AND
+    (
+        (promos.mode = 'COMMON' AND promos.used_count < promos.max_count) OR
+        (promos.mode = 'UNIQUE' AND promos.used_count < COALESCE(array_length(promos.promo_unique, 1), 0))
+    )`
+
+// UpdateWindow пересчитывает окно действия по датам и часовому поясу промокода: active_from действует
+// с начала дня, active_until - до конца дня включительно. Вызывается при каждом изменении дат или пояса.
+func (p *Promo) UpdateWindow() error {
+	if p.Timezone == "" {
+		p.Timezone = DefaultTimezone
 	}
-
-	if p.ActiveUntil != nil && currentTime.After(p.ActiveUntil.Time) {
-		isActive = false
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return err
 	}
 
-	if p.Mode == "COMMON" {
-		if p.MaxCount == 0 || p.UsedCount >= p.MaxCount {
-			isActive = false
-		}
-	} else if p.Mode == "UNIQUE" {
-		promoUniqueCount := len(p.PromoUnique)
-		if promoUniqueCount == 0 || p.UsedCount >= promoUniqueCount {
-			isActive = false
-		}
+	p.StartsAt, p.EndsAt = nil, nil
+	if p.ActiveFrom != nil && !p.ActiveFrom.IsZero() {
+		startsAt := p.ActiveFrom.StartIn(loc).UTC()
+		p.StartsAt = &startsAt
 	}
-
-	p.Active = isActive
-}
-
-type Target struct {
-	AgeFrom    *int     `json:"age_from,omitempty"`
-	AgeUntil   *int     `json:"age_until,omitempty"`
-	Country    string   `json:"country,omitempty"`
-	Categories []string `json:"categories,omitempty"`
-}
-
-func (t *Target) Validate() error {
-	// Validate AgeFrom
-	if t.AgeFrom != nil {
-		if *t.AgeFrom < 0 || *t.AgeFrom > 100 {
-			return errors.New("age_from must be between 0 and 100")
-		}
+	if p.ActiveUntil != nil && !p.ActiveUntil.IsZero() {
+		endsAt := p.ActiveUntil.StartIn(loc).AddDate(0, 0, 1).UTC()
+		p.EndsAt = &endsAt
 	}
+	return nil
+}
 
-	// Validate AgeUntil
-	if t.AgeUntil != nil {
-		if *t.AgeUntil < 0 || *t.AgeUntil > 100 {
-			return errors.New("age_until must be between 0 and 100")
-		}
+// IsActiveAt - активен ли промокод в момент now; в SQL то же правило задаёт ActiveCondition
+func (p *Promo) IsActiveAt(now time.Time) bool {
+	if p.ArchivedAt != nil {
+		return false
 	}
-
-	// If both AgeFrom and AgeUntil are provided, validate their relationship
-	if t.AgeFrom != nil && t.AgeUntil != nil {
-		if *t.AgeFrom > *t.AgeUntil {
-			return errors.New("age_from cannot be greater than age_until")
-		}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
 	}
-
-	// Validate Country
-	if t.Country != "" {
-		countryCode := strings.ToUpper(t.Country)
-		if _, valid := ValidCountryCodes[countryCode]; !valid {
-			return fmt.Errorf("country must be a valid ISO 3166-1 alpha-2 code, got '%s'", t.Country)
-		}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
 	}
 
-	// Validate Categories
-	if t.Categories != nil && len(t.Categories) > 0 {
-		for _, category := range t.Categories {
-			if utf8.RuneCountInString(category) < 2 || utf8.RuneCountInString(category) > 20 {
-				return errors.New("each category must be between 2 and 20 characters long")
-			}
-		}
+	switch p.Mode {
+	case "COMMON":
+		return p.UsedCount < p.MaxCount
+	case "UNIQUE":
+		return p.UsedCount < len(p.PromoUnique)
 	}
-
-	return nil
+	return false
 }
 
-func (t Target) Value() (driver.Value, error) {
-	return json.Marshal(t)
+// Exhausted сообщает, что все активации промокода израсходованы
+func (p *Promo) Exhausted() bool {
+	if p.Mode == "UNIQUE" {
+		return p.UsedCount >= len(p.PromoUnique)
+	}
+	return p.UsedCount >= p.MaxCount
 }
 
-func (t *Target) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
-	if !ok {
-		return errors.New("type assertion to []byte failed")
+// NextCode - код, который получит следующая активация. Уникальные коды выдаются по порядку списка.
+func (p *Promo) NextCode() string {
+	if p.Mode == "UNIQUE" {
+		return p.PromoUnique[p.UsedCount]
 	}
+	return p.PromoCommon
+}
 
-	return json.Unmarshal(bytes, t)
+func (p *Promo) SetActiveStatus() {
+	p.Active = p.IsActiveAt(time.Now())
 }
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"solution/internal/shared/models/b2b"
+)
+
+const (
+	PromoChangeCreate  = "create"
+	PromoChangeUpdate  = "update"
+	PromoChangeArchive = "archive"
+)
+
+const (
+	ActorCompany = "company"
+	ActorApiKey  = "api_key"
+	ActorAdmin   = "admin"
+)
+
+// Actor - кто выполнил изменение: компания по токену, API ключ компании или администратор
+type Actor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// PromoChange - запись истории промокода. Пишется в той же транзакции, что и изменение,
+// история только дополняется.
+type PromoChange struct {
+	ID        string `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PromoID   string `gorm:"type:uuid;not null;index:idx_promo_changes_promo_created,priority:1"`
+	Action    string `gorm:"size:20;not null"`
+	ActorType string `gorm:"size:20;not null"`
+	ActorID   string `gorm:"type:uuid;not null"`
+	// Changes - JSON вида {"поле": {"old": ..., "new": ...}} только по изменённым полям
+	Changes   string    `gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_promo_changes_promo_created,priority:2"`
+}
+
+// FieldChange - старое и новое значение поля в JSON представлении API; null - значения не было
+type FieldChange struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+// promoState - поля промокода, изменения которых попадают в историю. Счётчики лайков
+// и активаций меняются пользователями и в историю не входят.
+type promoState struct {
+	Description string           `json:"description"`
+	ImageURL    string           `json:"image_url"`
+	Mode        string           `json:"mode"`
+	PromoCommon string           `json:"promo_common"`
+	PromoUnique pq.StringArray   `json:"promo_unique"`
+	Target      json.RawMessage  `json:"target"`
+	MaxCount    int              `json:"max_count"`
+	Limits      ActivationLimits `json:"limits"`
+	ActiveFrom  *b2b.Date        `json:"active_from"`
+	ActiveUntil *b2b.Date        `json:"active_until"`
+	Timezone    string           `json:"timezone"`
+	ArchivedAt  *time.Time       `json:"archived_at"`
+}
+
+func stateFields(p *Promo) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if p == nil {
+		return fields, nil
+	}
+
+	// таргетинг сравнивается в том виде, в каком он хранится: страны в верхнем регистре
+	target, err := p.Target.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(promoState{
+		Description: p.Description,
+		ImageURL:    p.ImageURL,
+		Mode:        p.Mode,
+		PromoCommon: p.PromoCommon,
+		PromoUnique: p.PromoUnique,
+		Target:      target.([]byte),
+		MaxCount:    p.MaxCount,
+		Limits:      p.Limits,
+		ActiveFrom:  p.ActiveFrom,
+		ActiveUntil: p.ActiveUntil,
+		Timezone:    p.Timezone,
+		ArchivedAt:  p.ArchivedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// пустые значения хранятся как отсутствие значения, чтобы создание не показывало "" -> ""
+	for name, value := range fields {
+		if isEmptyJSON(value) {
+			delete(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+func isEmptyJSON(value json.RawMessage) bool {
+	switch string(value) {
+	case "null", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
+
+// DiffPromo сравнивает промокод до и после изменения; before == nil - промокод только создан
+func DiffPromo(before, after *Promo) (map[string]FieldChange, error) {
+	old, err := stateFields(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := stateFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]FieldChange)
+	for name, value := range current {
+		if !bytes.Equal(old[name], value) {
+			changes[name] = FieldChange{Old: old[name], New: value}
+		}
+	}
+	for name, value := range old {
+		if _, ok := current[name]; !ok {
+			changes[name] = FieldChange{Old: value}
+		}
+	}
+	return changes, nil
+}
+
+// NewPromoChange готовит запись истории; nil без ошибки - изменение ничего не поменяло
+func NewPromoChange(action string, actor Actor, before, after *Promo) (*PromoChange, error) {
+	changes, err := DiffPromo(before, after)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 && action == PromoChangeUpdate {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	return &PromoChange{
+		PromoID:   after.ID,
+		Action:    action,
+		ActorType: actor.Type,
+		ActorID:   actor.ID,
+		Changes:   string(data),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"solution/internal/shared/models/b2b"
+)
+
+func date(year int, month time.Month, day int) *b2b.Date {
+	return &b2b.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func utc(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestIsActiveAtAcrossTimezones(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		from     *b2b.Date
+		until    *b2b.Date
+		now      string
+		want     bool
+	}{
+		{name: "utc start of day", timezone: "UTC", from: date(2026, 3, 10), now: "2026-03-10T00:00:00Z", want: true},
+		{name: "utc before start", timezone: "UTC", from: date(2026, 3, 10), now: "2026-03-09T23:59:59.999Z"},
+		{name: "utc last instant of until", timezone: "UTC", until: date(2026, 3, 10), now: "2026-03-10T23:59:59.999Z", want: true},
+		{name: "utc day after until", timezone: "UTC", until: date(2026, 3, 10), now: "2026-03-11T00:00:00Z"},
+		{name: "empty timezone is utc", from: date(2026, 3, 10), now: "2026-03-09T23:00:00Z"},
+
+		{name: "moscow starts before utc midnight", timezone: "Europe/Moscow", from: date(2026, 3, 10), now: "2026-03-09T21:00:00Z", want: true},
+		{name: "moscow not started", timezone: "Europe/Moscow", from: date(2026, 3, 10), now: "2026-03-09T20:59:59Z"},
+		{name: "moscow ends before utc midnight", timezone: "Europe/Moscow", until: date(2026, 3, 10), now: "2026-03-10T21:00:00Z"},
+
+		// 8 марта 2026 в Нью-Йорке переход на летнее время: сутки длятся 23 часа
+		{name: "new york start before dst", timezone: "America/New_York", from: date(2026, 3, 8), now: "2026-03-08T05:00:00Z", want: true},
+		{name: "new york not started before dst", timezone: "America/New_York", from: date(2026, 3, 8), now: "2026-03-08T04:59:59Z"},
+		{name: "new york end after dst", timezone: "America/New_York", until: date(2026, 3, 8), now: "2026-03-09T03:59:59Z", want: true},
+		{name: "new york ended after dst", timezone: "America/New_York", until: date(2026, 3, 8), now: "2026-03-09T04:00:00Z"},
+
+		// 25 октября 2026 в Берлине переход на зимнее время: сутки длятся 25 часов
+		{name: "berlin start in summer time", timezone: "Europe/Berlin", from: date(2026, 10, 25), now: "2026-10-24T22:00:00Z", want: true},
+		{name: "berlin extra hour is active", timezone: "Europe/Berlin", until: date(2026, 10, 25), now: "2026-10-25T22:59:59Z", want: true},
+		{name: "berlin ended in winter time", timezone: "Europe/Berlin", until: date(2026, 10, 25), now: "2026-10-25T23:00:00Z"},
+
+		// один и тот же момент - разные календарные даты на краях шкалы часовых поясов
+		{name: "kiritimati already next day", timezone: "Pacific/Kiritimati", until: date(2026, 3, 10), now: "2026-03-10T10:00:00Z"},
+		{name: "pago pago still previous day", timezone: "Pacific/Pago_Pago", from: date(2026, 3, 10), now: "2026-03-10T10:00:00Z"},
+		{name: "pago pago window", timezone: "Pacific/Pago_Pago", from: date(2026, 3, 10), until: date(2026, 3, 10), now: "2026-03-11T10:59:59Z", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promo := Promo{Mode: "COMMON", MaxCount: 10, ActiveFrom: tt.from, ActiveUntil: tt.until, Timezone: tt.timezone}
+			if err := promo.UpdateWindow(); err != nil {
+				t.Fatal(err)
+			}
+			if got := promo.IsActiveAt(utc(tt.now)); got != tt.want {
+				t.Errorf("IsActiveAt(%s) = %v, want %v (window %v - %v)", tt.now, got, tt.want, promo.StartsAt, promo.EndsAt)
+			}
+		})
+	}
+}
+
+func TestIsActiveAtCounts(t *testing.T) {
+	archivedAt := utc("2026-03-01T00:00:00Z")
+	now := utc("2026-03-10T12:00:00Z")
+
+	tests := []struct {
+		name  string
+		promo Promo
+		want  bool
+	}{
+		{name: "common with activations left", promo: Promo{Mode: "COMMON", MaxCount: 2, UsedCount: 1}, want: true},
+		{name: "common exhausted", promo: Promo{Mode: "COMMON", MaxCount: 2, UsedCount: 2}},
+		{name: "unique with codes left", promo: Promo{Mode: "UNIQUE", PromoUnique: []string{"A", "B"}, UsedCount: 1}, want: true},
+		{name: "unique exhausted", promo: Promo{Mode: "UNIQUE", PromoUnique: []string{"A"}, UsedCount: 1}},
+		{name: "archived", promo: Promo{Mode: "COMMON", MaxCount: 2, ArchivedAt: &archivedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promo.IsActiveAt(now); got != tt.want {
+				t.Errorf("IsActiveAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
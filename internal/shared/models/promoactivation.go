@@ -2,9 +2,11 @@ package models
 
 import "time"
 
+// PromoActivation - выдача кода промокода пользователю. Индекс по промокоду и пользователю нужен
+// проверке ограничений активаций, индекс по пользователю - его истории активаций.
 type PromoActivation struct {
 	ID          string `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	PromoID     string `gorm:"type:uuid"`
-	UserID      string `gorm:"type:uuid"`
+	PromoID     string `gorm:"type:uuid;index:idx_promo_activations_promo_user,priority:1"`
+	UserID      string `gorm:"type:uuid;index:idx_promo_activations_promo_user,priority:2;index"`
 	ActivatedAt time.Time
 }
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/validation"
+	"strings"
+)
+
+const (
+	GenderMale   = "MALE"
+	GenderFemale = "FEMALE"
+)
+
+type Target struct {
+	AgeFrom          *int      `json:"age_from,omitempty"`
+	AgeUntil         *int      `json:"age_until,omitempty"`
+	Countries        []string  `json:"countries,omitempty"`
+	ExcludeCountries []string  `json:"exclude_countries,omitempty"`
+	Gender           string    `json:"gender,omitempty"`
+	RegisteredFrom   *b2b.Date `json:"registered_from,omitempty"`
+	RegisteredUntil  *b2b.Date `json:"registered_until,omitempty"`
+	Categories       []string  `json:"categories,omitempty"`
+}
+
+// UnmarshalJSON принимает и старый формат с одной страной в поле country
+func (t *Target) UnmarshalJSON(b []byte) error {
+	type target Target
+	var raw struct {
+		target
+		Country string `json:"country,omitempty"`
+	}
+
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*t = Target(raw.target)
+	if raw.Country != "" && !containsCountry(t.Countries, raw.Country) {
+		t.Countries = append(t.Countries, raw.Country)
+	}
+
+	return nil
+}
+
+func (t *Target) Validate() error {
+	v := validation.New()
+	ageFrom := validation.CheckPtr(v, "age_from", t.AgeFrom, validation.Range(0, 100))
+	ageUntil := validation.CheckPtr(v, "age_until", t.AgeUntil, validation.Range(0, 100))
+	if ageFrom && ageUntil && t.AgeFrom != nil && t.AgeUntil != nil && *t.AgeFrom > *t.AgeUntil {
+		v.Add("age_from", "must not be greater than age_until")
+	}
+
+	validateCountries(v, "countries", t.Countries)
+	validateCountries(v, "exclude_countries", t.ExcludeCountries)
+	for _, country := range t.ExcludeCountries {
+		if containsCountry(t.Countries, country) {
+			v.Add("exclude_countries", fmt.Sprintf("country '%s' cannot be both included and excluded", country))
+		}
+	}
+
+	if t.Gender != "" {
+		validation.Check(v, "gender", t.Gender, validation.OneOfFold(GenderMale, GenderFemale))
+	}
+
+	if t.RegisteredFrom != nil && t.RegisteredUntil != nil && t.RegisteredFrom.Time.After(t.RegisteredUntil.Time) {
+		v.Add("registered_from", "must not be after registered_until")
+	}
+
+	validation.CheckEach(v, "categories", t.Categories, validation.Length(2, 20))
+	return v.Err()
+}
+
+// Normalize приводит коды стран и пол к верхнему регистру - в таком виде они хранятся в jsonb и ищутся через @>
+func (t *Target) Normalize() {
+	for i := range t.Countries {
+		t.Countries[i] = strings.ToUpper(t.Countries[i])
+	}
+	for i := range t.ExcludeCountries {
+		t.ExcludeCountries[i] = strings.ToUpper(t.ExcludeCountries[i])
+	}
+	t.Gender = strings.ToUpper(t.Gender)
+}
+
+func (t Target) Value() (driver.Value, error) {
+	t.Countries = append([]string(nil), t.Countries...)
+	t.ExcludeCountries = append([]string(nil), t.ExcludeCountries...)
+	t.Normalize()
+	return json.Marshal(t)
+}
+
+func (t *Target) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, t)
+}
+
+func IsValidGender(gender string) bool {
+	gender = strings.ToUpper(gender)
+	return gender == GenderMale || gender == GenderFemale
+}
+
+func validateCountries(v *validation.Validator, field string, countries []string) {
+	if validation.CheckEach(v, field, countries, validation.Country()) {
+		validation.Check(v, field, countries, validation.UniqueFold())
+	}
+}
+
+func containsCountry(countries []string, country string) bool {
+	for _, c := range countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountryContainment возвращает jsonb для условия target @> ?, которое обслуживается GIN индексом по target
+func CountryContainment(field, country string) string {
+	containment, _ := json.Marshal(map[string][]string{field: {strings.ToUpper(country)}})
+	return string(containment)
+}
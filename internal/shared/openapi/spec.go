@@ -0,0 +1,140 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/google/uuid"
+	"solution/internal/shared/validation"
+)
+
+// BasePath - префикс, под которым сервер обслуживает пути из api.yml
+const BasePath = "/api"
+
+// форматы, которые kin-openapi не проверяет сам. email не проверяется схемой: правило задаёт
+// настраиваемая политика из validation.
+func init() {
+	openapi3.DefineStringFormatCallback("uuid", func(value string) error {
+		_, err := uuid.Parse(value)
+		return err
+	})
+	openapi3.DefineStringFormatCallback("iso-3166-alpha-2", func(value string) error {
+		if !validation.IsCountryCode(value) {
+			return fmt.Errorf("unknown country code")
+		}
+		return nil
+	})
+	// PATCH промокода и профиля принимает JSON Merge Patch (RFC 7396)
+	openapi3filter.RegisterBodyDecoder("application/merge-patch+json", openapi3filter.JSONBodyDecoder)
+}
+
+// Spec - загруженный api.yml: исходный текст для отдачи клиентам и роутер для поиска операций
+type Spec struct {
+	raw    []byte
+	doc    *openapi3.T
+	router routers.Router
+}
+
+// Load читает и проверяет спецификацию. Ошибка в api.yml - ошибка старта, а не запросов.
+func Load(path string) (*Spec, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI spec: %w", err)
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromData(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec %s: %w", path, err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec %s: %w", path, err)
+	}
+
+	// servers в api.yml - адрес-заглушка, операции ищутся только по пути относительно BasePath
+	doc.Servers = openapi3.Servers{{URL: BasePath}}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+
+	return &Spec{raw: raw, doc: doc, router: router}, nil
+}
+
+// Raw возвращает api.yml в исходном виде
+func (s *Spec) Raw() []byte {
+	return s.raw
+}
+
+// Operation - метод и путь в нотации api.yml вместе с BasePath, например GET /api/business/promo/{id}
+type Operation struct {
+	Method string
+	Path   string
+}
+
+func (o Operation) String() string {
+	return o.Method + " " + o.Path
+}
+
+// Operations возвращает все операции спецификации в стабильном порядке
+func (s *Spec) Operations() []Operation {
+	var ops []Operation
+	for path, item := range s.doc.Paths.Map() {
+		for method := range item.Operations() {
+			ops = append(ops, Operation{Method: method, Path: BasePath + path})
+		}
+	}
+	sortOperations(ops)
+	return ops
+}
+
+// Diff сравнивает спецификацию с маршрутами сервера: undocumented есть только у сервера,
+// unimplemented - только в api.yml
+func (s *Spec) Diff(routes []Operation) (undocumented, unimplemented []Operation) {
+	served := make(map[Operation]bool, len(routes))
+	for _, route := range routes {
+		served[route] = true
+	}
+
+	documented := make(map[Operation]bool)
+	for _, op := range s.Operations() {
+		documented[op] = true
+		if !served[op] {
+			unimplemented = append(unimplemented, op)
+		}
+	}
+	for _, route := range routes {
+		if !documented[route] {
+			undocumented = append(undocumented, route)
+		}
+	}
+
+	sortOperations(undocumented)
+	return undocumented, unimplemented
+}
+
+// GinOperation переводит маршрут gin в нотацию api.yml: /api/user/promo/:id -> /api/user/promo/{id}
+func GinOperation(method, path string) Operation {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return Operation{Method: method, Path: strings.Join(segments, "/")}
+}
+
+func sortOperations(ops []Operation) {
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+}
@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"solution/internal/shared/apperr"
+)
+
+var filterOptions = &openapi3filter.Options{
+	MultiError: true,
+	// значения по умолчанию подставляют обработчики, запрос не меняется
+	SkipSettingDefaults: true,
+	// авторизацию проверяют middleware обработчиков, спецификация описывает только заголовок
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+}
+
+// Documents сообщает, описан ли запрос в api.yml. Служебные маршруты (/healthz, /metrics) не описаны.
+func (s *Spec) Documents(req *http.Request) bool {
+	_, _, ok := s.findRoute(req)
+	return ok
+}
+
+// ValidateRequest проверяет параметры и тело запроса. Ошибки возвращаются как ошибки валидации по полям,
+// запросы вне спецификации не проверяются. Тело запроса остаётся доступным обработчику.
+func (s *Spec) ValidateRequest(req *http.Request) error {
+	route, params, ok := s.findRoute(req)
+	if !ok {
+		return nil
+	}
+
+	err := openapi3filter.ValidateRequest(req.Context(), &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: params,
+		Route:      route,
+		Options:    filterOptions,
+	})
+	if err != nil {
+		return apperr.Validation(errors.Join(fieldErrors(err, "")...))
+	}
+	return nil
+}
+
+// ValidateResponse сверяет статус, заголовки и тело ответа на req со спецификацией.
+// Статусы, не описанные для операции, не проверяются.
+func (s *Spec) ValidateResponse(req *http.Request, status int, header http.Header, body []byte) error {
+	route, params, ok := s.findRoute(req)
+	if !ok {
+		return nil
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: params,
+			Route:      route,
+		},
+		Status:  status,
+		Header:  header,
+		Options: filterOptions,
+	}
+	input.SetBodyBytes(body)
+	return openapi3filter.ValidateResponse(req.Context(), input)
+}
+
+func (s *Spec) findRoute(req *http.Request) (*routers.Route, map[string]string, bool) {
+	route, params, err := s.router.FindRoute(req)
+	if err != nil {
+		return nil, nil, false
+	}
+	return route, params, true
+}
+
+// fieldErrors разворачивает ошибки kin-openapi до ошибок полей с путями в формате validation: target.countries[0]
+func fieldErrors(err error, field string) []error {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fields []error
+		for _, inner := range e {
+			fields = append(fields, fieldErrors(inner, field)...)
+		}
+		return fields
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		}
+		if e.Err == nil {
+			return []error{apperr.Field(field, e.Reason)}
+		}
+		return fieldErrors(e.Err, field)
+	case *openapi3.SchemaError:
+		return []error{apperr.Field(joinPath(field, e.JSONPointer()), e.Reason)}
+	default:
+		return []error{apperr.Field(field, err.Error())}
+	}
+}
+
+func joinPath(field string, pointer []string) string {
+	path := field
+	for _, segment := range pointer {
+		if _, err := strconv.Atoi(segment); err == nil {
+			path += "[" + segment + "]"
+			continue
+		}
+		if path != "" {
+			path += "."
+		}
+		path += segment
+	}
+	return path
+}
@@ -0,0 +1,99 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/storage/postgres/pgtest"
+)
+
+// TestActiveConditionMatchesIsActiveAt сверяет SQL условие активности с IsActiveAt на границах окон
+// в разных часовых поясах и при переходах на летнее и зимнее время. Часовой пояс сессии Postgres
+// на результат влиять не должен. Нужен TEST_POSTGRES_DSN.
+func TestActiveConditionMatchesIsActiveAt(t *testing.T) {
+	db := pgtest.Open(t)
+
+	company := b2b.Company{Name: "Company", Email: "company@example.com", Password: "hash"}
+	if err := db.Create(&company).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(year int, month time.Month, d int) *b2b.Date {
+		return &b2b.Date{Time: time.Date(year, month, d, 0, 0, 0, 0, time.UTC)}
+	}
+	archivedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	promos := []models.Promo{
+		{Timezone: "UTC", ActiveFrom: day(2026, 3, 10), ActiveUntil: day(2026, 3, 10)},
+		{Timezone: "Europe/Moscow", ActiveFrom: day(2026, 3, 10), ActiveUntil: day(2026, 3, 10)},
+		{Timezone: "America/New_York", ActiveFrom: day(2026, 3, 8), ActiveUntil: day(2026, 3, 8)},
+		{Timezone: "Europe/Berlin", ActiveFrom: day(2026, 10, 25), ActiveUntil: day(2026, 10, 25)},
+		{Timezone: "Pacific/Kiritimati", ActiveFrom: day(2026, 3, 10), ActiveUntil: day(2026, 3, 10)},
+		{Timezone: "Pacific/Pago_Pago", ActiveFrom: day(2026, 3, 10), ActiveUntil: day(2026, 3, 10)},
+		{Timezone: "UTC", ActiveFrom: day(2026, 3, 10)},
+		{Timezone: "UTC", ActiveUntil: day(2026, 3, 10)},
+		{Timezone: "UTC", UsedCount: 10},
+		{Timezone: "UTC", ArchivedAt: &archivedAt},
+	}
+
+	var instants []time.Time
+	for i := range promos {
+		promos[i].CompanyID = company.ID
+		promos[i].Description = "Promo description"
+		promos[i].Mode = "COMMON"
+		promos[i].PromoCommon = "CODE"
+		if promos[i].MaxCount == 0 {
+			promos[i].MaxCount = 10
+		}
+		if err := promos[i].UpdateWindow(); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&promos[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		for _, bound := range []*time.Time{promos[i].StartsAt, promos[i].EndsAt} {
+			if bound != nil {
+				instants = append(instants, bound.Add(-time.Microsecond), *bound, bound.Add(time.Microsecond))
+			}
+		}
+	}
+	instants = append(instants, time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC))
+
+	for _, sessionZone := range []string{"UTC", "Pacific/Kiritimati", "America/New_York"} {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SET LOCAL TIME ZONE '" + sessionZone + "'").Error; err != nil {
+				return err
+			}
+
+			for _, now := range instants {
+				var active []string
+				err := tx.Model(&models.Promo{}).
+					Where(models.ActiveCondition, sql.Named("now", now)).
+					Pluck("id", &active).Error
+				if err != nil {
+					return err
+				}
+				activeIDs := make(map[string]bool, len(active))
+				for _, id := range active {
+					activeIDs[id] = true
+				}
+
+				for _, promo := range promos {
+					if want := promo.IsActiveAt(now); activeIDs[promo.ID] != want {
+						t.Errorf("session %s, promo %s %v - %v at %s: ActiveCondition = %v, IsActiveAt = %v",
+							sessionZone, promo.Timezone, promo.ActiveFrom, promo.ActiveUntil, now.Format(time.RFC3339Nano), activeIDs[promo.ID], want)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
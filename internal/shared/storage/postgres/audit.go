@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// appendOnlyTables - журналы, которые только дополняются: изменить или удалить запись нельзя
+// даже прямым SQL, в обход приложения
+var appendOnlyTables = []string{"admin_audit_log", "promo_changes"}
+
+func appendOnlyStatements(table string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s_immutable() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION '%[1]s is append-only';
+	END
+	$$ LANGUAGE plpgsql`, table),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS %[1]s_immutable_trigger ON %[1]s`, table),
+		fmt.Sprintf(`CREATE TRIGGER %[1]s_immutable_trigger
+		BEFORE UPDATE OR DELETE ON %[1]s
+		FOR EACH ROW EXECUTE FUNCTION %[1]s_immutable()`, table),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS %[1]s_truncate_trigger ON %[1]s`, table),
+		fmt.Sprintf(`CREATE TRIGGER %[1]s_truncate_trigger
+		BEFORE TRUNCATE ON %[1]s
+		FOR EACH STATEMENT EXECUTE FUNCTION %[1]s_immutable()`, table),
+	}
+}
+
+func initAppendOnly(db *gorm.DB) error {
+	for _, table := range appendOnlyTables {
+		for _, statement := range appendOnlyStatements(table) {
+			if err := db.Exec(statement).Error; err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+)
+
+// Ping проверяет соединение с базой
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations проверяет, что схема, которую создаёт InitPostgres, на месте
+func CheckMigrations(ctx context.Context, db *gorm.DB) error {
+	migrator := db.WithContext(ctx).Migrator()
+	for _, model := range migratedModels() {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table for %T is missing", model)
+		}
+	}
+	if !migrator.HasColumn(&models.Promo{}, "search_vector") {
+		return fmt.Errorf("promos.search_vector is missing")
+	}
+	return nil
+}
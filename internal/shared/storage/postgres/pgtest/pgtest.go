@@ -0,0 +1,141 @@
+// Package pgtest - временная база Postgres для тестов. Сервер задаётся переменной TEST_POSTGRES_DSN,
+// без неё pgtest поднимает свой кластер через initdb и pg_ctl. Если нет ни того, ни другого, тест падает;
+// go test -short пропускает тесты с базой явно.
+package pgtest
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	storage "solution/internal/shared/storage/postgres"
+)
+
+// DSNEnv - переменная окружения с адресом сервера Postgres для тестов
+const DSNEnv = "TEST_POSTGRES_DSN"
+
+// Open создаёт отдельную базу на сервере из TEST_POSTGRES_DSN или на временном кластере, применяет схему
+// через Migrate и удаляет базу после теста, поэтому тесты не мешают друг другу и не оставляют данных
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("postgres tests are skipped in -short mode")
+	}
+
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		dsn = startCluster(t)
+	}
+
+	server, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to test postgres: %v", err)
+	}
+
+	name := "solution_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	if err := server.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Fatalf("create test database: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(withDatabase(dsn, name)), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := server.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)").Error; err != nil {
+			t.Logf("drop test database %s: %v", name, err)
+		}
+		if sqlDB, err := server.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := storage.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// withDatabase подставляет имя базы в DSN в формате URL или key=value
+func withDatabase(dsn, name string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		u.Path = "/" + name
+		return u.String()
+	}
+	return dsn + " dbname=" + name
+}
+
+// startCluster запускает кластер Postgres в t.TempDir() и останавливает его после теста
+func startCluster(t testing.TB) string {
+	t.Helper()
+
+	bin, err := findBinDir()
+	if err != nil {
+		t.Fatalf("%s is not set and no local postgres found: %v (use go test -short to skip postgres tests)", DSNEnv, err)
+	}
+	if os.Geteuid() == 0 {
+		t.Fatalf("%s is not set and initdb cannot run as root (use go test -short to skip postgres tests)", DSNEnv)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("pick postgres port: %v", err)
+	}
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	run(t, filepath.Join(bin, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+
+	opts := fmt.Sprintf("-p %d -c listen_addresses=127.0.0.1 -c unix_socket_directories='' -c fsync=off", port)
+	run(t, filepath.Join(bin, "pg_ctl"), "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-o", opts, "-w", "start")
+	t.Cleanup(func() {
+		if out, err := exec.Command(filepath.Join(bin, "pg_ctl"), "-D", data, "-m", "immediate", "-w", "stop").CombinedOutput(); err != nil {
+			t.Logf("stop test postgres: %v: %s", err, out)
+		}
+	})
+
+	return fmt.Sprintf("host=127.0.0.1 port=%d user=postgres dbname=postgres sslmode=disable", port)
+}
+
+// findBinDir ищет initdb и pg_ctl в PATH и в стандартных каталогах пакетов Debian и RHEL
+func findBinDir() (string, error) {
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path), nil
+	}
+	for _, pattern := range []string{"/usr/lib/postgresql/*/bin/initdb", "/usr/pgsql-*/bin/initdb"} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return filepath.Dir(matches[len(matches)-1]), nil
+		}
+	}
+	return "", fmt.Errorf("initdb is not in PATH")
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func run(t testing.TB, name string, args ...string) {
+	t.Helper()
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		t.Fatalf("%s: %v: %s", filepath.Base(name), err, out)
+	}
+}
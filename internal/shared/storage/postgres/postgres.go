@@ -1,32 +1,88 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log"
 	"solution/internal/shared/config"
+	"solution/internal/shared/logger"
+	"solution/internal/shared/metrics"
 	"solution/internal/shared/models"
+	"solution/internal/shared/models/admin"
 	"solution/internal/shared/models/b2b"
 	"solution/internal/shared/models/b2c"
+	"solution/internal/shared/tracing"
 )
 
-func InitPostgres(cfg *config.Postgres) (*gorm.DB, error) {
-	connStr := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+// migratedModels - модели, таблицы которых создаёт AutoMigrate
+func migratedModels() []interface{} {
+	return []interface{}{&b2c.User{}, &b2b.Company{}, &models.Promo{}, &models.PromoActivation{}, &b2c.UserLike{}, &b2c.Comment{}, &b2b.ApiKey{}, &b2c.PromoSegmentScore{}, &admin.Admin{}, &admin.AuditEntry{}, &models.PromoChange{}}
+}
+
+func InitPostgres(config *config.Config) (*gorm.DB, error) {
+	cfg := config.Postgres
 
-	db, err := gorm.Open(postgres.Open(connStr), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: logger.NewGormLogger(config.Logging.SlowQueryThreshold),
+	})
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";")
+	if err := metrics.RegisterDB(db); err != nil {
+		return nil, fmt.Errorf("failed to register database metrics: %w", err)
+	}
+
+	if err := tracing.RegisterDB(db); err != nil {
+		return nil, fmt.Errorf("failed to register database tracing: %w", err)
+	}
 
-	err = db.AutoMigrate(&b2c.User{}, &b2b.Company{}, &models.Promo{}, &models.PromoActivation{}, &b2c.UserLike{}, &b2c.Comment{})
+	if err := db.Use(StatementTimeoutPlugin{Timeout: cfg.StatementTimeout}); err != nil {
+		return nil, fmt.Errorf("failed to register statement timeout: %w", err)
+	}
+
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("failed to migrate database: %v", err)
+		return nil, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := Migrate(db); err != nil {
 		return nil, err
 	}
 	return db, nil
 }
+
+// Migrate приводит схему к актуальному виду: таблицы моделей, таргетинг, окна действия промокодов,
+// полнотекстовый поиск и защита журнала администраторов от изменений.
+// Построение индексов и дозаполнение колонок не ограничиваются таймаутом запроса.
+func Migrate(db *gorm.DB) error {
+	db = db.WithContext(WithoutStatementTimeout(context.Background()))
+
+	db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";")
+
+	if err := db.AutoMigrate(migratedModels()...); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	if err := initPromoTargeting(db); err != nil {
+		return fmt.Errorf("failed to migrate promo targeting: %w", err)
+	}
+
+	if err := initPromoWindows(db); err != nil {
+		return fmt.Errorf("failed to migrate promo windows: %w", err)
+	}
+
+	if err := initPromoSearch(db); err != nil {
+		return fmt.Errorf("failed to init promo search: %w", err)
+	}
+
+	if err := initAppendOnly(db); err != nil {
+		return fmt.Errorf("failed to protect audit logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"gorm.io/gorm"
+)
+
+// promoSearchStatements - полнотекстовый поиск по промокодам: search_vector обновляют триггеры, в том числе при переименовании компании
+var promoSearchStatements = []string{
+	`ALTER TABLE promos ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+	`CREATE OR REPLACE FUNCTION promos_search_vector(p promos) RETURNS tsvector AS $$
+		SELECT
+			setweight(to_tsvector('simple', COALESCE((SELECT name FROM companies WHERE id::text = p.company_id), '')), 'A') ||
+			setweight(to_tsvector('russian', COALESCE(p.description, '')), 'B') ||
+			setweight(to_tsvector('english', COALESCE(p.description, '')), 'B') ||
+			setweight(to_tsvector('simple', COALESCE((
+				SELECT string_agg(c, ' ') FROM jsonb_array_elements_text(p.target->'categories') AS c
+			), '')), 'C')
+	$$ LANGUAGE sql STABLE`,
+	`CREATE OR REPLACE FUNCTION promos_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_vector := promos_search_vector(NEW);
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS promos_search_vector_trigger ON promos`,
+	`CREATE TRIGGER promos_search_vector_trigger
+		BEFORE INSERT OR UPDATE OF company_id, description, target ON promos
+		FOR EACH ROW EXECUTE FUNCTION promos_search_vector_update()`,
+	`CREATE OR REPLACE FUNCTION companies_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		UPDATE promos SET search_vector = promos_search_vector(promos) WHERE company_id = NEW.id::text;
+		RETURN NULL;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS companies_search_vector_trigger ON companies`,
+	`CREATE TRIGGER companies_search_vector_trigger
+		AFTER UPDATE OF name ON companies
+		FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name) EXECUTE FUNCTION companies_search_vector_update()`,
+	`UPDATE promos SET search_vector = promos_search_vector(promos) WHERE search_vector IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_promos_search_vector ON promos USING GIN (search_vector)`,
+}
+
+func initPromoSearch(db *gorm.DB) error {
+	for _, statement := range promoSearchStatements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/storage/postgres/pgtest"
+)
+
+// TestCompanyRenameReindexesPromos проверяет, что новое название компании находится поиском
+// сразу после переименования, а старое - нет. Нужен TEST_POSTGRES_DSN.
+func TestCompanyRenameReindexesPromos(t *testing.T) {
+	db := pgtest.Open(t)
+
+	company := b2b.Company{Name: "Oldname", Email: "company@example.com", Password: "hash"}
+	if err := db.Create(&company).Error; err != nil {
+		t.Fatal(err)
+	}
+	promo := models.Promo{CompanyID: company.ID, Description: "Promo description", Mode: "COMMON", PromoCommon: "CODE", MaxCount: 10}
+	if err := db.Create(&promo).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Model(&company).Update("name", "Newname").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	found := func(word string) bool {
+		var n int64
+		err := db.Model(&models.Promo{}).
+			Where("id = ? AND search_vector @@ to_tsquery('simple', ?)", promo.ID, word).
+			Count(&n).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n == 1
+	}
+	if !found("newname") {
+		t.Error("promo is not found by the new company name")
+	}
+	if found("oldname") {
+		t.Error("promo is still found by the old company name")
+	}
+}
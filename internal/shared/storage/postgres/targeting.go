@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"gorm.io/gorm"
+)
+
+// Таргетинг по странам хранится в target.countries / target.exclude_countries в верхнем регистре,
+// фильтры ленты и списка промокодов компании используют target @> ..., поэтому нужен GIN индекс по target.
+var promoTargetingStatements = []string{
+	// Промокоды, созданные до появления списков стран, хранят одну страну в target.country
+	`UPDATE promos SET target = (target - 'country') || CASE
+		WHEN COALESCE(target->>'country', '') = '' THEN '{}'::jsonb
+		ELSE jsonb_build_object('countries', jsonb_build_array(UPPER(target->>'country')))
+	END
+	WHERE target->'country' IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_promos_target ON promos USING GIN (target jsonb_path_ops)`,
+}
+
+func initPromoTargeting(db *gorm.DB) error {
+	for _, statement := range promoTargetingStatements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
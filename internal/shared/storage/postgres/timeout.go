@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"solution/internal/shared/gormhook"
+)
+
+const deadlineKey = "timeout:deadline"
+
+type noStatementTimeoutKey struct{}
+
+// WithoutStatementTimeout снимает ограничение StatementTimeoutPlugin для запросов с этим контекстом.
+// Нужен миграциям и фоновым пересчётам: на большой таблице они законно идут дольше таймаута запроса клиента.
+func WithoutStatementTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noStatementTimeoutKey{}, true)
+}
+
+func statementTimeoutDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noStatementTimeoutKey{}).(bool)
+	return disabled
+}
+
+// StatementTimeoutPlugin ограничивает каждый запрос GORM дедлайном Timeout, более ранний дедлайн контекста сохраняется
+type StatementTimeoutPlugin struct {
+	Timeout time.Duration
+}
+
+func (StatementTimeoutPlugin) Name() string {
+	return "statement_timeout"
+}
+
+func (p StatementTimeoutPlugin) Initialize(db *gorm.DB) error {
+	if p.Timeout <= 0 {
+		return nil
+	}
+
+	return gormhook.Register(db, "timeout", func(operation string) (before, after func(*gorm.DB)) {
+		if operation == "row" {
+			return p.setDeadline, keepDeadline
+		}
+		return p.setDeadline, releaseDeadline
+	})
+}
+
+func (p StatementTimeoutPlugin) setDeadline(db *gorm.DB) {
+	parent := db.Statement.Context
+	if statementTimeoutDisabled(parent) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(parent, p.Timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(deadlineKey, cancel)
+}
+
+func releaseDeadline(db *gorm.DB) {
+	if cancel, ok := db.InstanceGet(deadlineKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
+
+// keepDeadline не отменяет контекст row: *sql.Row читается после колбэков, контекст освободит сам дедлайн
+func keepDeadline(*gorm.DB) {}
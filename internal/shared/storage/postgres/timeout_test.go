@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestStatementTimeoutPlugin проверяет дедлайн, который видит драйвер. Запросы выполняются в режиме DryRun,
+// поэтому база не нужна.
+func TestStatementTimeoutPlugin(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(StatementTimeoutPlugin{Timeout: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	var deadline time.Time
+	var hasDeadline bool
+	capture := func(db *gorm.DB) { deadline, hasDeadline = db.Statement.Context.Deadline() }
+	if err := db.Callback().Query().Before("gorm:query").After("timeout:before_query").Register("test:query", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Row().Before("gorm:row").After("timeout:before_row").Register("test:row", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Raw().Before("gorm:raw").After("timeout:before_raw").Register("test:raw", capture); err != nil {
+		t.Fatal(err)
+	}
+
+	early, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		run    func(db *gorm.DB) error
+		want   bool
+		before time.Duration
+	}{
+		{name: "query", ctx: context.Background(), run: queryRow, want: true, before: time.Minute},
+		{name: "row", ctx: context.Background(), run: scanRow, want: true, before: time.Minute},
+		{name: "raw", ctx: context.Background(), run: execRaw, want: true, before: time.Minute},
+		{name: "earlier context deadline is kept", ctx: early, run: queryRow, want: true, before: time.Second},
+		{name: "query without timeout", ctx: WithoutStatementTimeout(context.Background()), run: queryRow},
+		{name: "migration without timeout", ctx: WithoutStatementTimeout(context.Background()), run: execRaw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasDeadline = false
+			if err := tt.run(db.WithContext(tt.ctx)); err != nil {
+				t.Fatal(err)
+			}
+			if hasDeadline != tt.want {
+				t.Fatalf("statement deadline set = %v, want %v", hasDeadline, tt.want)
+			}
+			if tt.want && time.Until(deadline) > tt.before {
+				t.Errorf("deadline in %v, want at most %v", time.Until(deadline), tt.before)
+			}
+		})
+	}
+}
+
+func queryRow(db *gorm.DB) error {
+	var rows []struct{ ID string }
+	return db.Table("promos").Find(&rows).Error
+}
+
+func scanRow(db *gorm.DB) error {
+	db.Table("promos").Select("id").Row()
+	return nil
+}
+
+func execRaw(db *gorm.DB) error {
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_test ON promos (id)").Error
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+)
+
+const promoWindowBatchSize = 500
+
+// initPromoWindows заполняет окно действия промокодам, созданным до появления starts_at и ends_at.
+// Окно считает models.Promo.UpdateWindow, чтобы правило не повторялось в SQL.
+func initPromoWindows(db *gorm.DB) error {
+	var promos []models.Promo
+	return db.Model(&models.Promo{}).
+		Where("(active_from IS NOT NULL AND starts_at IS NULL) OR (active_until IS NOT NULL AND ends_at IS NULL)").
+		FindInBatches(&promos, promoWindowBatchSize, func(tx *gorm.DB, _ int) error {
+			for i := range promos {
+				if err := promos[i].UpdateWindow(); err != nil {
+					return err
+				}
+				err := db.Model(&models.Promo{}).Where("id = ?", promos[i].ID).UpdateColumns(map[string]interface{}{
+					"timezone":  promos[i].Timezone,
+					"starts_at": promos[i].StartsAt,
+					"ends_at":   promos[i].EndsAt,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
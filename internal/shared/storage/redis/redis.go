@@ -3,6 +3,8 @@ package redis
 import (
 	"context"
 	"solution/internal/shared/config"
+	"solution/internal/shared/metrics"
+	"solution/internal/shared/tracing"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -12,14 +14,23 @@ type RDB struct {
 }
 
 func NewRedisClient(config *config.Config) (*RDB, error) {
-	address := config.Redis.ConnStr
+	cfg := config.Redis
 
 	client := redis.NewClient(&redis.Options{
-		Addr:     address,
-		Password: "",
-		DB:       0,
+		Addr:         cfg.Addr(),
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
 	})
 
+	if err := metrics.RegisterRedis(client); err != nil {
+		return &RDB{}, err
+	}
+	tracing.RegisterRedis(client)
+
 	if err := client.Ping(context.TODO()).Err(); err != nil {
 		return &RDB{}, err
 	}
@@ -0,0 +1,21 @@
+// Package redistest - Redis в памяти (miniredis) для тестов
+package redistest
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	"solution/internal/shared/storage/redis"
+)
+
+// Open запускает miniredis на время теста. Сервер возвращается, чтобы тест мог сдвигать время жизни ключей.
+func Open(t testing.TB) (*redis.RDB, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return &redis.RDB{Client: client}, server
+}
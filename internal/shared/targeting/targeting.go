@@ -0,0 +1,199 @@
+// Package targeting - единственное место, где описаны правила соответствия таргетинга промокода пользователю.
+// Каждое правило задано в трёх формах, которые должны совпадать по смыслу:
+//   - Matches проверяет пару промокод-пользователь в Go;
+//   - PromoConditions фильтрует промокоды (promos.target) под конкретного пользователя - лента и поиск;
+//   - UserConditions фильтрует пользователей (users) под конкретный таргетинг - оценка аудитории.
+//
+// Неизвестный атрибут пользователя (возраст 0, пустая страна или пол) не ограничивает выдачу.
+// Дата регистрации сравнивается по календарной дате в UTC независимо от часового пояса сессии БД.
+package targeting
+
+import (
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2c"
+	"strings"
+	"time"
+)
+
+// Audience - атрибуты пользователя, по которым работает таргетинг
+type Audience struct {
+	Age          int
+	Country      string
+	Gender       string
+	RegisteredAt time.Time
+}
+
+func AudienceOf(user *b2c.User) Audience {
+	return Audience{
+		Age:          user.Age,
+		Country:      user.Country,
+		Gender:       user.Gender,
+		RegisteredAt: user.CreatedAt,
+	}
+}
+
+// Condition - SQL условие для gorm Where
+type Condition struct {
+	SQL  string
+	Args []interface{}
+}
+
+func Apply(tx *gorm.DB, conditions []Condition) *gorm.DB {
+	for _, condition := range conditions {
+		tx = tx.Where(condition.SQL, condition.Args...)
+	}
+	return tx
+}
+
+func Matches(t models.Target, a Audience) bool {
+	if a.Age != 0 {
+		if t.AgeFrom != nil && *t.AgeFrom > a.Age {
+			return false
+		}
+		if t.AgeUntil != nil && *t.AgeUntil < a.Age {
+			return false
+		}
+	}
+
+	if a.Country != "" {
+		if len(t.Countries) > 0 && !containsFold(t.Countries, a.Country) {
+			return false
+		}
+		if containsFold(t.ExcludeCountries, a.Country) {
+			return false
+		}
+	}
+
+	if a.Gender != "" && t.Gender != "" && !strings.EqualFold(t.Gender, a.Gender) {
+		return false
+	}
+
+	if !a.RegisteredAt.IsZero() {
+		registered := truncateToDate(a.RegisteredAt)
+		if t.RegisteredFrom != nil && !t.RegisteredFrom.Time.IsZero() && registered.Before(t.RegisteredFrom.Time) {
+			return false
+		}
+		if t.RegisteredUntil != nil && !t.RegisteredUntil.Time.IsZero() && registered.After(t.RegisteredUntil.Time) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PromoConditions - условия по колонке target таблицы promos для пользователя a
+func PromoConditions(a Audience) []Condition {
+	conditions := make([]Condition, 0)
+
+	if a.Age != 0 {
+		conditions = append(conditions,
+			Condition{SQL: "COALESCE(target->>'age_from', '0')::int <= ?", Args: []interface{}{a.Age}},
+			Condition{SQL: "COALESCE(target->>'age_until', '100')::int >= ?", Args: []interface{}{a.Age}},
+		)
+	}
+
+	if a.Country != "" {
+		conditions = append(conditions,
+			Condition{
+				SQL:  "(target->'countries' IS NULL OR target @> ?::jsonb)",
+				Args: []interface{}{models.CountryContainment("countries", a.Country)},
+			},
+			Condition{
+				SQL:  "NOT target @> ?::jsonb",
+				Args: []interface{}{models.CountryContainment("exclude_countries", a.Country)},
+			},
+		)
+	}
+
+	if a.Gender != "" {
+		conditions = append(conditions, Condition{
+			SQL:  "(target->>'gender' IS NULL OR target->>'gender' = ?)",
+			Args: []interface{}{strings.ToUpper(a.Gender)},
+		})
+	}
+
+	if !a.RegisteredAt.IsZero() {
+		conditions = append(conditions,
+			Condition{
+				SQL:  "(target->>'registered_from' IS NULL OR (target->>'registered_from')::date <= (?::timestamptz AT TIME ZONE 'UTC')::date)",
+				Args: []interface{}{a.RegisteredAt},
+			},
+			Condition{
+				SQL:  "(target->>'registered_until' IS NULL OR (target->>'registered_until')::date >= (?::timestamptz AT TIME ZONE 'UTC')::date)",
+				Args: []interface{}{a.RegisteredAt},
+			},
+		)
+	}
+
+	return conditions
+}
+
+// UserConditions - условия по таблице users для таргетинга t
+func UserConditions(t models.Target) []Condition {
+	conditions := make([]Condition, 0)
+
+	if t.AgeFrom != nil {
+		conditions = append(conditions, Condition{SQL: "(users.age = 0 OR users.age >= ?)", Args: []interface{}{*t.AgeFrom}})
+	}
+	if t.AgeUntil != nil {
+		conditions = append(conditions, Condition{SQL: "(users.age = 0 OR users.age <= ?)", Args: []interface{}{*t.AgeUntil}})
+	}
+
+	if len(t.Countries) > 0 {
+		conditions = append(conditions, Condition{
+			SQL:  "(users.country = '' OR UPPER(users.country) IN ?)",
+			Args: []interface{}{upper(t.Countries)},
+		})
+	}
+	if len(t.ExcludeCountries) > 0 {
+		conditions = append(conditions, Condition{
+			SQL:  "(users.country = '' OR UPPER(users.country) NOT IN ?)",
+			Args: []interface{}{upper(t.ExcludeCountries)},
+		})
+	}
+
+	if t.Gender != "" {
+		conditions = append(conditions, Condition{
+			SQL:  "(COALESCE(users.gender, '') = '' OR users.gender = ?)",
+			Args: []interface{}{strings.ToUpper(t.Gender)},
+		})
+	}
+
+	if t.RegisteredFrom != nil && !t.RegisteredFrom.Time.IsZero() {
+		conditions = append(conditions, Condition{
+			SQL:  "(users.created_at IS NULL OR (users.created_at AT TIME ZONE 'UTC')::date >= ?::date)",
+			Args: []interface{}{*t.RegisteredFrom},
+		})
+	}
+	if t.RegisteredUntil != nil && !t.RegisteredUntil.Time.IsZero() {
+		conditions = append(conditions, Condition{
+			SQL:  "(users.created_at IS NULL OR (users.created_at AT TIME ZONE 'UTC')::date <= ?::date)",
+			Args: []interface{}{*t.RegisteredUntil},
+		})
+	}
+
+	return conditions
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func upper(values []string) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = strings.ToUpper(v)
+	}
+	return result
+}
+
+func truncateToDate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,114 @@
+package targeting_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b"
+	"solution/internal/shared/storage/postgres/pgtest"
+	"solution/internal/shared/targeting"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func date(year int, month time.Month, day int) *b2b.Date {
+	return &b2b.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+var registered = time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+
+// cases - одни и те же правила проверяются в Go и обеими формами SQL; таргетинг задан так, как он хранится
+var cases = []struct {
+	name     string
+	target   models.Target
+	audience targeting.Audience
+	want     bool
+}{
+	{name: "empty target", audience: targeting.Audience{Age: 30, Country: "ru", Gender: "MALE", RegisteredAt: registered}, want: true},
+	{name: "age in range", target: models.Target{AgeFrom: intPtr(18), AgeUntil: intPtr(30)}, audience: targeting.Audience{Age: 30}, want: true},
+	{name: "younger than age_from", target: models.Target{AgeFrom: intPtr(18)}, audience: targeting.Audience{Age: 17}},
+	{name: "older than age_until", target: models.Target{AgeUntil: intPtr(30)}, audience: targeting.Audience{Age: 31}},
+	{name: "unknown age", target: models.Target{AgeFrom: intPtr(18), AgeUntil: intPtr(30)}, audience: targeting.Audience{}, want: true},
+	{name: "country in list", target: models.Target{Countries: []string{"FR", "RU"}}, audience: targeting.Audience{Country: "ru"}, want: true},
+	{name: "country not in list", target: models.Target{Countries: []string{"FR"}}, audience: targeting.Audience{Country: "ru"}},
+	{name: "excluded country", target: models.Target{ExcludeCountries: []string{"RU"}}, audience: targeting.Audience{Country: "ru"}},
+	{name: "not excluded country", target: models.Target{ExcludeCountries: []string{"RU"}}, audience: targeting.Audience{Country: "fr"}, want: true},
+	{name: "unknown country", target: models.Target{Countries: []string{"FR"}}, audience: targeting.Audience{}, want: true},
+	{name: "same gender", target: models.Target{Gender: "FEMALE"}, audience: targeting.Audience{Gender: "FEMALE"}, want: true},
+	{name: "other gender", target: models.Target{Gender: "FEMALE"}, audience: targeting.Audience{Gender: "MALE"}},
+	{name: "unknown gender", target: models.Target{Gender: "FEMALE"}, audience: targeting.Audience{}, want: true},
+	{name: "registered on registered_from", target: models.Target{RegisteredFrom: date(2024, 3, 10)}, audience: targeting.Audience{RegisteredAt: registered}, want: true},
+	{name: "registered on registered_until", target: models.Target{RegisteredUntil: date(2024, 3, 10)}, audience: targeting.Audience{RegisteredAt: registered}, want: true},
+	{name: "registered before registered_from", target: models.Target{RegisteredFrom: date(2024, 3, 11)}, audience: targeting.Audience{RegisteredAt: registered}},
+	{name: "registered after registered_until", target: models.Target{RegisteredUntil: date(2024, 3, 9)}, audience: targeting.Audience{RegisteredAt: registered}},
+	{name: "unknown registration", target: models.Target{RegisteredFrom: date(2024, 3, 11)}, audience: targeting.Audience{}, want: true},
+	{
+		name:     "all rules",
+		target:   models.Target{AgeFrom: intPtr(18), Countries: []string{"RU"}, Gender: "MALE", RegisteredUntil: date(2024, 3, 10)},
+		audience: targeting.Audience{Age: 30, Country: "RU", Gender: "MALE", RegisteredAt: registered},
+		want:     true,
+	},
+}
+
+func TestMatches(t *testing.T) {
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targeting.Matches(tt.target, tt.audience); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConditions проверяет, что SQL условия отбирают то же, что Matches. Часовой пояс сессии далёк от UTC,
+// чтобы регистрация поздним вечером по UTC не попадала в соседний день. Нужен TEST_POSTGRES_DSN.
+func TestConditions(t *testing.T) {
+	db := pgtest.Open(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET LOCAL TIME ZONE 'Pacific/Kiritimati'").Error; err != nil {
+			return err
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				target, err := json.Marshal(tt.target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				promos := tx.Table("(SELECT ?::jsonb AS target) AS promos", string(target))
+				if got := count(t, targeting.Apply(promos, targeting.PromoConditions(tt.audience))); got != tt.want {
+					t.Errorf("PromoConditions() match = %v, want %v", got, tt.want)
+				}
+
+				var createdAt *time.Time
+				if !tt.audience.RegisteredAt.IsZero() {
+					createdAt = &tt.audience.RegisteredAt
+				}
+				users := tx.Table("(SELECT ?::int AS age, ?::text AS country, ?::text AS gender, ?::timestamptz AS created_at) AS users",
+					tt.audience.Age, tt.audience.Country, tt.audience.Gender, createdAt)
+				if got := count(t, targeting.Apply(users, targeting.UserConditions(tt.target))); got != tt.want {
+					t.Errorf("UserConditions() match = %v, want %v", got, tt.want)
+				}
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func count(t *testing.T, tx *gorm.DB) bool {
+	t.Helper()
+	var n int64
+	if err := tx.Count(&n).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n == 1
+}
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"errors"
-	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"solution/internal/shared/config"
 	"time"
@@ -14,14 +13,17 @@ type Claims struct {
 }
 
 func GenerateToken(userID string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+	expirationTime := time.Now().Add(config.GetTokenTTL())
 	claims := &Claims{
 		UserID: userID,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
-	jwtKey := config.GetJwtKey()
+	jwtKey, err := config.GetJwtKey()
+	if err != nil {
+		return "", err
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(jwtKey)
 	if err != nil {
@@ -32,7 +34,10 @@ func GenerateToken(userID string) (string, error) {
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
-	jwtKey := config.GetJwtKey()
+	jwtKey, err := config.GetJwtKey()
+	if err != nil {
+		return nil, err
+	}
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -47,7 +52,6 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	} else {
-		fmt.Println("Invalid token")
 		return nil, errors.New("invalid token")
 	}
 }
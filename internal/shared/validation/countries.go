@@ -1,6 +1,9 @@
-package models
+package validation
 
-var ValidCountryCodes = map[string]struct{}{
+import "strings"
+
+// countryCodes - коды ISO 3166-1 alpha-2
+var countryCodes = map[string]struct{}{
 	"AF": {}, "AX": {}, "AL": {}, "DZ": {}, "AS": {}, "AD": {}, "AO": {}, "AI": {}, "AQ": {}, "AG": {},
 	"AR": {}, "AM": {}, "AW": {}, "AU": {}, "AT": {}, "AZ": {}, "BS": {}, "BH": {}, "BD": {}, "BB": {},
 	"BY": {}, "BE": {}, "BZ": {}, "BJ": {}, "BM": {}, "BT": {}, "BO": {}, "BQ": {}, "BA": {}, "BW": {},
@@ -27,3 +30,9 @@ var ValidCountryCodes = map[string]struct{}{
 	"TV": {}, "UG": {}, "UA": {}, "AE": {}, "GB": {}, "US": {}, "UM": {}, "UY": {}, "UZ": {}, "VU": {},
 	"VE": {}, "VN": {}, "VG": {}, "VI": {}, "WF": {}, "EH": {}, "YE": {}, "ZM": {}, "ZW": {},
 }
+
+// IsCountryCode проверяет код страны ISO 3166-1 alpha-2 без учёта регистра
+func IsCountryCode(code string) bool {
+	_, ok := countryCodes[strings.ToUpper(code)]
+	return ok
+}
@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"solution/internal/shared/config"
+)
+
+type emailPolicy struct {
+	minLength int
+	maxLength int
+	pattern   *regexp.Regexp
+}
+
+var (
+	policyMu sync.RWMutex
+	password = config.PasswordPolicy{
+		MinLength:    8,
+		MaxLength:    60,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+		SpecialChars: "@$!%*?&",
+	}
+	email = emailPolicy{
+		minLength: 8,
+		maxLength: 120,
+		pattern:   regexp.MustCompile(config.DefaultEmailPattern),
+	}
+)
+
+// Configure применяет политики паролей и email из конфигурации. Регулярное выражение компилируется один раз.
+func Configure(cfg *config.Validation) error {
+	pattern, err := regexp.Compile(cfg.Email.Pattern)
+	if err != nil {
+		return fmt.Errorf("email pattern: %w", err)
+	}
+
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	password = cfg.Password
+	email = emailPolicy{
+		minLength: cfg.Email.MinLength,
+		maxLength: cfg.Email.MaxLength,
+		pattern:   pattern,
+	}
+	return nil
+}
+
+// Email - адрес по настроенной политике
+func Email() Rule[string] {
+	return func(value string) string {
+		policyMu.RLock()
+		policy := email
+		policyMu.RUnlock()
+
+		if n := utf8.RuneCountInString(value); n < policy.minLength || n > policy.maxLength {
+			return fmt.Sprintf("must be between %d and %d characters long", policy.minLength, policy.maxLength)
+		}
+		if !policy.pattern.MatchString(value) {
+			return "invalid email format"
+		}
+		return ""
+	}
+}
+
+// Password - пароль по настроенной политике; в сообщении перечислены все требования
+func Password() Rule[string] {
+	return func(value string) string {
+		policyMu.RLock()
+		policy := password
+		policyMu.RUnlock()
+
+		n := utf8.RuneCountInString(value)
+		ok := n >= policy.MinLength && n <= policy.MaxLength
+		if policy.RequireUpper && !strings.ContainsFunc(value, isUpper) {
+			ok = false
+		}
+		if policy.RequireLower && !strings.ContainsFunc(value, isLower) {
+			ok = false
+		}
+		if policy.RequireDigit && !strings.ContainsFunc(value, isDigit) {
+			ok = false
+		}
+		if policy.SpecialChars != "" && !strings.ContainsAny(value, policy.SpecialChars) {
+			ok = false
+		}
+		if ok {
+			return ""
+		}
+		return passwordRequirements(policy)
+	}
+}
+
+// SignInPassword - пароль при входе: только наличие и верхняя граница длины по политике.
+// Требования к составу проверяются при регистрации и смене пароля, иначе после ужесточения
+// политики пользователи со старыми паролями не смогут войти.
+func SignInPassword() Rule[string] {
+	return func(value string) string {
+		policyMu.RLock()
+		maxLength := password.MaxLength
+		policyMu.RUnlock()
+
+		if value == "" {
+			return "is required"
+		}
+		if utf8.RuneCountInString(value) > maxLength {
+			return fmt.Sprintf("must be at most %d characters long", maxLength)
+		}
+		return ""
+	}
+}
+
+func passwordRequirements(policy config.PasswordPolicy) string {
+	var parts []string
+	if policy.RequireUpper {
+		parts = append(parts, "one uppercase letter")
+	}
+	if policy.RequireLower {
+		parts = append(parts, "one lowercase letter")
+	}
+	if policy.RequireDigit {
+		parts = append(parts, "one number")
+	}
+	if policy.SpecialChars != "" {
+		parts = append(parts, fmt.Sprintf("one special character (%s)", policy.SpecialChars))
+	}
+
+	message := fmt.Sprintf("must be between %d and %d characters long", policy.MinLength, policy.MaxLength)
+	if len(parts) > 0 {
+		message += " and contain at least " + strings.Join(parts, ", ")
+	}
+	return message
+}
+
+func isUpper(r rune) bool { return r >= 'A' && r <= 'Z' }
+func isLower(r rune) bool { return r >= 'a' && r <= 'z' }
+func isDigit(r rune) bool { return r >= '0' && r <= '9' }
@@ -0,0 +1,128 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Required - непустая строка
+func Required() Rule[string] {
+	return func(value string) string {
+		if value == "" {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+// Length - длина в символах (рунах) в пределах [min, max]
+func Length(min, max int) Rule[string] {
+	return func(value string) string {
+		if n := utf8.RuneCountInString(value); n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d characters long", min, max)
+		}
+		return ""
+	}
+}
+
+// URL - абсолютный http(s) адрес не длиннее maxLength
+func URL(maxLength int) Rule[string] {
+	return func(value string) string {
+		if len(value) > maxLength {
+			return fmt.Sprintf("must be at most %d characters long", maxLength)
+		}
+		u, err := url.ParseRequestURI(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be a valid http(s) URL"
+		}
+		return ""
+	}
+}
+
+// Range - число в пределах [min, max]
+func Range(min, max int) Rule[int] {
+	return func(value int) string {
+		if value < min || value > max {
+			return fmt.Sprintf("must be between %d and %d", min, max)
+		}
+		return ""
+	}
+}
+
+// OneOf - одно из допустимых значений
+func OneOf(values ...string) Rule[string] {
+	return oneOf(values, func(a, b string) bool { return a == b })
+}
+
+// OneOfFold - одно из допустимых значений без учёта регистра
+func OneOfFold(values ...string) Rule[string] {
+	return oneOf(values, strings.EqualFold)
+}
+
+func oneOf(values []string, equal func(a, b string) bool) Rule[string] {
+	return func(value string) string {
+		for _, allowed := range values {
+			if equal(value, allowed) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of '%s'", strings.Join(values, "', '"))
+	}
+}
+
+// Country - код страны ISO 3166-1 alpha-2
+func Country() Rule[string] {
+	return func(value string) string {
+		if !IsCountryCode(value) {
+			return fmt.Sprintf("must be a valid ISO 3166-1 alpha-2 code, got '%s'", value)
+		}
+		return ""
+	}
+}
+
+// Timezone - имя часового пояса из базы IANA, например Europe/Moscow. Local зависит от сервера и не принимается.
+func Timezone() Rule[string] {
+	return func(value string) string {
+		if _, err := time.LoadLocation(value); err != nil || value == "" || value == "Local" {
+			return fmt.Sprintf("must be an IANA time zone name, got '%s'", value)
+		}
+		return ""
+	}
+}
+
+// NotEmpty - хотя бы один элемент
+func NotEmpty[T any]() Rule[[]T] {
+	return func(values []T) string {
+		if len(values) == 0 {
+			return "must not be empty"
+		}
+		return ""
+	}
+}
+
+// Unique - элементы не повторяются
+func Unique() Rule[[]string] {
+	return unique(func(s string) string { return s })
+}
+
+// UniqueFold - элементы не повторяются без учёта регистра, например коды стран
+func UniqueFold() Rule[[]string] {
+	return unique(strings.ToUpper)
+}
+
+func unique(normalize func(string) string) Rule[[]string] {
+	return func(values []string) string {
+		seen := make(map[string]struct{}, len(values))
+		for _, value := range values {
+			key := normalize(value)
+			if _, duplicate := seen[key]; duplicate {
+				return fmt.Sprintf("must not contain duplicates, got '%s' twice", value)
+			}
+			seen[key] = struct{}{}
+		}
+		return ""
+	}
+}
@@ -0,0 +1,211 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"solution/internal/shared/apperr"
+)
+
+func TestStringRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule[string]
+		value string
+		want  string
+	}{
+		{name: "required", rule: Required(), value: "x"},
+		{name: "required empty", rule: Required(), value: "", want: "is required"},
+		{name: "length in range", rule: Length(2, 4), value: "abcd"},
+		{name: "length counts runes", rule: Length(2, 4), value: "привет", want: "must be between 2 and 4 characters long"},
+		{name: "length too short", rule: Length(2, 4), value: "a", want: "must be between 2 and 4 characters long"},
+		{name: "url", rule: URL(100), value: "https://example.com/a.png"},
+		{name: "url without scheme", rule: URL(100), value: "example.com", want: "must be a valid http(s) URL"},
+		{name: "url other scheme", rule: URL(100), value: "ftp://example.com", want: "must be a valid http(s) URL"},
+		{name: "url without host", rule: URL(100), value: "http://", want: "must be a valid http(s) URL"},
+		{name: "url too long", rule: URL(10), value: "https://example.com", want: "must be at most 10 characters long"},
+		{name: "one of", rule: OneOf("COMMON", "UNIQUE"), value: "UNIQUE"},
+		{name: "one of is case sensitive", rule: OneOf("COMMON", "UNIQUE"), value: "common", want: "must be one of 'COMMON', 'UNIQUE'"},
+		{name: "one of fold", rule: OneOfFold("COMMON", "UNIQUE"), value: "common"},
+		{name: "country", rule: Country(), value: "ru"},
+		{name: "unknown country", rule: Country(), value: "XX", want: "must be a valid ISO 3166-1 alpha-2 code, got 'XX'"},
+		{name: "timezone", rule: Timezone(), value: "Europe/Moscow"},
+		{name: "timezone utc", rule: Timezone(), value: "UTC"},
+		{name: "unknown timezone", rule: Timezone(), value: "Mars/Olympus", want: "must be an IANA time zone name, got 'Mars/Olympus'"},
+		{name: "local timezone", rule: Timezone(), value: "Local", want: "must be an IANA time zone name, got 'Local'"},
+		{name: "empty timezone", rule: Timezone(), value: "", want: "must be an IANA time zone name, got ''"},
+		{name: "password", rule: Password(), value: "Secret1!"},
+		{name: "weak password", rule: Password(), value: "secret", want: "must be between 8 and 60 characters long and contain at least one uppercase letter, one lowercase letter, one number, one special character (@$!%*?&)"},
+		{name: "sign in password ignores policy", rule: SignInPassword(), value: "secret"},
+		{name: "sign in password empty", rule: SignInPassword(), value: "", want: "is required"},
+		{name: "sign in password too long", rule: SignInPassword(), value: strings.Repeat("a", 61), want: "must be at most 60 characters long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule(tt.value); got != tt.want {
+				t.Errorf("rule(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		value int
+		want  string
+	}{
+		{value: 0},
+		{value: 100},
+		{value: -1, want: "must be between 0 and 100"},
+		{value: 101, want: "must be between 0 and 100"},
+	}
+
+	for _, tt := range tests {
+		if got := Range(0, 100)(tt.value); got != tt.want {
+			t.Errorf("Range(0, 100)(%d) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSliceRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule[[]string]
+		values []string
+		want   string
+	}{
+		{name: "unique", rule: Unique(), values: []string{"a", "A"}},
+		{name: "duplicate", rule: Unique(), values: []string{"a", "b", "a"}, want: "must not contain duplicates, got 'a' twice"},
+		{name: "duplicate fold", rule: UniqueFold(), values: []string{"ru", "RU"}, want: "must not contain duplicates, got 'RU' twice"},
+		{name: "not empty", rule: NotEmpty[string](), values: []string{"a"}},
+		{name: "empty", rule: NotEmpty[string](), values: nil, want: "must not be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule(tt.values); got != tt.want {
+				t.Errorf("rule(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+// fields возвращает ошибки Validator так, как их видит клиент в ответе 400
+func fields(err error) []apperr.FieldError {
+	if err == nil {
+		return nil
+	}
+	var result []apperr.FieldError
+	for _, field := range apperr.Validation(err).Fields {
+		result = append(result, apperr.FieldError{Field: field.Field, Message: field.Message})
+	}
+	return result
+}
+
+func TestValidator(t *testing.T) {
+	name := "x"
+	age := 200
+
+	tests := []struct {
+		name     string
+		validate func(v *Validator)
+		want     []apperr.FieldError
+	}{
+		{
+			name: "valid",
+			validate: func(v *Validator) {
+				Check(v, "name", "John", Required(), Length(1, 10))
+				CheckPtr(v, "age", (*int)(nil), Range(0, 100))
+			},
+		},
+		{
+			name: "first failed rule per field",
+			validate: func(v *Validator) {
+				Check(v, "name", "", Required(), Length(1, 10))
+			},
+			want: []apperr.FieldError{{Field: "name", Message: "is required"}},
+		},
+		{
+			name: "all fields are reported",
+			validate: func(v *Validator) {
+				CheckPtr(v, "name", &name, Length(2, 10))
+				CheckPtr(v, "age", &age, Range(0, 100))
+			},
+			want: []apperr.FieldError{
+				{Field: "name", Message: "must be between 2 and 10 characters long"},
+				{Field: "age", Message: "must be between 0 and 100"},
+			},
+		},
+		{
+			name: "each element has its own path",
+			validate: func(v *Validator) {
+				CheckEach(v, "countries", []string{"RU", "XX", "YY"}, Country())
+			},
+			want: []apperr.FieldError{
+				{Field: "countries[1]", Message: "must be a valid ISO 3166-1 alpha-2 code, got 'XX'"},
+				{Field: "countries[2]", Message: "must be a valid ISO 3166-1 alpha-2 code, got 'YY'"},
+			},
+		},
+		{
+			name: "nested errors get the prefix",
+			validate: func(v *Validator) {
+				target := New()
+				Check(target, "age_from", -1, Range(0, 100))
+				CheckEach(target, "countries", []string{"XX"}, Country())
+				v.Nest("target", target.Err())
+				v.Nest("limits", nil)
+			},
+			want: []apperr.FieldError{
+				{Field: "target.age_from", Message: "must be between 0 and 100"},
+				{Field: "target.countries[0]", Message: "must be a valid ISO 3166-1 alpha-2 code, got 'XX'"},
+			},
+		},
+		{
+			name: "nested plain error takes the prefix as path",
+			validate: func(v *Validator) {
+				v.Nest("target", errors.New("invalid target"))
+			},
+			want: []apperr.FieldError{{Field: "target", Message: "invalid target"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			tt.validate(v)
+
+			if v.Valid() != (len(tt.want) == 0) {
+				t.Errorf("Valid() = %v with errors %v", v.Valid(), tt.want)
+			}
+			if got := fields(v.Err()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrMessage(t *testing.T) {
+	v := New()
+	v.Add("name", "is required")
+	v.Add("age", "must be between 0 and 100")
+
+	err := v.Err()
+	if got, want := err.Error(), "is required\nmust be between 0 and 100"; got != want {
+		t.Errorf("Err() = %q, want %q", got, want)
+	}
+
+	var field *apperr.FieldError
+	if !errors.As(err, &field) || field.Field != "name" {
+		t.Errorf("errors.As(FieldError) = %+v, want the first field", field)
+	}
+	if appErr := apperr.Validation(err); appErr.Code != apperr.CodeValidation {
+		t.Errorf("Validation code = %s, want %s", appErr.Code, apperr.CodeValidation)
+	}
+
+	if New().Err() != nil {
+		t.Error("Err() of an empty validator must be nil")
+	}
+}
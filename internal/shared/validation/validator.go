@@ -0,0 +1,69 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"solution/internal/shared/apperr"
+)
+
+// Validator собирает ошибки всех полей, а не только первую. Пути полей - как в JSON, через точку.
+type Validator struct {
+	errs []error
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Add добавляет ошибку поля
+func (v *Validator) Add(field, message string) {
+	v.errs = append(v.errs, apperr.Field(field, message))
+}
+
+// Nest добавляет ошибки вложенного объекта с префиксом пути, nil игнорируется
+func (v *Validator) Nest(prefix string, err error) {
+	if err != nil {
+		v.errs = append(v.errs, apperr.Nest(prefix, err))
+	}
+}
+
+// Valid сообщает, что ошибок пока нет - для проверок, зависящих от корректности отдельных полей
+func (v *Validator) Valid() bool {
+	return len(v.errs) == 0
+}
+
+// Err возвращает все ошибки через errors.Join или nil
+func (v *Validator) Err() error {
+	return errors.Join(v.errs...)
+}
+
+// Rule проверяет значение и возвращает текст ошибки или пустую строку
+type Rule[T any] func(value T) string
+
+// Check применяет правила по порядку; для поля фиксируется первая нарушенная
+func Check[T any](v *Validator, field string, value T, rules ...Rule[T]) bool {
+	for _, rule := range rules {
+		if message := rule(value); message != "" {
+			v.Add(field, message)
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPtr применяет правила к необязательному полю, nil считается корректным
+func CheckPtr[T any](v *Validator, field string, value *T, rules ...Rule[T]) bool {
+	if value == nil {
+		return true
+	}
+	return Check(v, field, *value, rules...)
+}
+
+// CheckEach применяет правила к каждому элементу, путь элемента - field[i]
+func CheckEach[T any](v *Validator, field string, values []T, rules ...Rule[T]) bool {
+	ok := true
+	for i, value := range values {
+		ok = Check(v, fmt.Sprintf("%s[%d]", field, i), value, rules...) && ok
+	}
+	return ok
+}
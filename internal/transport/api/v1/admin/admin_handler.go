@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/admin/dto"
+	"strconv"
+)
+
+func (h *Handler) ListUsers(c *gin.Context) {
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	users, totalCount, err := h.Admin.ListUsers(c.Request.Context(), c.Query("query"), limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(totalCount, 10))
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *Handler) ListCompanies(c *gin.Context) {
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	companies, totalCount, err := h.Admin.ListCompanies(c.Request.Context(), c.Query("query"), limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(totalCount, 10))
+	c.JSON(http.StatusOK, companies)
+}
+
+func (h *Handler) BlockUser(c *gin.Context) {
+	h.setUserBlocked(c, true)
+}
+
+func (h *Handler) UnblockUser(c *gin.Context) {
+	h.setUserBlocked(c, false)
+}
+
+func (h *Handler) setUserBlocked(c *gin.Context, blocked bool) {
+	req, err := bindAction(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := pathID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	adminID, err := currentAdmin(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.Admin.SetUserBlocked(c.Request.Context(), adminID, id, blocked, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *Handler) BlockCompany(c *gin.Context) {
+	h.setCompanyBlocked(c, true)
+}
+
+func (h *Handler) UnblockCompany(c *gin.Context) {
+	h.setCompanyBlocked(c, false)
+}
+
+func (h *Handler) setCompanyBlocked(c *gin.Context, blocked bool) {
+	req, err := bindAction(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := pathID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	adminID, err := currentAdmin(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.Admin.SetCompanyBlocked(c.Request.Context(), adminID, id, blocked, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *Handler) ArchivePromo(c *gin.Context) {
+	req, err := bindAction(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := pathID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	adminID, err := currentAdmin(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.Admin.ArchivePromo(c.Request.Context(), adminID, id, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// RemoveComment принимает причину в query параметре reason: у DELETE нет тела
+func (h *Handler) RemoveComment(c *gin.Context) {
+	id, err := pathID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	req := dto.ActionRequest{Reason: c.Query("reason")}
+	if err := req.Validate(); err != nil {
+		c.Error(apperr.Validation(err))
+		return
+	}
+
+	adminID, err := currentAdmin(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.Admin.RemoveComment(c.Request.Context(), adminID, id, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *Handler) GetActivations(c *gin.Context) {
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filter := dto.ActivationFilter{
+		PromoID: c.Query("promo_id"),
+		UserID:  c.Query("user_id"),
+	}
+	for field, value := range map[string]string{"promo_id": filter.PromoID, "user_id": filter.UserID} {
+		if _, err := uuid.Parse(value); value != "" && err != nil {
+			c.Error(apperr.Validation(apperr.Field(field, field+" must be a uuid")))
+			return
+		}
+	}
+
+	activations, totalCount, err := h.Admin.GetActivations(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(totalCount, 10))
+	c.JSON(http.StatusOK, activations)
+}
+
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	entries, totalCount, err := h.Admin.GetAuditLog(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(totalCount, 10))
+	c.JSON(http.StatusOK, entries)
+}
+
+// bindAction читает необязательное тело с причиной действия
+func bindAction(c *gin.Context) (dto.ActionRequest, error) {
+	var req dto.ActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		return req, apperr.Binding(err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return req, apperr.Validation(err)
+	}
+	return req, nil
+}
+
+// currentAdmin возвращает ID администратора из субъекта запроса, заполненного AuthMiddleware
+func currentAdmin(c *gin.Context) (string, error) {
+	principal, err := models.CurrentPrincipal(c.Request.Context())
+	if err != nil {
+		return "", err
+	}
+	if !principal.IsAdmin() {
+		return "", apperr.ErrUnauthorized
+	}
+	return principal.AdminID, nil
+}
+
+// pathID проверяет идентификатор из пути заранее: он попадает в журнал, где колонка target_id - uuid
+func pathID(c *gin.Context) (string, error) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return "", apperr.Validation(apperr.Field("id", "id must be a uuid"))
+	}
+	return id, nil
+}
+
+func parsePage(c *gin.Context) (int, int, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 0 {
+		return 0, 0, apperr.Validation(apperr.Field("limit", "limit must be a non-negative integer"))
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, apperr.Validation(apperr.Field("offset", "offset must be a non-negative integer"))
+	}
+
+	return limit, offset, nil
+}
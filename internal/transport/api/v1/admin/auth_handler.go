@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models/admin/dto"
+	"strings"
+)
+
+func (h *Handler) SignIn(c *gin.Context) {
+	var req dto.SignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.Binding(err))
+		return
+	}
+
+	req.Email = strings.ToLower(req.Email)
+
+	if err := req.Validate(); err != nil {
+		c.Error(apperr.Validation(err))
+		return
+	}
+
+	token, err := h.Auth.AuthenticateAdmin(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"os"
+	"solution/internal/service/admin"
+	"solution/internal/service/services"
+	"solution/internal/transport/api/v1/admin/middleware"
+)
+
+type AdminHandler interface {
+	Route(r *gin.Engine)
+	RouteAdminAuth(r *gin.Engine)
+	RouteAdmin(r *gin.Engine)
+}
+
+type Handler struct {
+	Auth  admin.AuthService
+	Admin admin.AdminService
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+
+	err := services.GetService(&h.Auth)
+	if err != nil {
+		slog.Error("Failed to get AuthService", "error", err)
+		os.Exit(1)
+	}
+
+	err = services.GetService(&h.Admin)
+	if err != nil {
+		slog.Error("Failed to get AdminService", "error", err)
+		os.Exit(1)
+	}
+
+	return h
+}
+
+func (h *Handler) Route(r *gin.Engine) {
+	h.RouteAdminAuth(r)
+	h.RouteAdmin(r)
+}
+
+func (h *Handler) RouteAdminAuth(r *gin.Engine) {
+	adminAuth := r.Group("api/admin/auth")
+	{
+		adminAuth.POST("/sign-in", h.SignIn)
+	}
+}
+
+func (h *Handler) RouteAdmin(r *gin.Engine) {
+	a := r.Group("api/admin")
+	a.Use(middleware.AuthMiddleware())
+	{
+		a.GET("/users", h.ListUsers)
+		a.POST("/users/:id/block", h.BlockUser)
+		a.POST("/users/:id/unblock", h.UnblockUser)
+
+		a.GET("/companies", h.ListCompanies)
+		a.POST("/companies/:id/block", h.BlockCompany)
+		a.POST("/companies/:id/unblock", h.UnblockCompany)
+
+		a.POST("/promos/:id/archive", h.ArchivePromo)
+		a.DELETE("/comments/:id", h.RemoveComment)
+
+		a.GET("/activations", h.GetActivations)
+		a.GET("/audit-log", h.GetAuditLog)
+
+		a.GET("/log-level", h.GetLogLevel)
+		a.PUT("/log-level", h.SetLogLevel)
+	}
+}
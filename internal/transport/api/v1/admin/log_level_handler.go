@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/logger"
+	"solution/internal/shared/models/admin/dto"
+	"strings"
+)
+
+func (h *Handler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LogLevelResponse{Level: levelName()})
+}
+
+// SetLogLevel меняет уровень логирования процесса без перезапуска. Значение действует
+// до следующего запуска: logging.level в конфигурации не меняется.
+func (h *Handler) SetLogLevel(c *gin.Context) {
+	var req dto.LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.Binding(err))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.Error(apperr.Validation(err))
+		return
+	}
+
+	adminID, err := currentAdmin(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	previous := levelName()
+	if err := logger.SetLevel(req.Level); err != nil {
+		c.Error(apperr.Validation(apperr.Field("level", err.Error())))
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Log level changed", "admin_id", adminID, "from", previous, "to", levelName())
+
+	c.JSON(http.StatusOK, dto.LogLevelResponse{Level: levelName()})
+}
+
+func levelName() string {
+	return strings.ToLower(logger.Level().String())
+}
@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"solution/internal/service/services"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/logger"
+	"solution/internal/shared/models"
+)
+
+func newLogLevelRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	// субъект запроса, который иначе заполнил бы AuthMiddleware
+	if err := services.AddScoped(func() *models.Principal { return &models.Principal{AdminID: "admin-id"} }); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(services.NewServiceContext(c.Request.Context()))
+		c.Next()
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			c.Status(apperr.From(c.Errors.Last().Err).Status())
+		}
+	})
+
+	h := &Handler{}
+	router.GET("/log-level", h.GetLogLevel)
+	router.PUT("/log-level", h.SetLogLevel)
+	return router
+}
+
+func TestSetLogLevel(t *testing.T) {
+	defer logger.SetLevel(levelName())
+	if err := logger.SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+	router := newLogLevelRouter(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		level  string
+	}{
+		{name: "debug", body: `{"level":"debug"}`, status: http.StatusOK, level: "debug"},
+		{name: "back to warn", body: `{"level":"warn"}`, status: http.StatusOK, level: "warn"},
+		{name: "unknown level", body: `{"level":"verbose"}`, status: http.StatusBadRequest, level: "warn"},
+		{name: "wrong case", body: `{"level":"DEBUG"}`, status: http.StatusBadRequest, level: "warn"},
+		{name: "missing level", body: `{}`, status: http.StatusBadRequest, level: "warn"},
+		{name: "broken json", body: `{`, status: http.StatusBadRequest, level: "warn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body)
+			}
+			if got := levelName(); got != tt.level {
+				t.Errorf("level = %q, want %q", got, tt.level)
+			}
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/log-level", nil))
+			if want := `{"level":"` + tt.level + `"}`; rec.Body.String() != want {
+				t.Errorf("GET /log-level = %s, want %s", rec.Body, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	repo "solution/internal/repository/admin"
+	"solution/internal/service/services"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models"
+	"solution/internal/shared/utils"
+	"strings"
+)
+
+// AuthMiddleware пропускает только токены администраторов: они хранятся отдельно от токенов
+// компаний и пользователей, поэтому чужой токен не найдётся в белом списке
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader {
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		adminID := claims.UserID
+		if adminID == "" {
+			slog.WarnContext(c.Request.Context(), "Invalid user_id in claims")
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		var repository repo.AuthRepository
+
+		err = services.GetService(&repository)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+
+		existingToken, err := repository.ValidateToken(c.Request.Context(), adminID)
+		if err != nil {
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		if existingToken != tokenString {
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		principal, err := models.CurrentPrincipal(c.Request.Context())
+		if err != nil {
+			abort(c, err)
+			return
+		}
+
+		principal.AdminID = adminID
+
+		c.Next()
+	}
+}
+
+// abort прерывает цепочку обработчиков, ответ с ошибкой пишет общий ErrorMiddleware
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,71 @@
+package b2b
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models/b2b/dto"
+)
+
+func (h *Handler) CreateApiKey(c *gin.Context) {
+	var req dto.ApiKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.Binding(err))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.Error(apperr.Validation(err))
+		return
+	}
+
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
+
+	apiKey, err := h.ApiKey.CreateApiKey(c.Request.Context(), company.CompanyID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+func (h *Handler) GetApiKeys(c *gin.Context) {
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.ApiKey.GetApiKeys(c.Request.Context(), company.CompanyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *Handler) RevokeApiKey(c *gin.Context) {
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
+
+	// id сравнивается с uuid колонкой: чужой формат - такого ключа просто нет, а не ошибка БД
+	keyID := c.Param("id")
+	if _, err := uuid.Parse(keyID); err != nil {
+		c.Error(dto.ErrApiKeyNotFound)
+		return
+	}
+
+	if err := h.ApiKey.RevokeApiKey(c.Request.Context(), company.CompanyID, keyID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -1,11 +1,9 @@
 package b2b
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
-	"log"
 	"net/http"
-	"solution/internal/service/b2b"
+	"solution/internal/shared/apperr"
 	"solution/internal/shared/models/b2b/dto"
 	"strings"
 )
@@ -13,32 +11,24 @@ import (
 func (h *Handler) SignUp(c *gin.Context) {
 	var req dto.SignUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error parsing request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Binding(err))
 		return
 	}
 
 	req.Email = strings.ToLower(req.Email)
 
 	if err := req.Validate(); err != nil {
-		log.Println("Error parsing request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Validation(err))
 		return
 	}
 
-	token, companyID, err := h.Auth.RegisterCompany(dto.SignUpRequest{
+	token, companyID, err := h.Auth.RegisterCompany(c.Request.Context(), dto.SignUpRequest{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password,
 	})
 	if err != nil {
-		if errors.Is(err, b2b.ErrEmailAlreadyRegistered) {
-			log.Println("Error parsing request:", err)
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
-		} else {
-			log.Println("Error parsing request:", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Internal server error"})
-		}
+		c.Error(err)
 		return
 	}
 
@@ -51,31 +41,23 @@ func (h *Handler) SignUp(c *gin.Context) {
 func (h *Handler) SignIn(c *gin.Context) {
 	var req dto.SignInRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error parsing request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Binding(err))
 		return
 	}
 
 	req.Email = strings.ToLower(req.Email)
 
 	if err := req.Validate(); err != nil {
-		log.Println("Error parsing request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Validation(err))
 		return
 	}
 
-	token, err := h.Auth.AuthenticateCompany(dto.SignInRequest{
+	token, err := h.Auth.AuthenticateCompany(c.Request.Context(), dto.SignInRequest{
 		Email:    req.Email,
 		Password: req.Password,
 	})
 	if err != nil {
-		if errors.Is(err, b2b.ErrInvalidCredentials) {
-			log.Println("Error parsing request:", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		} else {
-			log.Println("Error parsing request:", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Internal server error"})
-		}
+		c.Error(err)
 		return
 	}
 
@@ -2,9 +2,11 @@ package b2b
 
 import (
 	"github.com/gin-gonic/gin"
-	"log"
+	"log/slog"
+	"os"
 	"solution/internal/service/b2b"
 	"solution/internal/service/services"
+	models "solution/internal/shared/models/b2b"
 	"solution/internal/transport/api/v1/b2b/middleware"
 )
 
@@ -18,12 +20,20 @@ type BusinessHandler interface {
 	GetPromos(c *gin.Context)
 	GetPromoByID(c *gin.Context)
 	UpdatePromo(c *gin.Context)
+	UpdatePromoCodes(c *gin.Context)
 	GetPromoStat(c *gin.Context)
+	GetPromoHistory(c *gin.Context)
+	EstimateAudience(c *gin.Context)
+	RouteBusinessApiKeys(r *gin.Engine)
+	CreateApiKey(c *gin.Context)
+	GetApiKeys(c *gin.Context)
+	RevokeApiKey(c *gin.Context)
 }
 
 type Handler struct {
-	Auth  b2b.AuthService
-	Promo b2b.PromoService
+	Auth   b2b.AuthService
+	Promo  b2b.PromoService
+	ApiKey b2b.ApiKeyService
 }
 
 func NewHandler() *Handler {
@@ -31,12 +41,20 @@ func NewHandler() *Handler {
 
 	err := services.GetService(&h.Auth)
 	if err != nil {
-		log.Fatalf("Failed to get AuthService: %v", err)
+		slog.Error("Failed to get AuthService", "error", err)
+		os.Exit(1)
 	}
 
 	err = services.GetService(&h.Promo)
 	if err != nil {
-		log.Fatalf("Failed to get AuthService: %v", err)
+		slog.Error("Failed to get PromoService", "error", err)
+		os.Exit(1)
+	}
+
+	err = services.GetService(&h.ApiKey)
+	if err != nil {
+		slog.Error("Failed to get ApiKeyService", "error", err)
+		os.Exit(1)
 	}
 
 	return h
@@ -45,6 +63,7 @@ func NewHandler() *Handler {
 func (h *Handler) Route(r *gin.Engine) {
 	h.RouteBusinessAuth(r)
 	h.RouteBusinessPromo(r)
+	h.RouteBusinessApiKeys(r)
 }
 
 func (h *Handler) RouteBusinessAuth(r *gin.Engine) {
@@ -60,10 +79,23 @@ func (h *Handler) RouteBusinessPromo(r *gin.Engine) {
 	businessPromo := r.Group("api/business/promo")
 	businessPromo.Use(middleware.AuthMiddleware())
 	{
-		businessPromo.POST("", h.CreatePromo)
-		businessPromo.GET("", h.GetPromos)
-		businessPromo.GET("/:id", h.GetPromoByID)
-		businessPromo.PATCH("/:id", h.UpdatePromo)
-		businessPromo.GET("/:id/stat", h.GetPromoStat)
+		businessPromo.POST("", middleware.RequireScope(models.ScopePromoWrite), h.CreatePromo)
+		businessPromo.GET("", middleware.RequireScope(models.ScopePromoRead), h.GetPromos)
+		businessPromo.POST("/audience-estimate", middleware.RequireScope(models.ScopePromoRead), h.EstimateAudience)
+		businessPromo.GET("/:id", middleware.RequireScope(models.ScopePromoRead), h.GetPromoByID)
+		businessPromo.PATCH("/:id", middleware.RequireScope(models.ScopePromoWrite), h.UpdatePromo)
+		businessPromo.PUT("/:id/codes", middleware.RequireScope(models.ScopePromoWrite), h.UpdatePromoCodes)
+		businessPromo.GET("/:id/stat", middleware.RequireScope(models.ScopeStatsRead), h.GetPromoStat)
+		businessPromo.GET("/:id/history", middleware.RequireScope(models.ScopePromoRead), h.GetPromoHistory)
+	}
+}
+
+func (h *Handler) RouteBusinessApiKeys(r *gin.Engine) {
+	apiKeys := r.Group("api/business/api-keys")
+	apiKeys.Use(middleware.AuthMiddleware(), middleware.RequireToken())
+	{
+		apiKeys.POST("", h.CreateApiKey)
+		apiKeys.GET("", h.GetApiKeys)
+		apiKeys.DELETE("/:id", h.RevokeApiKey)
 	}
 }
@@ -1,11 +1,12 @@
 package middleware
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
-	"net/http"
+	"log/slog"
+	"solution/internal/service/b2b"
 	"solution/internal/service/services"
-	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models"
 	"solution/internal/shared/utils"
 
 	repo "solution/internal/repository/b2b"
@@ -16,30 +17,31 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		if apiKey := strings.TrimPrefix(authHeader, "ApiKey "); apiKey != authHeader {
+			authenticateApiKey(c, apiKey)
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
 		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
 		companyID := claims.UserID
 		if companyID == "" {
-			fmt.Println("Invalid user_id in claims")
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			slog.WarnContext(c.Request.Context(), "Invalid user_id in claims")
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
@@ -47,23 +49,109 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		err = services.GetService(&repository)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			abort(c, err)
 			return
 		}
 
-		existingToken, err := repository.ValidateToken(companyID)
+		existingToken, err := repository.ValidateToken(c.Request.Context(), companyID)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
 		if existingToken != tokenString {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
+			abort(c, apperr.ErrUnauthorized)
+			return
+		}
+
+		if !setPrincipal(c, &models.Principal{CompanyID: companyID}) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func authenticateApiKey(c *gin.Context, key string) {
+	var service b2b.ApiKeyService
+
+	err := services.GetService(&service)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	apiKey, err := service.AuthenticateApiKey(c.Request.Context(), key)
+	if err != nil {
+		abort(c, apperr.ErrUnauthorized)
+		return
+	}
+
+	if !setPrincipal(c, &models.Principal{CompanyID: apiKey.CompanyID, ApiKeyID: apiKey.ID, Scopes: apiKey.Scopes}) {
+		return
+	}
+
+	// last_used_at обновляется при закрытии области запроса, уже после ответа
+	var usage *b2b.ApiKeyUsage
+	if err := services.GetServiceForContext(c.Request.Context(), &usage); err != nil {
+		abort(c, err)
+		return
+	}
+	usage.Record(apiKey.ID)
+
+	c.Next()
+}
+
+// setPrincipal заполняет scoped субъекта запроса; при ошибке запрос прерывается
+func setPrincipal(c *gin.Context, principal *models.Principal) bool {
+	current, err := models.CurrentPrincipal(c.Request.Context())
+	if err != nil {
+		abort(c, err)
+		return false
+	}
+
+	*current = *principal
+	return true
+}
+
+// RequireScope пропускает запросы по JWT без ограничений, а для API ключей проверяет наличие скоупа
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := models.CurrentPrincipal(c.Request.Context())
+		if err != nil {
+			abort(c, err)
 			return
 		}
 
-		c.Set("company_id", companyID)
+		if !principal.HasScope(scope) {
+			abort(c, apperr.ErrForbidden)
+			return
+		}
 
 		c.Next()
 	}
 }
+
+// RequireToken запрещает доступ по API ключу - например, к управлению самими ключами
+func RequireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := models.CurrentPrincipal(c.Request.Context())
+		if err != nil {
+			abort(c, err)
+			return
+		}
+
+		if principal.IsApiKey() {
+			abort(c, apperr.ErrForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// abort прерывает цепочку обработчиков, ответ с ошибкой пишет общий ErrorMiddleware
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -2,11 +2,12 @@ package b2b
 
 import (
 	"errors"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"log"
 	"net/http"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/etag"
+	"solution/internal/shared/models"
 	"solution/internal/shared/models/b2b/dto"
 	"strconv"
 )
@@ -14,25 +15,25 @@ import (
 func (h *Handler) CreatePromo(c *gin.Context) {
 	var req dto.PromoCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error binding company:", err)
-		c.JSON(http.StatusBadRequest, dto.ErrorBadRequest)
+		c.Error(apperr.Binding(err))
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		log.Println("Error validating company:", err)
-		c.JSON(http.StatusBadRequest, dto.ErrorBadRequest)
+		c.Error(apperr.Validation(err))
 		return
 	}
 
-	companyID := c.GetString("company_id")
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
 
-	req.CompanyID = companyID
+	req.CompanyID = company.CompanyID
 
-	promoID, err := h.Promo.CreatePromo(req)
+	promoID, err := h.Promo.CreatePromo(c.Request.Context(), req, company.Actor())
 	if err != nil {
-		log.Println("Error creating promo:", err)
-		c.JSON(http.StatusBadRequest, dto.ErrorInternalServer)
+		c.Error(err)
 		return
 	}
 
@@ -40,8 +41,10 @@ func (h *Handler) CreatePromo(c *gin.Context) {
 }
 
 func (h *Handler) GetPromos(c *gin.Context) {
-	companyID := c.GetString("company_id")
-	fmt.Println(companyID)
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
 	limitStr := c.DefaultQuery("limit", "10")
 	offsetStr := c.DefaultQuery("offset", "0")
 	sortBy := c.Query("sort_by") // Изменено на Query
@@ -49,27 +52,24 @@ func (h *Handler) GetPromos(c *gin.Context) {
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
-		log.Println("Error parsing limit:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		c.Error(apperr.Validation(apperr.Field("limit", "limit must be an integer")))
 		return
 	}
 
 	offset, err := strconv.Atoi(offsetStr)
 	if err != nil {
-		log.Println("Error parsing offset:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+		c.Error(apperr.Validation(apperr.Field("offset", "offset must be an integer")))
 		return
 	}
 
 	if sortBy != "" && sortBy != "active_from" && sortBy != "active_until" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort_by parameter"})
+		c.Error(apperr.Validation(apperr.Field("sort_by", "sort_by must be either 'active_from' or 'active_until'")))
 		return
 	}
 
-	promos, totalCount, err := h.Promo.GetPromos(companyID, limit, offset, sortBy, country)
+	promos, totalCount, err := h.Promo.GetPromos(c.Request.Context(), company.CompanyID, limit, offset, sortBy, country)
 	if err != nil {
-		log.Println("Error getting promos:", err)
-		c.JSON(http.StatusBadRequest, dto.ErrorBadRequest.Message)
+		c.Error(err)
 		return
 	}
 
@@ -79,82 +79,192 @@ func (h *Handler) GetPromos(c *gin.Context) {
 }
 
 func (h *Handler) GetPromoByID(c *gin.Context) {
-	companyID := c.GetString("company_id")
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
 
 	promoID := c.Param("id")
 
-	promo, err := h.Promo.GetPromoByID(companyID, promoID)
+	promo, err := h.Promo.GetPromoByID(c.Request.Context(), company.CompanyID, promoID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, dto.ErrNotFound) {
-			log.Println("Promo not found:", err)
-			c.JSON(http.StatusNotFound, dto.ErrorPromoNotFound.Error())
-		} else if errors.Is(err, dto.ErrorNoAccess) {
-			log.Println("Access denied to promo:", err)
-			c.JSON(http.StatusForbidden, dto.ErrorNoAccessToPromo.Error())
-		} else {
-			log.Println("Error fetching promo:", err)
-			c.JSON(http.StatusBadRequest, dto.ErrorBadRequest.Message)
-		}
+		c.Error(promoError(err))
+		return
+	}
+
+	tag, err := etag.ForBody(promo.Version, promo)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", tag)
+	if etag.NoneMatch(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
 	c.JSON(http.StatusOK, promo)
 }
 
+// UpdatePromo принимает тело как JSON Merge Patch (RFC 7396): null очищает поле, проверяется результат слияния
 func (h *Handler) UpdatePromo(c *gin.Context) {
-	var req dto.PromoPatchRequest
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.Error(apperr.Binding(err))
+		return
+	}
+
+	match, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
+	promoID := c.Param("id")
+
+	updatedPromo, err := h.Promo.UpdatePromo(c.Request.Context(), company.CompanyID, promoID, patch, match, company.Actor())
+	if err != nil {
+		c.Error(promoError(err))
+		return
+	}
+
+	tag, err := etag.ForBody(updatedPromo.Version, updatedPromo)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", tag)
+	c.JSON(http.StatusOK, updatedPromo)
+}
+
+// UpdatePromoCodes меняет коды и режим промокода; выданные коды не удаляются и не выдаются повторно
+func (h *Handler) UpdatePromoCodes(c *gin.Context) {
+	var req dto.PromoCodesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error binding promo patch request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		c.Error(apperr.Binding(err))
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		log.Println("Error validating promo patch request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Validation(err))
+		return
+	}
+
+	match, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	companyID := c.GetString("company_id")
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
 	promoID := c.Param("id")
 
-	updatedPromo, err := h.Promo.UpdatePromo(companyID, promoID, req)
+	updatedPromo, err := h.Promo.UpdatePromoCodes(c.Request.Context(), company.CompanyID, promoID, req, match, company.Actor())
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, dto.ErrNotFound) {
-			log.Println("Promo not found:", err)
-			c.JSON(http.StatusNotFound, dto.ErrorPromoNotFound.Error())
-		} else if errors.Is(err, dto.ErrorNoAccess) {
-			log.Println("Access denied to promo:", err)
-			c.JSON(http.StatusForbidden, dto.ErrorNoAccessToPromo.Error())
-		} else if errors.Is(err, dto.ErrBadRequest) {
-			log.Println("Error patching promo:", err)
-			c.JSON(http.StatusBadRequest, dto.ErrorBadRequest.Message)
-		} else {
-			log.Println("Error fetching promo:", err)
-			c.JSON(http.StatusBadRequest, dto.ErrorBadRequest.Message)
-		}
+		c.Error(promoError(err))
 		return
 	}
 
+	tag, err := etag.ForBody(updatedPromo.Version, updatedPromo)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", tag)
 	c.JSON(http.StatusOK, updatedPromo)
 }
 
 func (h *Handler) GetPromoStat(c *gin.Context) {
-	companyID := c.GetString("company_id")
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
 	promoID := c.Param("id")
 
-	promoStat, err := h.Promo.GetPromoStatByID(companyID, promoID)
+	promoStat, err := h.Promo.GetPromoStatByID(c.Request.Context(), company.CompanyID, promoID)
 	if err != nil {
-		if errors.Is(err, dto.ErrorPromoNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": dto.ErrorPromoNotFound.Error()})
-		} else if errors.Is(err, dto.ErrorNoAccessToPromo) {
-			c.JSON(http.StatusForbidden, gin.H{"error": dto.ErrorNoAccessToPromo.Error()})
-		} else {
-			log.Println("Error getting promo stat:", err)
-			c.JSON(http.StatusBadRequest, dto.ErrorBadRequest.Message)
-		}
+		c.Error(promoError(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, promoStat)
 }
+
+func (h *Handler) GetPromoHistory(c *gin.Context) {
+	company, ok := currentCompany(c)
+	if !ok {
+		return
+	}
+	promoID := c.Param("id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 0 {
+		c.Error(apperr.Validation(apperr.Field("limit", "limit must be a non-negative integer")))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.Error(apperr.Validation(apperr.Field("offset", "offset must be a non-negative integer")))
+		return
+	}
+
+	changes, totalCount, err := h.Promo.GetPromoHistory(c.Request.Context(), company.CompanyID, promoID, limit, offset)
+	if err != nil {
+		c.Error(promoError(err))
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(totalCount, 10))
+
+	c.JSON(http.StatusOK, changes)
+}
+
+func (h *Handler) EstimateAudience(c *gin.Context) {
+	var target models.Target
+	if err := c.ShouldBindJSON(&target); err != nil {
+		c.Error(apperr.Binding(err))
+		return
+	}
+
+	if err := target.Validate(); err != nil {
+		c.Error(apperr.Validation(err))
+		return
+	}
+
+	estimate, err := h.Promo.EstimateAudience(c.Request.Context(), target)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
+
+// currentCompany возвращает субъекта запроса, заполненного AuthMiddleware. Ошибку сразу передаёт в c.Error.
+func currentCompany(c *gin.Context) (*models.Principal, bool) {
+	principal, err := models.CurrentPrincipal(c.Request.Context())
+	if err == nil && !principal.IsCompany() {
+		err = apperr.ErrUnauthorized
+	}
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return principal, true
+}
+
+// promoError сводит отсутствие записи в репозитории к 404 "promo not found"
+func promoError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, dto.ErrNotFound) {
+		return apperr.Wrap(apperr.CodeNotFound, dto.ErrorPromoNotFound.Message, err)
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package b2b
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"solution/internal/repository/memory"
+	"solution/internal/service/b2b"
+	"solution/internal/service/services"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/etag"
+	"solution/internal/shared/models"
+	"solution/internal/shared/models/b2b/dto"
+)
+
+func TestGetPromoHistoryPagination(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	auth := memory.NewB2BAuthRepository(store)
+	companyID, err := auth.CreateCompany(ctx, dto.SignUpRequest{Name: "Company", Email: "company@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherID, err := auth.CreateCompany(ctx, dto.SignUpRequest{Name: "Other company", Email: "other@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := b2b.NewPromoService(memory.NewB2BPromoRepository(store))
+	actor := models.Actor{Type: models.ActorCompany, ID: companyID}
+	newPromo := func(companyID string) string {
+		maxCount := 10
+		promoID, err := service.CreatePromo(ctx, dto.PromoCreateRequest{
+			CompanyID:   companyID,
+			Description: "Promo description",
+			Mode:        "COMMON",
+			PromoCommon: "CODE",
+			Target:      &models.Target{},
+			MaxCount:    &maxCount,
+		}, models.Actor{Type: models.ActorCompany, ID: companyID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return promoID
+	}
+
+	// создание и четыре изменения - пять записей истории
+	promoID := newPromo(companyID)
+	for i := 1; i <= 4; i++ {
+		patch := fmt.Sprintf(`{"max_count": %d}`, 10+i)
+		if _, err := service.UpdatePromo(ctx, companyID, promoID, []byte(patch), etag.Condition{}, actor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	foreignID := newPromo(otherID)
+
+	// субъект запроса, который иначе заполнил бы AuthMiddleware
+	if err := services.AddScoped(func() *models.Principal { return &models.Principal{CompanyID: companyID} }); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(services.NewServiceContext(c.Request.Context()))
+		c.Next()
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			c.Status(apperr.From(c.Errors.Last().Err).Status())
+		}
+	})
+	router.GET("/promo/:id/history", (&Handler{Promo: service}).GetPromoHistory)
+
+	get := func(t *testing.T, target string) (*httptest.ResponseRecorder, []string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			return rec, nil
+		}
+
+		var changes []dto.PromoChangeResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &changes); err != nil {
+			t.Fatalf("GET %s: %v (%s)", target, err, rec.Body)
+		}
+		ids := make([]string, len(changes))
+		for i, change := range changes {
+			ids[i] = change.ID
+		}
+		return rec, ids
+	}
+
+	_, all := get(t, "/promo/"+promoID+"/history")
+	if len(all) != 5 {
+		t.Fatalf("history has %d changes, want 5", len(all))
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		want   []string
+	}{
+		{name: "first page", query: "?limit=2", status: http.StatusOK, want: all[:2]},
+		{name: "second page", query: "?limit=2&offset=2", status: http.StatusOK, want: all[2:4]},
+		{name: "last page", query: "?limit=2&offset=4", status: http.StatusOK, want: all[4:]},
+		{name: "past the end", query: "?offset=5", status: http.StatusOK, want: []string{}},
+		{name: "zero limit", query: "?limit=0", status: http.StatusOK, want: []string{}},
+		{name: "negative limit", query: "?limit=-1", status: http.StatusBadRequest},
+		{name: "invalid offset", query: "?offset=first", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, ids := get(t, "/promo/"+promoID+"/history"+tt.query)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != "5" {
+				t.Errorf("X-Total-Count = %q, want %q", got, "5")
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("changes = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	for target, status := range map[string]int{
+		"/promo/" + foreignID + "/history":                    http.StatusForbidden,
+		"/promo/00000000-0000-0000-0000-000000000000/history": http.StatusNotFound,
+	} {
+		if rec, _ := get(t, target); rec.Code != status {
+			t.Errorf("GET %s: status %d, want %d", target, rec.Code, status)
+		}
+	}
+}
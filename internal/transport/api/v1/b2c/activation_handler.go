@@ -0,0 +1,53 @@
+package b2c
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"solution/internal/shared/apperr"
+)
+
+func (h *Handler) ActivatePromo(c *gin.Context) {
+	promoID := c.Param("id")
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.Activation.ActivatePromo(c.Request.Context(), promoID, userID)
+	if err != nil {
+		c.Error(notFound(err, "Promo not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetActivationHistory возвращает промокоды, активированные пользователем, от последней активации
+func (h *Handler) GetActivationHistory(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 0 {
+		c.Error(apperr.Validation(apperr.Field("limit", "limit must be a non-negative integer")))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.Error(apperr.Validation(apperr.Field("offset", "offset must be a non-negative integer")))
+		return
+	}
+
+	promos, totalCount, err := h.Activation.GetActivationHistory(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(totalCount, 10))
+	c.JSON(http.StatusOK, promos)
+}
@@ -1,11 +1,9 @@
 package b2c
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
-	"log"
 	"net/http"
-	"solution/internal/service/b2c"
+	"solution/internal/shared/apperr"
 	"solution/internal/shared/models/b2c/dto"
 	"strings"
 )
@@ -13,28 +11,20 @@ import (
 func (h *Handler) SignUp(c *gin.Context) {
 	var req dto.SignUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error parsing request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Binding(err))
 		return
 	}
 
 	req.Email = strings.ToLower(req.Email)
 
 	if err := req.Validate(); err != nil {
-		log.Println("Validation error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Validation(err))
 		return
 	}
 
-	token, userID, err := h.Auth.RegisterUser(req)
+	token, userID, err := h.Auth.RegisterUser(c.Request.Context(), req)
 	if err != nil {
-		if errors.Is(err, b2c.ErrEmailAlreadyRegistered) {
-			log.Println("Email already registered:", req.Email)
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
-		} else {
-			log.Println("Internal server error:", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad req"})
-		}
+		c.Error(err)
 		return
 	}
 
@@ -47,28 +37,20 @@ func (h *Handler) SignUp(c *gin.Context) {
 func (h *Handler) SignIn(c *gin.Context) {
 	var req dto.SignInRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error parsing request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Binding(err))
 		return
 	}
 
 	req.Email = strings.ToLower(req.Email)
 
 	if err := req.Validate(); err != nil {
-		log.Println("Error parsing request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.Validation(err))
 		return
 	}
 
-	token, err := h.Auth.AuthenticateUser(req)
+	token, err := h.Auth.AuthenticateUser(c.Request.Context(), req)
 	if err != nil {
-		if errors.Is(err, dto.ErrInvalidCredentials) {
-			log.Println("Error parsing request:", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		} else {
-			log.Println("Error parsing request:", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "BadRequest."})
-		}
+		c.Error(err)
 		return
 	}
 
@@ -2,7 +2,9 @@ package b2c
 
 import (
 	"github.com/gin-gonic/gin"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
 	"solution/internal/service/b2c"
 	"solution/internal/service/services"
 	"solution/internal/transport/api/v1/b2c/middleware"
@@ -11,12 +13,15 @@ import (
 type UserHandler interface {
 	Route(r *gin.Engine)
 	RouteUserAuth(r *gin.Engine)
+	// DispatchedRoutes - маршруты без собственной регистрации в gin, их разбирает обработчик соседнего маршрута
+	DispatchedRoutes() []gin.RouteInfo
 }
 
 type Handler struct {
-	Auth    b2c.AuthService
-	Profile b2c.ProfileService
-	Promo   b2c.PromoService
+	Auth       b2c.AuthService
+	Profile    b2c.ProfileService
+	Promo      b2c.PromoService
+	Activation b2c.ActivationService
 }
 
 func NewHandler() *Handler {
@@ -24,17 +29,26 @@ func NewHandler() *Handler {
 
 	err := services.GetService(&h.Auth)
 	if err != nil {
-		log.Fatalf("Failed to get AuthService: %v", err)
+		slog.Error("Failed to get AuthService", "error", err)
+		os.Exit(1)
 	}
 
 	err = services.GetService(&h.Profile)
 	if err != nil {
-		log.Fatalf("Failed to get ProfileService: %v", err)
+		slog.Error("Failed to get ProfileService", "error", err)
+		os.Exit(1)
 	}
 
 	err = services.GetService(&h.Promo)
 	if err != nil {
-		log.Fatalf("Failed to get PromoService: %v", err)
+		slog.Error("Failed to get PromoService", "error", err)
+		os.Exit(1)
+	}
+
+	err = services.GetService(&h.Activation)
+	if err != nil {
+		slog.Error("Failed to get ActivationService", "error", err)
+		os.Exit(1)
 	}
 
 	return h
@@ -71,11 +85,14 @@ func (h *Handler) RouteUserPromo(r *gin.Engine) {
 		user.Use(middleware.AuthMiddleware())
 
 		user.GET("/feed", h.GetPromosForUser)
+		user.GET("/search", h.SearchPromos)
 
 		promo := user.Group("/promo")
 		promo.Use(middleware.AuthMiddleware())
 		{
+			// GET history обслуживает GetPromo: gin 1.6 не регистрирует статический сегмент рядом с :id
 			promo.GET(":id", h.GetPromo)
+			promo.POST(":id/activate", h.ActivatePromo)
 			promo.POST(":id/like", h.LikePromo)
 			promo.DELETE(":id/like", h.UnlikePromo)
 			comments := promo.Group(":id/comments")
@@ -96,3 +113,9 @@ func (h *Handler) RouteUserPromo(r *gin.Engine) {
 
 	}
 }
+
+func (h *Handler) DispatchedRoutes() []gin.RouteInfo {
+	return []gin.RouteInfo{
+		{Method: http.MethodGet, Path: "/api/user/promo/history", Handler: "GetActivationHistory"},
+	}
+}
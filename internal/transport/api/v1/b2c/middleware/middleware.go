@@ -1,12 +1,12 @@
 package middleware
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
-	"net/http"
+	"log/slog"
 	repo "solution/internal/repository/b2c"
 	"solution/internal/service/services"
-	"solution/internal/shared/models/b2b/dto"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models"
 	"solution/internal/shared/utils"
 	"strings"
 )
@@ -15,30 +15,26 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
 		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
 		userId := claims.UserID
 		if userId == "" {
-			fmt.Println("Invalid user_id in claims")
-			c.JSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
-			c.Abort()
+			slog.WarnContext(c.Request.Context(), "Invalid user_id in claims")
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
@@ -46,23 +42,35 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		err = services.GetService(&repository)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			abort(c, err)
 			return
 		}
 
-		existingToken, err := repository.ValidateToken(userId)
+		existingToken, err := repository.ValidateToken(c.Request.Context(), userId)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
 		if existingToken != tokenString {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorUnauthorized)
+			abort(c, apperr.ErrUnauthorized)
 			return
 		}
 
-		c.Set("user_id", userId)
+		principal, err := models.CurrentPrincipal(c.Request.Context())
+		if err != nil {
+			abort(c, err)
+			return
+		}
+
+		principal.UserID = userId
 
 		c.Next()
 	}
 }
+
+// abort прерывает цепочку обработчиков, ответ с ошибкой пишет общий ErrorMiddleware
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -2,51 +2,73 @@ package b2c
 
 import (
 	"errors"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/etag"
 	"solution/internal/shared/models/b2c/dto"
 )
 
 func (h *Handler) GetProfile(c *gin.Context) {
-	userID := c.GetString("user_id")
-	fmt.Println(userID)
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 
-	profile, err := h.Profile.GetProfile(userID)
+	profile, err := h.Profile.GetProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(profileError(err))
+		return
+	}
+
+	tag := etag.Format(profile.Version)
+	c.Header("ETag", tag)
+	if etag.NoneMatch(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
 	c.JSON(http.StatusOK, profile)
 }
 
+// UpdateProfile принимает тело как JSON Merge Patch (RFC 7396): null очищает поле, проверяется результат слияния
 func (h *Handler) UpdateProfile(c *gin.Context) {
-	userID := c.GetString("user_id")
-
-	var req dto.ProfileUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	userID, ok := currentUser(c)
+	if !ok {
 		return
 	}
 
-	if err := req.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.Error(apperr.Binding(err))
 		return
 	}
 
-	err := h.Profile.UpdateProfile(userID, req)
+	match, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
 	if err != nil {
-		if !errors.Is(err, dto.ErrNoFieldsToUpdate) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
+		c.Error(err)
+		return
+	}
+
+	err = h.Profile.UpdateProfile(c.Request.Context(), userID, patch, match)
+	if err != nil && !errors.Is(err, dto.ErrNoFieldsToUpdate) {
+		c.Error(profileError(err))
+		return
 	}
-	currentProfile, err := h.Profile.GetProfile(userID)
+	currentProfile, err := h.Profile.GetProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(profileError(err))
 		return
 	}
 
+	c.Header("ETag", etag.Format(currentProfile.Version))
 	c.JSON(http.StatusOK, currentProfile)
 }
+
+// profileError: профиль пользователя с действующим токеном не найден - токен больше не авторизует
+func profileError(err error) error {
+	if errors.Is(err, dto.ErrNotFound) {
+		return apperr.Wrap(apperr.CodeUnauthorized, apperr.ErrUnauthorized.Message, err)
+	}
+	return err
+}
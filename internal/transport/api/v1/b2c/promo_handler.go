@@ -5,47 +5,50 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"log"
 	"net/http"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/models"
 	"solution/internal/shared/models/b2c/dto"
 	"strconv"
 )
 
 func (h *Handler) GetPromosForUser(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 	limitStr := c.DefaultQuery("limit", "10")
 	offsetStr := c.DefaultQuery("offset", "0")
 	category := c.Query("category")
 	activeStr := c.Query("active")
+	sortBy := c.DefaultQuery("sort", dto.FeedSortRecent)
+
+	if sortBy != dto.FeedSortRecent && sortBy != dto.FeedSortRelevance {
+		c.Error(apperr.Validation(apperr.Field("sort", fmt.Sprintf("sort must be either '%s' or '%s'", dto.FeedSortRecent, dto.FeedSortRelevance))))
+		return
+	}
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
-		log.Println("Error parsing limit:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		c.Error(apperr.Validation(apperr.Field("limit", "limit must be an integer")))
 		return
 	}
 
 	offset, err := strconv.Atoi(offsetStr)
 	if err != nil {
-		log.Println("Error parsing offset:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+		c.Error(apperr.Validation(apperr.Field("offset", "offset must be an integer")))
 		return
 	}
 
-	var active *bool
-	if activeStr != "" {
-		activeValue, err := strconv.ParseBool(activeStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'active' parameter"})
-			return
-		}
-		active = &activeValue
+	active, err := parseActive(activeStr)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	promos, totalCount, err := h.Promo.GetPromosForUser(userID, limit, offset, category, active)
+	promos, totalCount, err := h.Promo.GetPromosForUser(c.Request.Context(), userID, limit, offset, category, active, sortBy)
 	if err != nil {
-		log.Println("Error getting promos:", err)
-		c.JSON(http.StatusBadRequest, dto.ErrBadRequest)
+		c.Error(err)
 		return
 	}
 
@@ -57,19 +60,19 @@ func (h *Handler) GetPromosForUser(c *gin.Context) {
 
 func (h *Handler) GetPromo(c *gin.Context) {
 	promoID := c.Param("id")
-	userID := c.GetString("user_id")
+	if promoID == "history" {
+		h.GetActivationHistory(c)
+		return
+	}
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 
-	promo, err := h.Promo.GetPromo(promoID, userID)
+	promo, err := h.Promo.GetPromo(c.Request.Context(), promoID, userID)
 	if err != nil {
-		if errors.Is(err, dto.ErrNotFound) {
-			log.Println("Promo not found:", err)
-			c.JSON(http.StatusNotFound, dto.ErrNotFound)
-			return
-		} else {
-			log.Println("Error getting promo:", err)
-			c.JSON(http.StatusBadRequest, dto.ErrNotFound)
-			return
-		}
+		c.Error(notFound(err, "Promo not found"))
+		return
 	}
 
 	c.JSON(http.StatusOK, promo)
@@ -77,19 +80,15 @@ func (h *Handler) GetPromo(c *gin.Context) {
 
 func (h *Handler) LikePromo(c *gin.Context) {
 	promoID := c.Param("id")
-	userID := c.GetString("user_id")
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 
-	err := h.Promo.LikePromo(promoID, userID)
+	err := h.Promo.LikePromo(c.Request.Context(), promoID, userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, dto.ErrNotFound) {
-			log.Println("Promo not found:", err)
-			c.JSON(http.StatusNotFound, dto.ErrNotFound)
-			return
-		} else {
-			log.Println("Error liking promo:", err)
-			c.JSON(http.StatusBadRequest, dto.ErrBadRequest)
-			return
-		}
+		c.Error(notFound(err, "Promo not found"))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -97,19 +96,15 @@ func (h *Handler) LikePromo(c *gin.Context) {
 
 func (h *Handler) UnlikePromo(c *gin.Context) {
 	promoID := c.Param("id")
-	userID := c.GetString("user_id")
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 
-	err := h.Promo.UnlikePromo(promoID, userID)
+	err := h.Promo.UnlikePromo(c.Request.Context(), promoID, userID)
 	if err != nil {
-		if errors.Is(err, dto.ErrNotFound) {
-			log.Println("Promo not found:", err)
-			c.JSON(http.StatusNotFound, dto.ErrNotFound)
-			return
-		} else {
-			log.Println("Error unliking promo:", err)
-			c.JSON(http.StatusBadRequest, dto.ErrBadRequest)
-			return
-		}
+		c.Error(notFound(err, "Promo not found"))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -117,33 +112,27 @@ func (h *Handler) UnlikePromo(c *gin.Context) {
 
 // AddComment добавляет комментарий к промокоду
 func (h *Handler) AddComment(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 	promoID := c.Param("id")
 
 	var req dto.CommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error binding comment request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		c.Error(apperr.Binding(err))
 		return
 	}
 
 	// Проверяем длину текста комментария
 	if err := req.Validate(); err != nil {
-		log.Println("Error validating comment:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Text length must be between 10 and 1000 characters"})
-
+		c.Error(apperr.Validation(err))
 		return
 	}
 
-	comment, err := h.Promo.AddComment(userID, promoID, req.Text)
+	comment, err := h.Promo.AddComment(c.Request.Context(), userID, promoID, req.Text)
 	if err != nil {
-		if errors.Is(err, dto.ErrNotFound) {
-			log.Println("Promo not found:", err)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Promo is not found"})
-			return
-		}
-		log.Println("Error adding comment:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to add comment"})
+		c.Error(notFound(err, "Promo not found"))
 		return
 	}
 
@@ -155,15 +144,9 @@ func (h *Handler) GetComments(c *gin.Context) {
 	limit := c.DefaultQuery("limit", "10")
 	offset := c.DefaultQuery("offset", "0")
 
-	comments, totalCount, err := h.Promo.GetComments(promoID, limit, offset)
+	comments, totalCount, err := h.Promo.GetComments(c.Request.Context(), promoID, limit, offset)
 	if err != nil {
-		if errors.Is(err, dto.ErrNotFound) {
-			log.Println("Promo not found:", err)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Promo not found"})
-			return
-		}
-		log.Println("Error getting comments:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get comments"})
+		c.Error(notFound(err, "Promo not found"))
 		return
 	}
 
@@ -176,15 +159,9 @@ func (h *Handler) GetComment(c *gin.Context) {
 	promoID := c.Param("id")
 	commentID := c.Param("comment_id")
 
-	comment, err := h.Promo.GetComment(promoID, commentID)
+	comment, err := h.Promo.GetComment(c.Request.Context(), promoID, commentID)
 	if err != nil {
-		if errors.Is(err, dto.ErrNotFound) {
-			log.Println("Comment not found:", err)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
-			return
-		}
-		log.Println("Error getting comment:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get comment"})
+		c.Error(notFound(err, "Comment not found"))
 		return
 	}
 
@@ -193,31 +170,22 @@ func (h *Handler) GetComment(c *gin.Context) {
 
 // EditComment редактирует текст комментария
 func (h *Handler) EditComment(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 	promoID := c.Param("id")
 	commentID := c.Param("comment_id")
 
 	var req dto.CommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error binding comment request:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		c.Error(apperr.Binding(err))
 		return
 	}
 
-	comment, err := h.Promo.EditComment(userID, promoID, commentID, req.Text)
+	comment, err := h.Promo.EditComment(c.Request.Context(), userID, promoID, commentID, req.Text)
 	if err != nil {
-		if errors.Is(err, dto.ErrNotFound) {
-			log.Println("Comment not found:", err)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
-			return
-		}
-		if errors.Is(err, dto.ErrNoAccess) {
-			log.Println("No access to edit this comment:", err)
-			c.JSON(http.StatusForbidden, gin.H{"error": "No access to edit this comment"})
-			return
-		}
-		log.Println("Error editing comment:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to edit comment"})
+		c.Error(notFound(err, "Comment not found"))
 		return
 	}
 
@@ -226,26 +194,52 @@ func (h *Handler) EditComment(c *gin.Context) {
 
 // DeleteComment удаляет комментарий
 func (h *Handler) DeleteComment(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
 	promoID := c.Param("id")
 	commentID := c.Param("comment_id")
 
-	err := h.Promo.DeleteComment(userID, promoID, commentID)
+	err := h.Promo.DeleteComment(c.Request.Context(), userID, promoID, commentID)
 	if err != nil {
-		if errors.Is(err, dto.ErrNotFound) {
-			log.Println("Comment not found:", err)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
-			return
-		}
-		if errors.Is(err, dto.ErrNoAccess) {
-			log.Println("No access to delete this comment:", err)
-			c.JSON(http.StatusForbidden, gin.H{"error": "No access to delete this comment"})
-			return
-		}
-		log.Println("Error deleting comment:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to delete comment"})
+		c.Error(notFound(err, "Comment not found"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
+
+// currentUser возвращает ID пользователя из субъекта запроса, заполненного AuthMiddleware.
+// Ошибку сразу передаёт в c.Error.
+func currentUser(c *gin.Context) (string, bool) {
+	principal, err := models.CurrentPrincipal(c.Request.Context())
+	if err == nil && !principal.IsUser() {
+		err = apperr.ErrUnauthorized
+	}
+	if err != nil {
+		c.Error(err)
+		return "", false
+	}
+	return principal.UserID, true
+}
+
+// notFound уточняет сообщение для отсутствующей записи: одна и та же ErrNotFound
+// означает и промокод, и комментарий
+func notFound(err error, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, dto.ErrNotFound) {
+		return apperr.Wrap(apperr.CodeNotFound, message, err)
+	}
+	return err
+}
+
+func parseActive(value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	active, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, apperr.Validation(apperr.Field("active", "active must be a boolean"))
+	}
+	return &active, nil
+}
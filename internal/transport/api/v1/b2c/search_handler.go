@@ -0,0 +1,57 @@
+package b2c
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"solution/internal/shared/apperr"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxSearchQueryLength = 100
+
+func (h *Handler) SearchPromos(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	query := strings.TrimSpace(c.Query("q"))
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+	activeStr := c.Query("active")
+
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQueryLength {
+		c.Error(apperr.Validation(apperr.Field("q", fmt.Sprintf("q must be between 1 and %d characters long", maxSearchQueryLength))))
+		return
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		c.Error(apperr.Validation(apperr.Field("limit", "limit must be a non-negative integer")))
+		return
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		c.Error(apperr.Validation(apperr.Field("offset", "offset must be a non-negative integer")))
+		return
+	}
+
+	active, err := parseActive(activeStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results, totalCount, err := h.Promo.SearchPromos(c.Request.Context(), userID, query, limit, offset, active)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(totalCount, 10))
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"solution/internal/shared/apperr"
+)
+
+const problemContentType = "application/problem+json"
+
+// ErrorResponse - формат ошибки из api.yml, расширенный кодом и ошибками полей
+type ErrorResponse struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Code    apperr.Code          `json:"code"`
+	Fields  []*apperr.FieldError `json:"errors,omitempty"`
+}
+
+// ProblemResponse - RFC 7807, отдаётся клиентам, запросившим application/problem+json
+type ProblemResponse struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Detail   string               `json:"detail"`
+	Instance string               `json:"instance,omitempty"`
+	Code     apperr.Code          `json:"code"`
+	Fields   []*apperr.FieldError `json:"errors,omitempty"`
+}
+
+// ErrorMiddleware отвечает на ошибку, добавленную обработчиком через c.Error, если ответ ещё не записан.
+// Клиентские ошибки пишутся в лог с уровнем warn, серверные - error.
+func ErrorMiddleware(c *gin.Context) {
+	c.Next()
+
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	err := c.Errors.Last().Err
+	appErr := apperr.From(err)
+	status := appErr.Status()
+
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(c.Request.Context(), level, "Request failed", "code", appErr.Code, "error", err)
+
+	writeError(c, appErr)
+}
+
+func writeError(c *gin.Context, appErr *apperr.Error) {
+	status := appErr.Status()
+
+	if acceptsProblem(c.GetHeader("Accept")) {
+		c.Render(status, problemRender{ProblemResponse{
+			Type:     "about:blank",
+			Title:    http.StatusText(status),
+			Status:   status,
+			Detail:   appErr.Message,
+			Instance: c.Request.URL.Path,
+			Code:     appErr.Code,
+			Fields:   appErr.Fields,
+		}})
+		return
+	}
+
+	c.JSON(status, ErrorResponse{
+		Status:  "error",
+		Message: appErr.Message,
+		Code:    appErr.Code,
+		Fields:  appErr.Fields,
+	})
+}
+
+func acceptsProblem(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.Split(part, ";")[0])
+		if strings.EqualFold(mediaType, problemContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+type problemRender struct {
+	problem ProblemResponse
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.problem)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", problemContentType)
+}
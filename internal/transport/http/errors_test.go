@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"solution/internal/shared/apperr"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+	fieldErr := apperr.Validation(errors.Join(
+		apperr.Field("max_count", "must be between 0 and 100000000"),
+		apperr.Nest("target", apperr.Field("age_from", "must not be greater than age_until")),
+	))
+
+	tests := []struct {
+		name        string
+		err         error
+		accept      string
+		status      int
+		contentType string
+		want        string
+	}{
+		{
+			name:        "app error as json",
+			err:         apperr.ErrNotFound,
+			status:      http.StatusNotFound,
+			contentType: "application/json; charset=utf-8",
+			want:        `{"status":"error","message":"Ресурс не найден.","code":"not_found"}`,
+		},
+		{
+			name:        "field errors as json",
+			err:         fieldErr,
+			accept:      "application/json",
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			want: `{"status":"error","message":"Ошибка в данных запроса.","code":"validation_failed","errors":[` +
+				`{"field":"max_count","message":"must be between 0 and 100000000"},` +
+				`{"field":"target.age_from","message":"must not be greater than age_until"}]}`,
+		},
+		{
+			name:        "problem json",
+			err:         apperr.ErrNotFound,
+			accept:      "application/problem+json",
+			status:      http.StatusNotFound,
+			contentType: "application/problem+json",
+			want:        `{"type":"about:blank","title":"Not Found","status":404,"detail":"Ресурс не найден.","instance":"/fail","code":"not_found"}`,
+		},
+		{
+			name:        "problem json among other types",
+			err:         fieldErr,
+			accept:      "application/json;q=0.9, Application/Problem+JSON; q=1",
+			status:      http.StatusBadRequest,
+			contentType: "application/problem+json",
+			want: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Ошибка в данных запроса.","instance":"/fail",` +
+				`"code":"validation_failed","errors":[` +
+				`{"field":"max_count","message":"must be between 0 and 100000000"},` +
+				`{"field":"target.age_from","message":"must not be greater than age_until"}]}`,
+		},
+		{
+			name:        "internal error hides the cause",
+			err:         errors.New("pq: connection refused"),
+			status:      http.StatusInternalServerError,
+			contentType: "application/json; charset=utf-8",
+			want:        `{"status":"error","message":"Ошибка сервера.","code":"internal"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorMiddleware)
+			router.GET("/fail", func(c *gin.Context) { c.Error(tt.err) })
+
+			req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !jsonEqual(t, rec.Body.Bytes(), []byte(tt.want)) {
+				t.Errorf("body = %s, want %s", rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMiddlewareKeepsWrittenResponse(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorMiddleware)
+	router.GET("/written", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "done")
+		c.Error(apperr.ErrInternal)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/written", nil))
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "done" {
+		t.Errorf("response = %d %q, want the handler response", rec.Code, rec.Body)
+	}
+}
+
+func jsonEqual(t *testing.T, got, want []byte) bool {
+	t.Helper()
+
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("decode %s: %v", got, err)
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatalf("decode %s: %v", want, err)
+	}
+	gotJSON, _ := json.Marshal(gotValue)
+	wantJSON, _ := json.Marshal(wantValue)
+	return string(gotJSON) == string(wantJSON)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/logger"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware берёт X-Request-ID клиента или генерирует новый,
+// возвращает его в ответе и кладёт в контекст запроса для логов сервисов и БД
+func RequestIDMiddleware(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if !isValidRequestID(id) {
+		id = uuid.NewString()
+	}
+
+	ctx := logger.WithRequestID(c.Request.Context(), id)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id))
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Header(requestIDHeader, id)
+
+	c.Next()
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLogMiddleware пишет по записи на запрос. Query не логируется: в нём бывают коды и поисковые запросы.
+func AccessLogMiddleware(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	slog.Log(c.Request.Context(), level, "HTTP request",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"route", c.FullPath(),
+		"status", status,
+		"duration", time.Since(start),
+		"client_ip", c.ClientIP(),
+	)
+}
+
+// RecoveryMiddleware превращает панику обработчика в 500 с записью в лог, ответ пишет ErrorMiddleware
+func RecoveryMiddleware(c *gin.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(c.Request.Context(), "Panic while handling request",
+				"panic", r,
+				"stack", string(debug.Stack()),
+			)
+			c.Error(apperr.ErrInternal)
+			c.Abort()
+		}
+	}()
+
+	c.Next()
+}
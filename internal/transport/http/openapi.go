@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/openapi"
+)
+
+const (
+	specPath = openapi.BasePath + "/openapi.yml"
+	docsPath = openapi.BasePath + "/docs"
+)
+
+// swaggerUIPage - Swagger UI из CDN поверх спецификации, которую отдаёт сам сервер
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>Promo Code API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "` + specPath + `", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+func specHandler(spec *openapi.Spec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/yaml", spec.Raw())
+	}
+}
+
+func docsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// RequestValidationMiddleware отклоняет запросы, не соответствующие api.yml, до авторизации и обработчиков
+func RequestValidationMiddleware(spec *openapi.Spec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := spec.ValidateRequest(c.Request); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ResponseValidationMiddleware буферизует ответы на описанные в api.yml запросы и сверяет их со спецификацией.
+// Ответ, нарушающий контракт, заменяется ошибкой 500, чтобы расхождение не осталось незамеченным.
+// Должен стоять до ErrorMiddleware, чтобы проверялись и ответы с ошибками.
+func ResponseValidationMiddleware(spec *openapi.Spec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !spec.Documents(c.Request) {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if err := spec.ValidateResponse(c.Request, w.status, w.Header(), w.body.Bytes()); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Response does not match OpenAPI spec", "status", w.status, "error", err)
+			c.Writer.Header().Del("Content-Type")
+			writeError(c, apperr.ErrInternal)
+			return
+		}
+		w.flush()
+	}
+}
+
+// bufferedWriter придерживает статус и тело ответа до проверки; заголовки пишутся сразу в исходный writer,
+// но уходят клиенту только при flush
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush ничего не делает: потоковые ответы нельзя проверить целиком
+func (w *bufferedWriter) Flush() {}
+
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
@@ -3,40 +3,118 @@ package server
 import (
 	"context"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"solution/internal/service/services"
+	"solution/internal/shared/apperr"
+	"solution/internal/shared/config"
+	"solution/internal/shared/health"
+	"solution/internal/shared/logger"
+	"solution/internal/shared/metrics"
+	"solution/internal/shared/openapi"
+	"solution/internal/shared/tracing"
+	"solution/internal/transport/api/v1/admin"
 	"solution/internal/transport/api/v1/b2b"
 	"solution/internal/transport/api/v1/b2c"
+	"strings"
 )
 
 type Router interface {
 	RouteInit()
 	SetContext(ctx context.Context)
 	ContextMiddleware(c *gin.Context)
+	ScopeMiddleware(c *gin.Context)
 }
 
 type MainRouter struct {
-	router     *gin.Engine
-	ctx        context.Context
-	b2bHandler b2b.BusinessHandler
-	b2cHandler b2c.UserHandler
+	router       *gin.Engine
+	ctx          context.Context
+	b2bHandler   b2b.BusinessHandler
+	b2cHandler   b2c.UserHandler
+	adminHandler admin.AdminHandler
+	spec         *openapi.Spec
+	openapi      *config.OpenAPI
 }
 
-func NewRouter() *MainRouter {
+func NewRouter(checker *health.Checker, spec *openapi.Spec, openapiCfg *config.OpenAPI) *MainRouter {
+	return newRouter(checker, spec, openapiCfg, b2b.NewHandler(), b2c.NewHandler(), admin.NewHandler())
+}
+
+// newRouter собирает роутер с готовыми обработчиками. Обработчики берут сервисы из DI только
+// при обработке запроса, поэтому маршруты можно зарегистрировать и без контейнера.
+func newRouter(checker *health.Checker, spec *openapi.Spec, openapiCfg *config.OpenAPI, b2bHandler b2b.BusinessHandler, b2cHandler b2c.UserHandler, adminHandler admin.AdminHandler) *MainRouter {
+	// отладочный вывод gin (список маршрутов и т.п.) нужен только на уровне debug
+	if logger.Level() > slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	useJSONFieldNames()
+
 	router := &MainRouter{
-		router:     gin.Default(),
-		b2bHandler: b2b.NewHandler(),
-		b2cHandler: b2c.NewHandler(),
+		router:       gin.New(),
+		b2bHandler:   b2bHandler,
+		b2cHandler:   b2cHandler,
+		adminHandler: adminHandler,
+		spec:         spec,
+		openapi:      openapiCfg,
 	}
 
+	// служебные маршруты регистрируются до middleware, чтобы пробы не попадали в логи и метрики запросов
+	router.router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.router.GET("/healthz", gin.WrapH(checker.LivenessHandler()))
+	router.router.GET("/readyz", gin.WrapH(checker.ReadinessHandler()))
+	router.router.GET(specPath, specHandler(spec))
+	router.router.GET(docsPath, docsHandler)
+
 	return router
 }
 
 func (r *MainRouter) RouteInit() {
-	r.router.Use(r.ContextMiddleware)
+	r.router.Use(tracing.HTTPMiddleware, RequestIDMiddleware, AccessLogMiddleware, metrics.HTTPMiddleware)
+	if r.openapi.ValidateResponses {
+		r.router.Use(ResponseValidationMiddleware(r.spec))
+	}
+	r.router.Use(ErrorMiddleware, RecoveryMiddleware)
+	if r.openapi.ValidateRequests {
+		r.router.Use(RequestValidationMiddleware(r.spec))
+	}
+	r.router.Use(r.ContextMiddleware, r.ScopeMiddleware)
+	r.router.NoRoute(func(c *gin.Context) { c.Error(apperr.ErrNotFound) })
 
-	r.router.GET("api/ping", func(c *gin.Context) { c.String(200, "pong") })
+	r.router.GET("api/ping", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
 
 	r.b2bHandler.Route(r.router)
 	r.b2cHandler.Route(r.router)
+	r.adminHandler.Route(r.router)
+
+	r.checkSpec()
+}
+
+// Operations возвращает маршруты API в нотации api.yml, без служебных маршрутов
+func (r *MainRouter) Operations() []openapi.Operation {
+	var ops []openapi.Operation
+	routes := append(r.router.Routes(), r.b2cHandler.DispatchedRoutes()...)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, openapi.BasePath+"/") || route.Path == specPath || route.Path == docsPath {
+			continue
+		}
+		ops = append(ops, openapi.GinOperation(route.Method, route.Path))
+	}
+	return ops
+}
+
+// checkSpec предупреждает о маршрутах, которых нет в api.yml, и об операциях api.yml без маршрута
+func (r *MainRouter) checkSpec() {
+	undocumented, unimplemented := r.spec.Diff(r.Operations())
+	for _, op := range undocumented {
+		slog.Warn("Route is missing from OpenAPI spec", "route", op.String())
+	}
+	for _, op := range unimplemented {
+		slog.Warn("OpenAPI operation has no route", "operation", op.String())
+	}
 }
 
 func (r *MainRouter) SetContext(ctx context.Context) {
@@ -47,3 +125,38 @@ func (r *MainRouter) ContextMiddleware(c *gin.Context) {
 	c.Set("context", r.ctx)
 	c.Next()
 }
+
+// ScopeMiddleware создаёт область scoped сервисов на время запроса.
+// Сервисы разрешаются через services.GetServiceForContext(c.Request.Context(), ...),
+// а реализующие io.Closer закрываются после обработки запроса.
+func (r *MainRouter) ScopeMiddleware(c *gin.Context) {
+	ctx := services.NewServiceContext(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+
+	defer func() {
+		if err := services.CloseServiceContext(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to dispose request services", "error", err)
+		}
+	}()
+
+	c.Next()
+}
+
+// useJSONFieldNames заставляет валидатор gin называть поля как в JSON, чтобы ошибки binding
+// указывали на те же пути, что и ошибки Validate()
+func useJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
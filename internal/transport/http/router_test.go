@@ -0,0 +1,104 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"solution/internal/service/services"
+	"solution/internal/shared/config"
+	"solution/internal/shared/health"
+	"solution/internal/shared/openapi"
+	"solution/internal/transport/api/v1/admin"
+	"solution/internal/transport/api/v1/b2b"
+	"solution/internal/transport/api/v1/b2c"
+)
+
+// specFile - api.yml в корне репозитория относительно пакета
+const specFile = "../../../api.yml"
+
+func newTestRouter(t *testing.T) *MainRouter {
+	t.Helper()
+
+	spec, err := openapi.Load(specFile)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	r := newRouter(health.NewChecker(time.Second), spec, &config.OpenAPI{}, &b2b.Handler{}, &b2c.Handler{}, &admin.Handler{})
+	r.RouteInit()
+	return r
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	r := newTestRouter(t)
+
+	undocumented, unimplemented := r.spec.Diff(r.Operations())
+	for _, op := range undocumented {
+		t.Errorf("route %s is missing from api.yml", op)
+	}
+	for _, op := range unimplemented {
+		t.Errorf("api.yml operation %s has no route", op)
+	}
+}
+
+// Маршруты без собственной регистрации в gin должны доходить до обработчика, а не до NoRoute:
+// без токена обработчик отвечает 401, а не 404
+func TestDispatchedRoutesAreServed(t *testing.T) {
+	r := newTestRouter(t)
+
+	for _, route := range (&b2c.Handler{}).DispatchedRoutes() {
+		rec := httptest.NewRecorder()
+		r.router.ServeHTTP(rec, httptest.NewRequest(route.Method, route.Path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: status %d, want %d", route.Method, route.Path, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+// scopeProbe - scoped сервис, который запоминает своё закрытие
+type scopeProbe struct{ closed bool }
+
+func (p *scopeProbe) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestScopeMiddlewareClosesRequestServices(t *testing.T) {
+	if err := services.AddScoped(func() *scopeProbe { return &scopeProbe{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &MainRouter{router: gin.New()}
+	r.router.Use(r.ScopeMiddleware)
+
+	var probes []*scopeProbe
+	r.router.GET("/probe", func(c *gin.Context) {
+		for i := 0; i < 2; i++ {
+			var probe *scopeProbe
+			if err := services.GetServiceForContext(c.Request.Context(), &probe); err != nil {
+				t.Fatal(err)
+			}
+			if probe.closed {
+				t.Error("scoped service closed before the end of the request")
+			}
+			probes = append(probes, probe)
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	for i := 0; i < 2; i++ {
+		r.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/probe", nil))
+	}
+
+	if len(probes) != 4 || probes[0] != probes[1] || probes[2] != probes[3] || probes[0] == probes[2] {
+		t.Fatalf("want one scoped instance per request, got %v", probes)
+	}
+	for _, probe := range probes {
+		if !probe.closed {
+			t.Error("scoped service was not closed after the request")
+		}
+	}
+}
@@ -2,29 +2,82 @@ package server
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"solution/internal/shared/config"
+	"solution/internal/shared/health"
+	"solution/internal/shared/openapi"
+	"sync"
+	"time"
 )
 
 type Server struct {
-	addr         string
+	cfg          *config.Server
+	health       *health.Checker
+	drainDelay   time.Duration
 	serverRouter *MainRouter
+	routesOnce   sync.Once
 }
 
-func NewServer(cfg *config.Config) *Server {
+func NewServer(cfg *config.Config, checker *health.Checker, spec *openapi.Spec) *Server {
 	return &Server{
-		addr:         cfg.Server.Addr,
-		serverRouter: NewRouter(),
+		cfg:          cfg.Server,
+		health:       checker,
+		drainDelay:   cfg.Health.DrainDelay,
+		serverRouter: NewRouter(checker, spec, cfg.OpenAPI),
 	}
 }
 
+// Handler возвращает роутер со всеми маршрутами; маршруты регистрируются один раз.
+// Без StartHttpServer запросы получают фоновый контекст вместо контекста сервера.
+func (s *Server) Handler() http.Handler {
+	s.routesOnce.Do(func() {
+		if s.serverRouter.ctx == nil {
+			s.serverRouter.SetContext(context.Background())
+		}
+		s.serverRouter.RouteInit()
+	})
+	return s.serverRouter.router
+}
+
+// StartHttpServer обслуживает запросы до отмены ctx, после чего корректно завершает сервер.
+// Перед остановкой /readyz начинает отвечать 503 и сервер ждёт drainDelay, продолжая обслуживать запросы.
 func (s *Server) StartHttpServer(ctx context.Context) error {
 	s.serverRouter.SetContext(ctx)
 
-	s.serverRouter.RouteInit()
+	srv := &http.Server{
+		Addr:         s.cfg.Addr,
+		Handler:      s.Handler(),
+		ReadTimeout:  s.cfg.ReadTimeout,
+		WriteTimeout: s.cfg.WriteTimeout,
+		IdleTimeout:  s.cfg.IdleTimeout,
+	}
 
-	err := s.serverRouter.router.Run(s.addr)
-	if err != nil {
+	errCh := make(chan error, 1)
+	go func() {
+		if s.cfg.TLS.Enabled() {
+			errCh <- srv.ListenAndServeTLS(s.cfg.TLS.CertFile, s.cfg.TLS.KeyFile)
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
 		return err
+	case <-ctx.Done():
+		s.health.Drain()
+		if s.drainDelay > 0 {
+			slog.Info("Draining HTTP server", "delay", s.drainDelay)
+			time.Sleep(s.drainDelay)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
 	}
-	return nil
 }